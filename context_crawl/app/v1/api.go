@@ -11,4 +11,8 @@ import (
 func RegisterRoutes(router *gin.Engine) {
 	// 注册处理多个URL的接口
 	router.POST("/crawl", handler.HandleProcessURLs)
+	// 注册按游标获取下一页分块的接口
+	router.POST("/crawl/next", handler.HandleNextChunks)
+	// 注册按选择器抽取结构化数据的接口
+	router.POST("/extract", handler.HandleExtract)
 }
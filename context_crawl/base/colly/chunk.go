@@ -1,50 +1,58 @@
 package colly
 
 import (
-	"fmt"
 	"regexp"
-	"strings"
+	"strconv"
 
+	"context_crawl/base/sentence"
+	"context_crawl/base/tokenizer"
 	"context_crawl/types"
 )
 
+// rePageToken 页码标记，见types.PageMarker
+var rePageToken = regexp.MustCompile(`@PAGE_(\d+)@`)
+
+// 默认分块参数
+const (
+	DefaultChunkSize    = 500 // 分块大小上限
+	DefaultChunkOverlap = 0   // 相邻分块的重叠长度，默认不重叠，需要时通过请求的chunk_overlap开启
+)
+
 // ScoredChunker 实现了基于质量评分的文本分块器
 type ScoredChunker struct {
-	ScoreThreshold float64 // 分块质量分数阈值
+	ScoreThreshold float64      // 分块质量分数阈值
+	ChunkSize      int          // 分块大小上限，单位由Unit决定
+	Overlap        int          // 相邻分块重叠的长度（滑动窗口），单位由Unit决定
+	Unit           string       // 计数单位：rune（按字符）或 token（按cl100k_base编码的token）
+	Scorer         types.Scorer // 质量评分器，低于ScoreThreshold的分块被丢弃
 }
 
 // NewScoredChunker 创建一个新的ScoredChunker实例
 func NewScoredChunker(scoreThreshold float64) *ScoredChunker {
 	return &ScoredChunker{
 		ScoreThreshold: scoreThreshold,
+		ChunkSize:      DefaultChunkSize,
+		Overlap:        DefaultChunkOverlap,
+		Unit:           tokenizer.UnitRune,
+		Scorer:         NewDefaultScorer(),
 	}
 }
 
 // NewDefaultScoredChunker 创建一个默认的ScoredChunker实例，scoreThreshold为0.0
 func NewDefaultScoredChunker() *ScoredChunker {
-	return &ScoredChunker{
-		ScoreThreshold: 0.0,
-	}
+	return NewScoredChunker(0.0)
 }
 
 // Chunk 对文本进行分块处理，实现types.Chunker接口
 func (sc *ScoredChunker) Chunk(input types.Type) (types.Type, error) {
 	text := input.Text
-	chunkSize := 500 // 默认分块大小
-
-	// 定义Chunk结构体
-	type Chunk struct {
-		Text   string
-		Score  float64
-		IsCode bool
-	}
+	chunkSize, overlap, counter := sc.settings(input.Options)
 
-	var chunks []Chunk
+	var chunks []types.Chunk
 
 	// 先把占位符单独分离，防止被正则切句拆开
-	reCodePlaceholder := regexp.MustCompile(`@CODE_\d+@`)
-	segments := reCodePlaceholder.Split(text, -1)
-	placeholders := reCodePlaceholder.FindAllString(text, -1)
+	segments := reCodeToken.Split(text, -1)
+	placeholders := reCodeToken.FindAllString(text, -1)
 
 	// 使用输入Type中的代码映射
 	codeMap := input.CodeMap
@@ -52,47 +60,32 @@ func (sc *ScoredChunker) Chunk(input types.Type) (types.Type, error) {
 		codeMap = make(map[string]string)
 	}
 
+	page := 0 // 当前页码，文本中没有页码标记时始终为0
 	for i, segment := range segments {
-		segment = strings.TrimSpace(segment)
-		if segment != "" {
-			// 按句子切分普通文本
-			reSentence := regexp.MustCompile(`[^。！？.!?]+[。！？.!?]?`)
-			sentences := reSentence.FindAllString(segment, -1)
-
-			var current string
-			for _, s := range sentences {
-				s = strings.TrimSpace(s)
-				if s == "" {
-					continue
-				}
-
-				if len(current)+len(s) <= chunkSize {
-					current += s
-				} else {
-					if current != "" {
-						score := sc.chunkScore(current)
-						if score >= sc.ScoreThreshold {
-							chunks = append(chunks, Chunk{
-								Text:   current,
-								Score:  score,
-								IsCode: false,
-							})
-						}
-					}
-					current = s
-				}
+		// 按句子切分普通文本，并记录每个句子所在的页
+		var sentences []string
+		var pages []int
+		markers := rePageToken.FindAllStringSubmatch(segment, -1)
+		for j, part := range rePageToken.Split(segment, -1) {
+			for _, s := range sentence.Split(part) {
+				sentences = append(sentences, s)
+				pages = append(pages, page)
+			}
+			if j < len(markers) {
+				page, _ = strconv.Atoi(markers[j][1])
 			}
+		}
 
-			// 收尾
-			if current != "" {
-				score := sc.chunkScore(current)
-				if score >= sc.ScoreThreshold {
-					chunks = append(chunks, Chunk{
-						Text:   current,
-						Score:  score,
-						IsCode: false,
-					})
-				}
+		for _, current := range packSpans(sentences, chunkSize, overlap, counter) {
+			score := sc.chunkScore(current.text, input.Outlinks)
+			if score >= sc.ScoreThreshold {
+				chunks = append(chunks, types.Chunk{
+					Text:      stripAnchors(current.text),
+					Score:     score,
+					IsCode:    false,
+					PageStart: pages[current.first],
+					PageEnd:   pages[current.last],
+				})
 			}
 		}
 
@@ -100,50 +93,89 @@ func (sc *ScoredChunker) Chunk(input types.Type) (types.Type, error) {
 		if i < len(placeholders) {
 			ph := placeholders[i]
 			codeText := codeMap[ph]
-			chunks = append(chunks, Chunk{
-				Text:   codeText,
-				Score:  1.0,
-				IsCode: true,
+			chunks = append(chunks, types.Chunk{
+				Text:      codeText,
+				Score:     1.0,
+				IsCode:    true,
+				Lang:      input.CodeLang[ph],
+				PageStart: page,
+				PageEnd:   page,
 			})
 		}
 	}
 
+	chunks = collapseDuplicates(chunks, input.Url, !input.Options.KeepDuplicates)
+
 	// 如果没有分块，返回提示信息
 	if len(chunks) == 0 {
-		chunks = append(chunks, Chunk{
-			Text:   "查询结果为空，当前链接中无有效信息，请尝试其他关键词或者其他链接。",
+		chunks = append(chunks, types.Chunk{
+			Text:   types.EmptyChunkText,
 			Score:  0.0,
 			IsCode: false,
 		})
 	}
 
 	// 格式化分块结果
-	var organizedText strings.Builder
-	for i, chunk := range chunks {
-		organizedText.WriteString(fmt.Sprintf("### chunk %d (recall_score:%.3f is_code:%t):\n", i+1, chunk.Score, chunk.IsCode))
-		organizedText.WriteString(chunk.Text + "\n\n")
+	result := types.Type{
+		Url:      input.Url,
+		Text:     types.FormatChunks(chunks),
+		Options:  input.Options,
+		Outlinks: input.Outlinks,
+		Images:   input.Images,
+		Chunks:   chunks,
+		Metadata: input.Metadata,
+		Toc:      input.Toc,
 	}
-
-	return types.Type{
-		Url:  input.Url,
-		Text: organizedText.String(),
-	}, nil
+	if input.Options.Summary {
+		result.Summary, result.Keywords = summarize(stripAnchors(input.Text))
+	}
+	return result, nil
 }
 
-// chunkScore chunk 质量评分函数（私有方法）
-func (sc *ScoredChunker) chunkScore(text string) float64 {
-	textLen := len(strings.TrimSpace(text))
-	if textLen == 0 {
-		return 0
+// settings 返回本次分块使用的大小、重叠长度和计数方式，请求选项优先于分块器配置
+func (sc *ScoredChunker) settings(options types.Options) (int, int, tokenizer.Counter) {
+	chunkSize, overlap, unit := sc.ChunkSize, sc.Overlap, sc.Unit
+	if options.ChunkSize > 0 {
+		chunkSize = options.ChunkSize
 	}
+	if options.ChunkOverlap != nil {
+		overlap = *options.ChunkOverlap
+	}
+	if options.ChunkUnit != "" {
+		unit = options.ChunkUnit
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	// 重叠不超过分块大小的一半，保证窗口能向前推进
+	if overlap > chunkSize/2 {
+		overlap = chunkSize / 2
+	}
+	if overlap < 0 {
+		overlap = 0
+	}
+	return chunkSize, overlap, anchorBlindCounter{tokenizer.NewCounter(unit)}
+}
 
-	reAlpha := regexp.MustCompile(`[A-Za-z0-9]`)
-	alphaCount := len(reAlpha.FindAllString(text, -1))
-	reCJK := regexp.MustCompile(`[\p{Han}]`)
-	cjkCount := len(reCJK.FindAllString(text, -1))
-	score := float64(cjkCount+alphaCount) / float64(textLen)
-	if score > 1.0 {
-		score = 1.0
+// anchorBlindCounter 计数时忽略锚文本标记，标记不占分块大小
+type anchorBlindCounter struct {
+	tokenizer.Counter
+}
+
+// Count 返回去掉锚文本标记后的长度
+func (c anchorBlindCounter) Count(text string) int {
+	return c.Counter.Count(stripAnchors(text))
+}
+
+// chunkScore chunk 质量评分函数（私有方法），未设置Scorer时使用默认评分器
+// 评分器实现了types.LinkScorer时传入页面的outlinks和带锚文本标记的文本，其余评分器只拿到去掉标记的文本
+func (sc *ScoredChunker) chunkScore(text string, outlinks []types.Outlink) float64 {
+	scorer := sc.Scorer
+	if scorer == nil {
+		scorer = defaultScorer
+	}
+	if ls, ok := scorer.(types.LinkScorer); ok {
+		return ls.ScoreWithLinks(text, outlinks)
 	}
-	return score
+	return scorer.Score(stripAnchors(text))
 }
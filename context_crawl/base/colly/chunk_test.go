@@ -0,0 +1,87 @@
+package colly
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestChunkPageRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunker types.Chunker
+		text    string
+		size    int
+		codeMap map[string]string
+		want    []chunkView
+	}{
+		{
+			name:    "scored chunk spans a page marker",
+			chunker: NewDefaultScoredChunker(),
+			text:    "@PAGE_1@\nThe method reads the input. It starts on the first page.\n@PAGE_2@\nIt ends on the second page.\n@PAGE_3@\nThe results follow on the third page.",
+			size:    100,
+			want: []chunkView{
+				{Text: "The method reads the input. It starts on the first page. It ends on the second page.", PageStart: 1, PageEnd: 2},
+				{Text: "The results follow on the third page.", PageStart: 3, PageEnd: 3},
+			},
+		},
+		{
+			name:    "scored code block takes the page it appears on",
+			chunker: NewDefaultScoredChunker(),
+			text:    "@PAGE_1@\nSet it up first.\n@PAGE_2@\nThen run:\n@CODE_0@\nDone.",
+			codeMap: map[string]string{"@CODE_0@": "make test"},
+			want: []chunkView{
+				{Text: "Set it up first. Then run:", PageStart: 1, PageEnd: 2},
+				{Text: "make test", IsCode: true, PageStart: 2, PageEnd: 2},
+				{Text: "Done.", PageStart: 2, PageEnd: 2},
+			},
+		},
+		{
+			name:    "scored chunks without page markers",
+			chunker: NewDefaultScoredChunker(),
+			text:    "No pages here.",
+			want:    []chunkView{{Text: "No pages here."}},
+		},
+		{
+			name:    "section chunk spans a page marker",
+			chunker: NewSectionChunker(0),
+			text:    "@PAGE_1@\n# Intro\nfirst page\n@PAGE_2@\nsecond page\n@CODE_0@\n# Method\n@PAGE_3@\nthird page",
+			codeMap: map[string]string{"@CODE_0@": "x := 1\ny := 2"},
+			want: []chunkView{
+				{Text: "first page\nsecond page", Path: "Intro", PageStart: 1, PageEnd: 2},
+				{Text: "x := 1\ny := 2", Path: "Intro", IsCode: true, PageStart: 2, PageEnd: 2},
+				{Text: "third page", Path: "Method", PageStart: 3, PageEnd: 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := types.Type{
+				Url:     "https://example.com/paper.pdf",
+				Text:    tt.text,
+				CodeMap: tt.codeMap,
+				Options: types.Options{ChunkSize: tt.size},
+			}
+			result, err := tt.chunker.Chunk(input)
+			if err != nil {
+				t.Fatalf("Chunk() error = %v", err)
+			}
+			got := make([]chunkView, 0, len(result.Chunks))
+			for _, c := range result.Chunks {
+				got = append(got, chunkView{
+					Text:      c.Text,
+					Path:      strings.Join(c.HeadingPath, " > "),
+					IsCode:    c.IsCode,
+					Lang:      c.Lang,
+					PageStart: c.PageStart,
+					PageEnd:   c.PageEnd,
+				})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunk() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
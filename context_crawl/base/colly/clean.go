@@ -42,22 +42,87 @@ func (bc *BasicCleaner) Clean(input types.Type) (types.Type, error) {
 		html = strings.Replace(html, code, placeholder, 1)
 	}
 
+	// 提取图片：在去标签之前收集img及其上下文
+	var images []types.Image
+	var imageMap map[string]string
+	if input.Options.ExtractImages {
+		html, images, imageMap = extractImages(html, input.Url, input.Options.ImagePlaceholders)
+	}
+
+	// 保留超链接：在去标签之前把a标签替换为引用标记；不保留时仍标记锚文本，供评分计算链接密度
+	var outlinks []types.Outlink
+	var linkMap map[string]string
+	if input.Options.PreserveLinks {
+		html, outlinks, linkMap = preserveLinks(html, input.Url)
+	} else {
+		html, linkMap = hideLinks(html)
+	}
+
+	// 保留文档结构：标题转为 # 标记行，块级元素转为换行
+	html = markStructure(html)
+
 	// 去掉 HTML 标签
 	reHTML := regexp.MustCompile(`(?s)<[^>]*>`)
 	html = reHTML.ReplaceAllString(html, "")
 	reURL := regexp.MustCompile(`(https?:\\*\/\*\/*[^\s\"']+)`)
 	html = reURL.ReplaceAllString(html, "")
+	// 链接占位符同样在去除URL之后还原，锚文本是URL时也能保留
+	for placeholder, text := range linkMap {
+		html = strings.Replace(html, placeholder, text, 1)
+	}
 	// 压缩多余空白，但保持占位符两侧至少一个空格
 	for placeholder := range codeMap {
 		html = strings.ReplaceAll(html, placeholder, " "+placeholder+" ")
 	}
-	reSpace := regexp.MustCompile(`\s+`)
-	html = reSpace.ReplaceAllString(html, " ")
-	html = strings.TrimSpace(html)
+	html = collapseSpaces(html)
+	// 图片占位符在去除URL之后再还原，避免图片地址被清洗掉
+	for placeholder, markdown := range imageMap {
+		html = strings.Replace(html, placeholder, markdown, 1)
+	}
 
 	return types.Type{
-		Url:     input.Url,
-		Text:    html,
-		CodeMap: codeMap,
+		Url:      input.Url,
+		Text:     html,
+		CodeMap:  codeMap,
+		Options:  input.Options,
+		Outlinks: outlinks,
+		Images:   images,
 	}, nil
 }
+
+var (
+	reHeadingTag = regexp.MustCompile(`(?is)<h([1-6])\b[^>]*>(.*?)</h[1-6]>`)
+	reListItem   = regexp.MustCompile(`(?i)<li\b[^>]*>`)
+	reBlockTag   = regexp.MustCompile(`(?i)</?(?:p|div|section|article|main|header|footer|nav|aside|ul|ol|dl|dt|dd|table|thead|tbody|tr|blockquote|figure|figcaption|pre|form|fieldset)\b[^>]*>|<br\s*/?>|<hr\b[^>]*>`)
+	reHSpace     = regexp.MustCompile(`[^\S\n]+`)
+)
+
+// markStructure 在去标签之前标记文档结构：标题转为独占一行的「## 标题」，
+// 列表项转为「- 」开头的行，其余块级元素转为换行，供SectionChunker按结构分块
+func markStructure(html string) string {
+	html = reHeadingTag.ReplaceAllStringFunc(html, func(tag string) string {
+		m := reHeadingTag.FindStringSubmatch(tag)
+		text := strings.TrimSpace(reBlankRun.ReplaceAllString(reTag.ReplaceAllString(m[2], ""), " "))
+		if text == "" {
+			return "\n"
+		}
+		level := int(m[1][0] - '0')
+		return "\n" + strings.Repeat("#", level) + " " + text + "\n"
+	})
+	html = reListItem.ReplaceAllString(html, "\n- ")
+	return reBlockTag.ReplaceAllString(html, "\n")
+}
+
+// collapseSpaces 压缩行内空白并去掉空行，每个非空行即一个段落
+func collapseSpaces(text string) string {
+	lines := strings.Split(reHSpace.ReplaceAllString(text, " "), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "-" {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
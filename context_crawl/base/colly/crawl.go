@@ -12,6 +12,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"mime"
 	"net"
 	"net/http"
 	"os"
@@ -19,13 +20,19 @@ import (
 	"sync"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/chromedp/chromedp"
 	"github.com/gocolly/colly/v2"
 
 	"context_crawl/types"
+	"context_crawl/utils"
 )
 
+// ErrCodePDFContent 链接内容是PDF（按Content-Type或文件头识别），交给PDF pipeline处理
+const ErrCodePDFContent = "pdf_content"
+
+// pdfPipeline core中注册的PDF pipeline名称
+const pdfPipeline = "pdf"
+
 // CollyCrawler 实现了基于Colly的爬虫
 
 type CollyCrawler struct {
@@ -167,13 +174,30 @@ func (cc *CollyCrawler) Crawl(input types.Type) (types.Type, error) {
 	fmt.Println("🚀 开始爬取网页...")
 	start := time.Now()
 	var result types.Type
-	resultChan := make(chan types.Type, 1)
-	// dynamicResultChan := make(chan FetchResult, 1) // 禁用动态抓取后不再需要
 
-	// 全局超时：60 秒内必须结束（考虑到重试机制）
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	// 站点规则：指定了浏览器渲染的站点直接走渲染流程
+	rule, _ := utils.MatchSiteRule(input.Url)
+	// 请求中的选择器或URL锚点用于只返回目标章节
+	fragment, selector := fragmentOf(input.Url), input.Options.Selector
+
+	// 全局超时：60 秒内必须结束（考虑到重试机制），请求的截止时间更早时以截止时间为准
+	parent, cancelParent := input.Options.Context()
+	defer cancelParent()
+	ctx, cancel := context.WithTimeout(parent, 60*time.Second)
 	defer cancel()
 
+	if rule != nil && rule.Render == utils.RenderDynamic {
+		html, err := crawlRendered(ctx, input.Url, rule, fragment, selector)
+		if err != nil {
+			return types.Type{}, err
+		}
+		fmt.Println("总耗时:", time.Since(start))
+		return types.Type{Url: input.Url, Text: html, Options: input.Options}, nil
+	}
+
+	resultChan := make(chan types.Type, 1)
+	// dynamicResultChan := make(chan FetchResult, 1) // 禁用动态抓取后不再需要
+
 	// 多个用户代理轮换
 	userAgents := []string{
 		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
@@ -187,6 +211,7 @@ func (cc *CollyCrawler) Crawl(input types.Type) (types.Type, error) {
 	c := colly.NewCollector(
 		colly.Async(false), // 使用同步模式
 		colly.MaxDepth(1),
+		colly.StdlibContext(ctx),
 	)
 	// 随机选择用户代理
 	c.UserAgent = userAgents[time.Now().UnixNano()%int64(len(userAgents))]
@@ -211,6 +236,15 @@ func (cc *CollyCrawler) Crawl(input types.Type) (types.Type, error) {
 		Delay:       500 * time.Millisecond, // 适当延迟
 	})
 
+	// 站点规则中的额外请求头
+	if rule != nil && len(rule.Headers) > 0 {
+		c.OnRequest(func(r *colly.Request) {
+			for k, v := range rule.Headers {
+				r.Headers.Set(k, v)
+			}
+		})
+	}
+
 	// 静态页面
 	c.OnHTML("body", func(e *colly.HTMLElement) {
 		select {
@@ -219,25 +253,52 @@ func (cc *CollyCrawler) Crawl(input types.Type) (types.Type, error) {
 		default:
 		}
 
-		e.DOM.Find("script, style, noscript").Each(func(i int, s *goquery.Selection) {
-			s.Remove()
-		})
-		html, err := e.DOM.Html()
+		html, err := extractBody(e.DOM, rule, fragment, selector)
 		if err != nil {
 			log.Println("❌ 网页解析失败:", e.Request.URL, err)
 			return
 		}
+		// 站点规则为auto时，检测到JS渲染的页面改用浏览器抓取
+		// 渲染在截止时间前结束，剩余时间不足时直接使用静态结果
+		if rule != nil && rule.Render == utils.RenderAuto && needsJS(html) {
+			if renderCtx, cancelRender, ok := renderContext(ctx); !ok {
+				log.Printf("⚠️ 剩余时间不足以浏览器渲染，使用静态结果: %s", e.Request.URL)
+			} else {
+				if rendered, err := crawlRendered(renderCtx, e.Request.URL.String(), rule, fragment, selector); err == nil {
+					html = rendered
+				} else {
+					log.Printf("⚠️ 浏览器渲染失败，使用静态结果: %v", err)
+				}
+				cancelRender()
+			}
+		}
 		// 暂时禁用动态抓取，优先返回静态结果
 		// if needsJS(html) {
 		//  FetchPageAsync(e.Request.URL.String(), dynamicResultChan)
 		//  return
 		// }
 		select {
-		case resultChan <- types.Type{Url: e.Request.URL.String(), Text: html}:
+		case resultChan <- types.Type{Url: e.Request.URL.String(), Text: html, Options: input.Options}:
 		case <-ctx.Done():
 		}
 	})
 
+	// 链接内容是PDF时（扩展名不是.pdf的下载链接、application/octet-stream等）交给PDF pipeline：
+	// Content-Type声明为PDF时不再下载正文，其余非HTML响应按文件头识别
+	isPDF := false
+	c.OnResponseHeaders(func(r *colly.Response) {
+		if isPDFContentType(r.Headers.Get("Content-Type")) {
+			isPDF = true
+			r.Request.Abort()
+		}
+	})
+	c.OnResponse(func(r *colly.Response) {
+		// HTML页面正文里可能恰好提到 %PDF- ，只识别非HTML响应
+		if !strings.Contains(strings.ToLower(r.Headers.Get("Content-Type")), "html") && utils.IsPDFContent(r.Body) {
+			isPDF = true
+		}
+	})
+
 	c.OnError(func(r *colly.Response, err error) {
 		log.Printf("❌ Error: %v, URL: %s, StatusCode: %d", err, r.Request.URL, r.StatusCode)
 		// 对于超时错误，记录更详细的信息
@@ -255,6 +316,9 @@ func (cc *CollyCrawler) Crawl(input types.Type) (types.Type, error) {
 
 	// 关闭resultChan，因为我们已经禁用了动态抓取
 	close(resultChan)
+	if isPDF {
+		return types.Type{}, types.NewHandoffError(ErrCodePDFContent, "链接内容是PDF", pdfPipeline)
+	}
 
 	// 处理网络爬取的结果
 	done := make(chan struct{})
@@ -278,6 +342,12 @@ func (cc *CollyCrawler) Crawl(input types.Type) (types.Type, error) {
 	return result, nil
 }
 
+// isPDFContentType 判断Content-Type是否声明为PDF
+func isPDFContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/pdf" || mediaType == "application/x-pdf"
+}
+
 // 清洗文本，保留代码块占位符
 // 清洗文本，保留代码块占位符
 // 辅助函数
@@ -0,0 +1,41 @@
+package colly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestCrawlHandsPDFToPDFPipeline(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n%%EOF\n")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(pdf)
+	})
+	mux.HandleFunc("/paper", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf; qs=0.001")
+		w.Write(pdf)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body><p>The PDF version (%PDF-1.7) is linked below for anyone who prefers print.</p></body></html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, path := range []string{"/download", "/paper"} {
+		_, err := NewCollyCrawler().Crawl(types.Type{Url: server.URL + path})
+		typed, ok := types.AsError(err)
+		if !ok || typed.Code != ErrCodePDFContent || typed.Handoff != pdfPipeline {
+			t.Errorf("Crawl(%s) error = %v, want handoff to %q", path, err, pdfPipeline)
+		}
+	}
+
+	result, err := NewCollyCrawler().Crawl(types.Type{Url: server.URL + "/page"})
+	if err != nil || result.Text == "" {
+		t.Errorf("Crawl(/page) = %q, %v, want the HTML page", result.Text, err)
+	}
+}
@@ -0,0 +1,41 @@
+package colly
+
+import (
+	"context_crawl/base/simhash"
+	"context_crawl/types"
+)
+
+// collapseDuplicates 计算每个分块的SimHash指纹并记录来源URL，
+// collapse为true时页面内重复的分块（反复出现的cookie提示、分享按钮文案等）只保留第一次出现的那个
+// 重复按DuplicateChunk判断，跨页面去重（service.DeduplicateResults）使用同样的规则；过短的分块不参与判断
+func collapseDuplicates(chunks []types.Chunk, url string, collapse bool) []types.Chunk {
+	kept := make([]types.Chunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		chunk.Fingerprint, chunk.HasFingerprint = simhash.Compute(chunk.Text)
+		if collapse && duplicateKept(chunk, kept) {
+			continue
+		}
+		chunk.Sources = []string{url}
+		kept = append(kept, chunk)
+	}
+	return kept
+}
+
+// duplicateKept 判断分块是否与已保留的分块重复
+func duplicateKept(chunk types.Chunk, kept []types.Chunk) bool {
+	for _, k := range kept {
+		if DuplicateChunk(k, chunk) {
+			return true
+		}
+	}
+	return false
+}
+
+// DuplicateChunk 判断两个分块是否重复：正文按SimHash指纹近重复判断，两者都需要有指纹；
+// 代码只在原文完全相同时才算重复，按系统区分的安装命令、只差一行的配置等片段指纹很接近，但都需要保留
+func DuplicateChunk(a, b types.Chunk) bool {
+	if a.IsCode || b.IsCode {
+		return a.IsCode && b.IsCode && a.Text == b.Text
+	}
+	return a.HasFingerprint && b.HasFingerprint && simhash.Near(a.Fingerprint, b.Fingerprint)
+}
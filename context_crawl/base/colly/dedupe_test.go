@@ -0,0 +1,68 @@
+package colly
+
+import (
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestCollapseDuplicates(t *testing.T) {
+	notice := "We use cookies to improve your experience on our website and to show relevant ads."
+	config := "[server]\nhost = \"0.0.0.0\"\nport = 8080\nread_timeout = \"30s\"\nwrite_timeout = \"30s\"\nmax_body_size = \"10MB\"\n\n" +
+		"[logging]\nlevel = \"info\"\nformat = \"json\"\noutput = \"stdout\"\n\n[cache]\nenabled = true\nsize = 1024\n"
+	tests := []struct {
+		name   string
+		chunks []types.Chunk
+		keep   bool // KeepDuplicates
+		want   []string
+	}{
+		{
+			name:   "repeated notice",
+			chunks: []types.Chunk{{Text: notice}, {Text: "Intro paragraph about the project goals and scope."}, {Text: notice}},
+			want:   []string{notice, "Intro paragraph about the project goals and scope."},
+		},
+		{
+			name:   "short chunks are not compared",
+			chunks: []types.Chunk{{Text: "Share"}, {Text: "Share"}},
+			want:   []string{"Share", "Share"},
+		},
+		{
+			name:   "repeated notice kept on request",
+			chunks: []types.Chunk{{Text: notice}, {Text: notice}},
+			keep:   true,
+			want:   []string{notice, notice},
+		},
+		{
+			// 两段配置的指纹在近重复距离内，但只差一行的配置示例都需要保留
+			name:   "near-identical code variants kept",
+			chunks: []types.Chunk{{Text: config + `ttl = "5m"`, IsCode: true}, {Text: config + `ttl = "1h"`, IsCode: true}},
+			want:   []string{config + `ttl = "5m"`, config + `ttl = "1h"`},
+		},
+		{
+			name:   "identical code collapsed",
+			chunks: []types.Chunk{{Text: "npm install", IsCode: true}, {Text: "npm install", IsCode: true}},
+			want:   []string{"npm install"},
+		},
+		{
+			name:   "code and prose kept apart",
+			chunks: []types.Chunk{{Text: "go build ./... && go test ./..."}, {Text: "go build ./... && go test ./...", IsCode: true}},
+			want:   []string{"go build ./... && go test ./...", "go build ./... && go test ./..."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collapseDuplicates(tt.chunks, "https://example.com", !tt.keep)
+			if len(got) != len(tt.want) {
+				t.Fatalf("collapseDuplicates() = %+v, want %q", got, tt.want)
+			}
+			for i, chunk := range got {
+				if chunk.Text != tt.want[i] {
+					t.Errorf("chunk %d = %q, want %q", i, chunk.Text, tt.want[i])
+				}
+				if len(chunk.Sources) != 1 || chunk.Sources[0] != "https://example.com" {
+					t.Errorf("chunk %d sources = %v", i, chunk.Sources)
+				}
+			}
+		})
+	}
+}
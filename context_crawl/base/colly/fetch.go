@@ -0,0 +1,76 @@
+package colly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+
+	"context_crawl/utils"
+)
+
+// FetchDocument 抓取页面的完整HTML，不做任何清洗，用于按选择器抽取结构化数据
+// rule为匹配URL的站点规则（可为nil），请求带上其中的额外请求头；dynamic为true或站点规则指定浏览器渲染时，
+// 返回渲染后的DOM，站点规则为auto时先静态抓取，检测到JS渲染的页面再改用浏览器；ctx取消时放弃抓取和渲染
+func (cc *CollyCrawler) FetchDocument(ctx context.Context, url string, dynamic bool, rule *utils.SiteRule) (string, error) {
+	if dynamic || (rule != nil && rule.Render == utils.RenderDynamic) {
+		return renderPage(ctx, url, rule)
+	}
+
+	c := colly.NewCollector(
+		colly.Async(false),
+		colly.MaxDepth(1),
+		colly.StdlibContext(ctx),
+	)
+	c.UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	c.SetRequestTimeout(cc.RequestTimeout)
+	c.WithTransport(&http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 10 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+	})
+
+	if rule != nil && len(rule.Headers) > 0 {
+		c.OnRequest(func(r *colly.Request) {
+			for k, v := range rule.Headers {
+				r.Headers.Set(k, v)
+			}
+		})
+	}
+
+	var body []byte
+	c.OnResponse(func(r *colly.Response) {
+		body = r.Body
+	})
+
+	if err := c.Visit(url); err != nil {
+		log.Printf("❌ 页面抓取失败: %v, URL: %s", err, url)
+		return "", fmt.Errorf("页面抓取失败: %v", err)
+	}
+	if len(body) == 0 {
+		return "", fmt.Errorf("页面内容为空: %s", url)
+	}
+	html := string(body)
+	// 与Crawl相同：渲染在截止时间前结束，剩余时间不足或渲染失败时使用静态结果
+	if rule != nil && rule.Render == utils.RenderAuto && needsJS(html) {
+		if renderCtx, cancelRender, ok := renderContext(ctx); !ok {
+			log.Printf("⚠️ 剩余时间不足以浏览器渲染，使用静态结果: %s", url)
+		} else {
+			if rendered, err := renderPage(renderCtx, url, rule); err == nil {
+				html = rendered
+			} else {
+				log.Printf("⚠️ 浏览器渲染失败，使用静态结果: %v", err)
+			}
+			cancelRender()
+		}
+	}
+	return html, nil
+}
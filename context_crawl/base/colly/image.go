@@ -0,0 +1,255 @@
+package colly
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"context_crawl/types"
+)
+
+var (
+	reImgAttr   = make(map[string]*regexp.Regexp)
+	reStyleSize = regexp.MustCompile(`(?i)\b(width|height)\s*:\s*(\d+)px`)
+)
+
+// decorativeHints 地址、class或id中有这些完整词的图片视为装饰性图片
+var decorativeHints = map[string]bool{
+	"icon": true, "icons": true, "favicon": true, "sprite": true, "sprites": true, "spacer": true,
+	"pixel": true, "tracker": true, "emoji": true, "emojis": true, "avatar": true, "avatars": true,
+	"logo": true, "badge": true, "badges": true,
+}
+
+// minImageSize 声明尺寸不超过该值的图片视为图标或跟踪像素
+const minImageSize = 32
+
+func init() {
+	// 预编译需要读取的属性，运行期只读，可并发使用
+	for _, name := range []string{"src", "data-src", "data-original", "data-lazy-src", "srcset", "data-srcset",
+		"alt", "width", "height", "style", "class", "id", "role", "aria-hidden"} {
+		reImgAttr[name] = regexp.MustCompile(`(?is)\s` + regexp.QuoteMeta(name) + `\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	}
+}
+
+// imageAttr 读取img标签的属性值
+func imageAttr(tag, name string) string {
+	return attrValue(reImgAttr[name], tag)
+}
+
+// extractImages 收集HTML中的内容图片，并将img标签替换为 @IMG_n@ 占位符（placeholders为false时直接移除）
+// 返回处理后的HTML、图片列表以及占位符到 ![alt](src) 的映射
+func extractImages(htmlText string, pageURL string, placeholders bool) (string, []types.Image, map[string]string) {
+	base, _ := url.Parse(pageURL)
+	var images []types.Image
+	imageMap := make(map[string]string)
+
+	replaceImg := func(tag, heading, caption string) string {
+		img, ok := parseImage(base, tag)
+		if !ok {
+			return ""
+		}
+		img.Caption = caption
+		img.Heading = heading
+		images = append(images, img)
+		if !placeholders {
+			return ""
+		}
+		placeholder := fmt.Sprintf("@IMG_%d@", len(images)-1)
+		alt := img.Alt
+		if alt == "" {
+			alt = caption
+		}
+		imageMap[placeholder] = fmt.Sprintf("![%s](%s)", alt, img.Src)
+		return " " + placeholder + " "
+	}
+
+	if !strings.Contains(strings.ToLower(htmlText), "<img") {
+		return htmlText, nil, imageMap
+	}
+	contexts, ok := imageContexts(htmlText)
+	if !ok {
+		return htmlText, nil, imageMap
+	}
+	tags := imageTags(htmlText)
+	if len(tags) != len(contexts) {
+		// 解析器和分词器对img的判定不一致（如select中的img），无法一一对应时不提取
+		return htmlText, nil, imageMap
+	}
+
+	// 只在原始标记中替换img标签，其余文字保持原样，不经过重新序列化的转义
+	var out strings.Builder
+	last := 0
+	for i, tag := range tags {
+		out.WriteString(htmlText[last:tag[0]])
+		out.WriteString(replaceImg(htmlText[tag[0]:tag[1]], contexts[i].heading, contexts[i].caption))
+		last = tag[1]
+	}
+	out.WriteString(htmlText[last:])
+	return out.String(), images, imageMap
+}
+
+// imageContext 图片所在位置之前最近的标题及所在figure的说明文字
+type imageContext struct {
+	heading string
+	caption string
+}
+
+// imageContexts 解析DOM，按文档顺序返回每个img的上下文
+func imageContexts(htmlText string) ([]imageContext, bool) {
+	root, err := parseFragment(htmlText)
+	if err != nil {
+		return nil, false
+	}
+	var contexts []imageContext
+	heading := ""
+	goquery.NewDocumentFromNode(root).Find("h1, h2, h3, h4, h5, h6, img").Each(func(i int, s *goquery.Selection) {
+		if goquery.NodeName(s) != "img" {
+			heading = collapseText(s.Text())
+			return
+		}
+		caption := ""
+		if figure := s.Closest("figure"); figure.Length() > 0 {
+			caption = collapseText(figure.Find("figcaption").First().Text())
+		}
+		contexts = append(contexts, imageContext{heading: heading, caption: caption})
+	})
+	return contexts, true
+}
+
+// imageTags 用分词器按文档顺序返回原始标记中每个img标签的起止偏移，注释和script中的img不计入
+func imageTags(htmlText string) [][2]int {
+	var tags [][2]int
+	z := nethtml.NewTokenizer(strings.NewReader(htmlText))
+	offset := 0
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			return tags
+		}
+		size := len(z.Raw())
+		if tt == nethtml.StartTagToken || tt == nethtml.SelfClosingTagToken {
+			// 解析器会把body中的<image>当作<img>
+			if name, _ := z.TagName(); atom.Lookup(name) == atom.Img || atom.Lookup(name) == atom.Image {
+				tags = append(tags, [2]int{offset, offset + size})
+			}
+		}
+		offset += size
+	}
+}
+
+// parseFragment 以body为上下文解析HTML片段，返回挂载了全部节点的body节点
+func parseFragment(htmlText string) (*nethtml.Node, error) {
+	body := &nethtml.Node{Type: nethtml.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := nethtml.ParseFragment(strings.NewReader(htmlText), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		body.AppendChild(node)
+	}
+	return body, nil
+}
+
+// parseImage 解析单个img标签，装饰性图片返回false
+func parseImage(base *url.URL, tag string) (types.Image, bool) {
+	if strings.EqualFold(imageAttr(tag, "role"), "presentation") || imageAttr(tag, "aria-hidden") == "true" {
+		return types.Image{}, false
+	}
+
+	// 懒加载图片的真实地址通常放在data-src等属性中
+	src := ""
+	for _, name := range []string{"data-src", "data-original", "data-lazy-src", "src"} {
+		if v := imageAttr(tag, name); v != "" && !strings.HasPrefix(v, "data:") {
+			src = v
+			break
+		}
+	}
+	link, ok := resolveHref(base, src)
+	if !ok {
+		return types.Image{}, false
+	}
+
+	img := types.Image{
+		Src:    link.String(),
+		Alt:    imageAttr(tag, "alt"),
+		Width:  imageSize(imageAttr(tag, "width")),
+		Height: imageSize(imageAttr(tag, "height")),
+	}
+	for _, m := range reStyleSize.FindAllStringSubmatch(imageAttr(tag, "style"), -1) {
+		size, _ := strconv.Atoi(m[2])
+		if strings.EqualFold(m[1], "width") && img.Width == 0 {
+			img.Width = size
+		} else if strings.EqualFold(m[1], "height") && img.Height == 0 {
+			img.Height = size
+		}
+	}
+	if (img.Width > 0 && img.Width <= minImageSize) || (img.Height > 0 && img.Height <= minImageSize) {
+		return types.Image{}, false
+	}
+
+	if strings.HasSuffix(strings.ToLower(link.Path), ".ico") {
+		return types.Image{}, false
+	}
+	if isDecorative(link.Path + " " + imageAttr(tag, "class") + " " + imageAttr(tag, "id")) {
+		return types.Image{}, false
+	}
+
+	srcset := imageAttr(tag, "data-srcset")
+	if srcset == "" {
+		srcset = imageAttr(tag, "srcset")
+	}
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		if u, ok := resolveHref(base, fields[0]); ok {
+			img.Srcset = append(img.Srcset, u.String())
+		}
+	}
+
+	return img, true
+}
+
+// isDecorative 将地址、class和id按 / . _ - 和空白切成词，有任一词是装饰性提示词时返回true；
+// 按整词比较，silicon-wafer.jpg、package-tracking-diagram.png 这类文件名不会误判
+func isDecorative(hint string) bool {
+	words := strings.FieldsFunc(strings.ToLower(hint), func(r rune) bool {
+		return r == '/' || r == '.' || r == '_' || r == '-' || unicode.IsSpace(r)
+	})
+	for _, word := range words {
+		if decorativeHints[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// imageSize 解析width/height属性，忽略百分比等非像素值
+func imageSize(value string) int {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+	size, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// collapseText 压缩DOM文本中的空白
+func collapseText(text string) string {
+	return strings.TrimSpace(reBlankRun.ReplaceAllString(text, " "))
+}
+
+// plainText 去掉标签并压缩空白，得到片段的纯文本
+func plainText(fragment string) string {
+	text := reTag.ReplaceAllString(fragment, "")
+	return strings.TrimSpace(reBlankRun.ReplaceAllString(html.UnescapeString(text), " "))
+}
@@ -0,0 +1,116 @@
+package colly
+
+import (
+	"strings"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestExtractImages(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		srcs     []string
+		headings []string
+		captions []string
+		wantText []string // 处理后的HTML中应保留的片段
+	}{
+		{
+			name:     "image after heading",
+			html:     `<h2>Setup</h2><p>Run it.</p><img src="/a.png" alt="diagram">`,
+			srcs:     []string{"https://example.com/a.png"},
+			headings: []string{"Setup"},
+			captions: []string{""},
+			wantText: []string{"<h2>Setup</h2>", "@IMG_0@"},
+		},
+		{
+			name:     "image inside heading",
+			html:     `<h2><img src="/hero.png" width="200"> Release <em>notes</em></h2><p>Body</p><img src="/b.png">`,
+			srcs:     []string{"https://example.com/hero.png", "https://example.com/b.png"},
+			headings: []string{"Release notes", "Release notes"},
+			captions: []string{"", ""},
+			wantText: []string{"Release <em>notes</em></h2>", "@IMG_0@", "@IMG_1@"},
+		},
+		{
+			name:     "figure caption",
+			html:     `<h3>Results</h3><figure><img src="c.png"><figcaption>Accuracy  by   epoch</figcaption></figure>`,
+			srcs:     []string{"https://example.com/docs/c.png"},
+			headings: []string{"Results"},
+			captions: []string{"Accuracy by epoch"},
+			wantText: []string{"<figcaption>Accuracy  by   epoch</figcaption>"},
+		},
+		{
+			name:     "decorative images removed",
+			html:     `<p><img src="/icons/star.svg"><img src="/p.gif" width="1" height="1">text</p>`,
+			wantText: []string{"<p>text</p>"},
+		},
+		{
+			name: "decorative hints match whole words only",
+			html: `<img src="/img/silicon-wafer.jpg"><img src="/lexicon.png"><img src="/package-tracking-diagram.png">` +
+				`<img src="/logos-of-partners.png"><img src="/a.png" class="site-logo"><img src="/b.png" id="user_avatar">` +
+				`<img src="/static/emoji/smile.png"><img src="/tracking-pixel.gif">`,
+			srcs: []string{"https://example.com/img/silicon-wafer.jpg", "https://example.com/lexicon.png",
+				"https://example.com/package-tracking-diagram.png", "https://example.com/logos-of-partners.png"},
+			headings: []string{"", "", "", ""},
+			captions: []string{"", "", "", ""},
+		},
+		{
+			name:     "no images",
+			html:     `<p>plain &amp; simple</p>`,
+			wantText: []string{"<p>plain &amp; simple</p>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, images, imageMap := extractImages(tt.html, "https://example.com/docs/page", true)
+			if len(images) != len(tt.srcs) {
+				t.Fatalf("extractImages() images = %+v, want %d", images, len(tt.srcs))
+			}
+			for i, img := range images {
+				if img.Src != tt.srcs[i] || img.Heading != tt.headings[i] || img.Caption != tt.captions[i] {
+					t.Errorf("image[%d] = %+v, want src=%q heading=%q caption=%q",
+						i, img, tt.srcs[i], tt.headings[i], tt.captions[i])
+				}
+			}
+			if len(imageMap) != len(tt.srcs) {
+				t.Errorf("extractImages() imageMap = %v, want %d entries", imageMap, len(tt.srcs))
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(got, want) {
+					t.Errorf("extractImages() html = %q, want it to contain %q", got, want)
+				}
+			}
+			if strings.Contains(strings.ToLower(got), "<img") {
+				t.Errorf("extractImages() html still contains img: %q", got)
+			}
+		})
+	}
+}
+
+func TestCleanKeepsQuotesWithImages(t *testing.T) {
+	html := `<h2>Don't "quote" me</h2><p>It's a <b>"test"</b> &amp; more</p><img src="/a.png" alt="chart"><p>That's all</p>`
+	for _, extract := range []bool{false, true} {
+		input := types.Type{
+			Url:     "https://example.com/page",
+			Text:    html,
+			Options: types.Options{ExtractImages: extract, ImagePlaceholders: true},
+		}
+		result, err := NewBasicCleaner().Clean(input)
+		if err != nil {
+			t.Fatalf("Clean() error = %v", err)
+		}
+		for _, want := range []string{`## Don't "quote" me`, `It's a "test"`, "That's all"} {
+			if !strings.Contains(result.Text, want) {
+				t.Errorf("ExtractImages=%v: Clean() text = %q, want it to contain %q", extract, result.Text, want)
+			}
+		}
+		if strings.Contains(result.Text, "&#") {
+			t.Errorf("ExtractImages=%v: Clean() text = %q contains escaped entities", extract, result.Text)
+		}
+		if extract && !strings.Contains(result.Text, "![chart](https://example.com/a.png)") {
+			t.Errorf("Clean() text = %q, want image placeholder restored", result.Text)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package colly
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"context_crawl/types"
+)
+
+var (
+	reAnchor   = regexp.MustCompile(`(?is)<a\b([^>]*)>(.*?)</a>`)
+	reHref     = regexp.MustCompile(`(?is)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	reRel      = regexp.MustCompile(`(?is)\brel\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	reTag      = regexp.MustCompile(`(?s)<[^>]*>`)
+	reBlankRun = regexp.MustCompile(`\s+`)
+	reImgToken = regexp.MustCompile(`@IMG_\d+@`)
+)
+
+// 未保留超链接时，锚文本在正文中用这两个私用区字符包围，只供评分时计算链接密度，分块输出前去掉
+const (
+	anchorStart = "\uE000"
+	anchorEnd   = "\uE001"
+)
+
+// hideLinks 将HTML中带href的a标签替换为 @LINK_n@ 占位符，返回处理后的HTML以及占位符到
+// 「anchorStart锚文本anchorEnd」的映射；用于未开启preserve_links时，导航、目录等链接密集的分块仍能被识别
+// 页内锚点同样计入，目录往往全是这类链接
+func hideLinks(htmlText string) (string, map[string]string) {
+	linkMap := make(map[string]string)
+	replaced := reAnchor.ReplaceAllStringFunc(htmlText, func(tag string) string {
+		m := reAnchor.FindStringSubmatch(tag)
+		attrs, inner := m[1], m[2]
+		text := plainText(inner)
+		if attrValue(reHref, attrs) == "" || text == "" {
+			return inner
+		}
+		placeholder := fmt.Sprintf("@LINK_%d@", len(linkMap))
+		linkMap[placeholder] = anchorStart + text + anchorEnd
+		return placeholder
+	})
+	return replaced, linkMap
+}
+
+// stripAnchors 去掉hideLinks留下的锚文本标记
+func stripAnchors(text string) string {
+	if !strings.Contains(text, anchorStart) {
+		return text
+	}
+	return strings.NewReplacer(anchorStart, "", anchorEnd, "").Replace(text)
+}
+
+// preserveLinks 将HTML中的a标签替换为 @LINK_n@ 占位符，并收集outlinks
+// 返回处理后的HTML、outlinks以及占位符到「锚文本[n]」的映射；锚文本本身可能就是URL，
+// 占位符要在清洗URL之后再还原，否则锚文本会被一起清洗掉
+// 相对链接基于页面URL解析为绝对地址，同一地址复用同一个序号
+func preserveLinks(htmlText string, pageURL string) (string, []types.Outlink, map[string]string) {
+	base, _ := url.Parse(pageURL)
+	var outlinks []types.Outlink
+	indexByURL := make(map[string]int)
+	linkMap := make(map[string]string)
+
+	replaced := reAnchor.ReplaceAllStringFunc(htmlText, func(tag string) string {
+		m := reAnchor.FindStringSubmatch(tag)
+		attrs, inner := m[1], m[2]
+
+		// 锚文本中可能含有图片占位符，不计入链接文本
+		text := strings.TrimSpace(reImgToken.ReplaceAllString(plainText(inner), ""))
+
+		link, ok := resolveHref(base, attrValue(reHref, attrs))
+		if !ok {
+			return inner
+		}
+
+		index, seen := indexByURL[link.String()]
+		if !seen {
+			index = len(outlinks) + 1
+			indexByURL[link.String()] = index
+			outlinks = append(outlinks, types.Outlink{
+				Index:    index,
+				Url:      link.String(),
+				Text:     text,
+				Rel:      attrValue(reRel, attrs),
+				Internal: isInternalLink(base, link),
+			})
+		} else if outlinks[index-1].Text == "" {
+			outlinks[index-1].Text = text
+		}
+
+		placeholder := fmt.Sprintf("@LINK_%d@", len(linkMap))
+		linkMap[placeholder] = fmt.Sprintf("%s[%d]", plainText(inner), index)
+		return placeholder
+	})
+
+	return replaced, outlinks, linkMap
+}
+
+// attrValue 从标签属性串中取出指定属性的值
+func attrValue(re *regexp.Regexp, attrs string) string {
+	m := re.FindStringSubmatch(attrs)
+	if m == nil {
+		return ""
+	}
+	for _, v := range m[1:] {
+		if v != "" {
+			return strings.TrimSpace(html.UnescapeString(v))
+		}
+	}
+	return ""
+}
+
+// resolveHref 将href解析为绝对地址，页内锚点和非http(s)链接不作为outlink返回
+func resolveHref(base *url.URL, href string) (*url.URL, bool) {
+	if href == "" || strings.HasPrefix(href, "#") {
+		return nil, false
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return nil, false
+	}
+	if base != nil {
+		ref = base.ResolveReference(ref)
+	}
+	if ref.Scheme != "http" && ref.Scheme != "https" {
+		return nil, false
+	}
+	return ref, true
+}
+
+// isInternalLink 判断链接是否与页面同域，忽略www前缀
+func isInternalLink(base, link *url.URL) bool {
+	if base == nil {
+		return false
+	}
+	trim := func(host string) string {
+		return strings.TrimPrefix(strings.ToLower(host), "www.")
+	}
+	return trim(base.Hostname()) == trim(link.Hostname())
+}
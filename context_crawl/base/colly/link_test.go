@@ -0,0 +1,81 @@
+package colly
+
+import (
+	"strings"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestPreserveLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		want     string
+		outlinks []types.Outlink
+	}{
+		{
+			name: "relative link",
+			html: `<p>See <a href="/docs/intro">the intro</a>.</p>`,
+			want: "See the intro[1].",
+			outlinks: []types.Outlink{
+				{Index: 1, Url: "https://example.com/docs/intro", Text: "the intro", Internal: true},
+			},
+		},
+		{
+			name: "url as anchor text",
+			html: `<p>Mirror: <a href="https://mirror.org/pkg">https://mirror.org/pkg</a></p>`,
+			want: "Mirror: https://mirror.org/pkg[1]",
+			outlinks: []types.Outlink{
+				{Index: 1, Url: "https://mirror.org/pkg", Text: "https://mirror.org/pkg"},
+			},
+		},
+		{
+			name: "same target reuses index",
+			html: `<p><a href="https://a.org/">A</a> and <a href="https://a.org/" rel="nofollow">again</a></p>`,
+			want: "A[1] and again[1]",
+			outlinks: []types.Outlink{
+				{Index: 1, Url: "https://a.org/", Text: "A", Internal: false},
+			},
+		},
+		{
+			name: "fragment and mailto are not outlinks",
+			html: `<p><a href="#top">Top</a> <a href="mailto:x@y.z">mail</a></p>`,
+			want: "Top mail",
+		},
+		{
+			name: "markup inside anchor",
+			html: `<p><a href="/x"><b>bold</b> &amp; plain</a></p>`,
+			want: "bold & plain[1]",
+			outlinks: []types.Outlink{
+				{Index: 1, Url: "https://example.com/x", Text: "bold & plain", Internal: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := types.Type{
+				Url:     "https://example.com/page",
+				Text:    tt.html,
+				Options: types.Options{PreserveLinks: true},
+			}
+			got, err := NewBasicCleaner().Clean(input)
+			if err != nil {
+				t.Fatalf("Clean() error = %v", err)
+			}
+			if strings.TrimSpace(got.Text) != tt.want {
+				t.Errorf("Clean() text = %q, want %q", got.Text, tt.want)
+			}
+			if len(got.Outlinks) != len(tt.outlinks) {
+				t.Fatalf("Clean() outlinks = %+v, want %+v", got.Outlinks, tt.outlinks)
+			}
+			for i, want := range tt.outlinks {
+				link := got.Outlinks[i]
+				if link.Index != want.Index || link.Url != want.Url || link.Text != want.Text || link.Internal != want.Internal {
+					t.Errorf("outlink[%d] = %+v, want %+v", i, link, want)
+				}
+			}
+		})
+	}
+}
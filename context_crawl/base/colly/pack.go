@@ -0,0 +1,166 @@
+package colly
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"context_crawl/base/sentence"
+	"context_crawl/base/tokenizer"
+)
+
+// span 打包出的一个分块，first、last为其包含的第一个和最后一个句子的下标（含重叠部分）
+type span struct {
+	text        string
+	first, last int
+}
+
+// packSpans 将句子按大小上限装入分块，相邻分块之间保留overlap长度的重叠，同时返回每个分块覆盖的句子范围，用于推算分块所在的页码
+// 超过上限的单个句子会被强制切开；以换行结尾的句子表示段落结束，拼接时不再补空格
+func packSpans(sentences []string, chunkSize, overlap int, counter tokenizer.Counter) []span {
+	var chunks []span
+	var window []string // 当前分块中的句子
+	var lengths []int   // 与window一一对应的长度
+	var origins []int   // 与window一一对应的句子下标
+	windowLen := 0
+	fresh := 0 // 当前分块中不属于重叠部分的句子数
+
+	flush := func() {
+		if fresh == 0 {
+			return
+		}
+		chunks = append(chunks, span{
+			text:  strings.TrimSpace(joinSentences(window)),
+			first: origins[0],
+			last:  origins[len(origins)-1],
+		})
+		fresh = 0
+
+		// 从末尾向前取不超过overlap的句子作为下一个分块的开头
+		start, tailLen := len(window), 0
+		for start > 0 && tailLen+lengths[start-1] <= overlap {
+			start--
+			tailLen += lengths[start]
+		}
+		if start == len(window) && overlap > 0 {
+			// 最后一句就超过了重叠长度，截取它的末尾
+			last, origin := window[len(window)-1], origins[len(origins)-1]
+			window, lengths, origins, windowLen = nil, nil, nil, 0
+			if tail := tailUnits(last, overlap, counter); tail != "" {
+				n := counter.Count(tail)
+				window, lengths, origins, windowLen = []string{tail}, []int{n}, []int{origin}, n
+			}
+			return
+		}
+		window, lengths, origins, windowLen = window[start:], lengths[start:], origins[start:], tailLen
+	}
+
+	add := func(s string, n, origin int) {
+		if windowLen+n > chunkSize {
+			flush()
+			// 重叠部分加上新句子仍超限时放弃重叠
+			if windowLen+n > chunkSize {
+				window, lengths, origins, windowLen = nil, nil, nil, 0
+			}
+		}
+		window = append(window, s)
+		lengths = append(lengths, n)
+		origins = append(origins, origin)
+		windowLen += n
+		fresh++
+	}
+
+	for i, s := range sentences {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		n := counter.Count(s)
+		if n <= chunkSize {
+			add(s, n, i)
+			continue
+		}
+		// 超长句子强制切分
+		for _, piece := range splitUnits(s, chunkSize, counter) {
+			add(piece, counter.Count(piece), i)
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// reAtom 强制切分时的最小单位：单个汉字、一个词（连同其后的空白）或一段空白
+var reAtom = regexp.MustCompile(`\p{Han}|[^\s\p{Han}]+\s*|\s+`)
+
+// splitUnits 将超长文本按词切成长度不超过max的若干段，单个词超长时按字符切
+func splitUnits(text string, max int, counter tokenizer.Counter) []string {
+	var pieces []string
+	var current strings.Builder
+	currentLen := 0
+	emit := func() {
+		if piece := strings.TrimSpace(current.String()); piece != "" {
+			pieces = append(pieces, piece)
+		}
+		current.Reset()
+		currentLen = 0
+	}
+
+	for _, atom := range reAtom.FindAllString(text, -1) {
+		n := counter.Count(atom)
+		if n > max {
+			// 超长的词（如base64、长URL）逐字符切分
+			for _, r := range atom {
+				rn := counter.Count(string(r))
+				if currentLen+rn > max {
+					emit()
+				}
+				current.WriteRune(r)
+				currentLen += rn
+			}
+			continue
+		}
+		if currentLen+n > max {
+			emit()
+		}
+		current.WriteString(atom)
+		currentLen += n
+	}
+	emit()
+
+	return pieces
+}
+
+// tailUnits 截取文本末尾不超过max长度的部分，从词边界开始
+func tailUnits(text string, max int, counter tokenizer.Counter) string {
+	atoms := reAtom.FindAllString(text, -1)
+	start, total := len(atoms), 0
+	for start > 0 {
+		n := counter.Count(atoms[start-1])
+		if total+n > max {
+			break
+		}
+		start--
+		total += n
+	}
+	return strings.TrimSpace(strings.Join(atoms[start:], ""))
+}
+
+// joinSentences 拼接句子，两个非中文句子之间补一个空格
+func joinSentences(sentences []string) string {
+	var b strings.Builder
+	for _, s := range sentences {
+		if s == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(b.String())
+			next, _ := utf8.DecodeRuneInString(s)
+			if !unicode.IsSpace(prev) && !sentence.IsCJK(prev) && !sentence.IsCJK(next) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package colly
+
+import (
+	"reflect"
+	"testing"
+
+	"context_crawl/base/tokenizer"
+	"context_crawl/types"
+)
+
+func TestPackSpans(t *testing.T) {
+	sentences := []string{"aaaa.", "bbbb.", "", "cccc.", "dddd."}
+	tests := []struct {
+		name      string
+		chunkSize int
+		overlap   int
+		want      []span
+	}{
+		{"no overlap", 10, 0, []span{
+			{text: "aaaa. bbbb.", first: 0, last: 1},
+			{text: "cccc. dddd.", first: 3, last: 4},
+		}},
+		{"sentence overlap", 11, 5, []span{
+			{text: "aaaa. bbbb.", first: 0, last: 1},
+			{text: "bbbb. cccc.", first: 1, last: 3},
+			{text: "cccc. dddd.", first: 3, last: 4},
+		}},
+		{"oversized sentence split", 3, 0, []span{
+			{text: "aaa", first: 0, last: 0}, {text: "a.", first: 0, last: 0},
+			{text: "bbb", first: 1, last: 1}, {text: "b.", first: 1, last: 1},
+			{text: "ccc", first: 3, last: 3}, {text: "c.", first: 3, last: 3},
+			{text: "ddd", first: 4, last: 4}, {text: "d.", first: 4, last: 4},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := packSpans(sentences, tt.chunkSize, tt.overlap, tokenizer.RuneCounter{})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("packSpans() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkerSettings(t *testing.T) {
+	overlap := func(n int) *int { return &n }
+	tests := []struct {
+		name        string
+		options     types.Options
+		wantSize    int
+		wantOverlap int
+	}{
+		{"defaults do not overlap", types.Options{}, DefaultChunkSize, 0},
+		{"overlap is opt-in", types.Options{ChunkOverlap: overlap(40)}, DefaultChunkSize, 40},
+		{"overlap capped at half size", types.Options{ChunkSize: 100, ChunkOverlap: overlap(80)}, 100, 50},
+		{"negative overlap", types.Options{ChunkOverlap: overlap(-3)}, DefaultChunkSize, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, overlap, _ := NewDefaultScoredChunker().settings(tt.options)
+			if size != tt.wantSize || overlap != tt.wantOverlap {
+				t.Errorf("settings() = (%d, %d), want (%d, %d)", size, overlap, tt.wantSize, tt.wantOverlap)
+			}
+		})
+	}
+}
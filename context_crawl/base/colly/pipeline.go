@@ -2,6 +2,7 @@
 package colly
 
 import (
+	"context_crawl/base/normalize"
 	"context_crawl/types"
 )
 
@@ -15,8 +16,8 @@ type CollyPipeline struct {
 // NewCollyPipeline 创建一个新的Pipeline实例，使用colly作为爬虫组件
 func NewCollyPipeline() *CollyPipeline {
 	crawler := NewCollyCrawler()
-	cleaner := NewBasicCleaner()
-	chunker := NewScoredChunker(0.0)
+	cleaner := normalize.NewCleaner(NewBasicCleaner(), normalize.DefaultOptions()) // 清洗后统一规范化Unicode噪声
+	chunker := NewSectionChunker(0.3)                                              // 网页中导航、页脚等模板内容较多，阈值高一些
 	return &CollyPipeline{
 		Crawler: crawler,
 		Cleaner: cleaner,
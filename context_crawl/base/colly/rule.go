@@ -0,0 +1,128 @@
+package colly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+
+	"context_crawl/utils"
+)
+
+// extractBody 从body中移除脚本和站点规则指定的元素，并截取正文区域
+// 优先使用请求指定的选择器或URL锚点定位的章节，其次使用站点规则的正文选择器，都未命中时返回整个body
+func extractBody(body *goquery.Selection, rule *utils.SiteRule, fragment, selector string) (string, error) {
+	body.Find("script, style, noscript").Remove()
+	if rule != nil {
+		for _, s := range rule.RemoveSelectors {
+			body.Find(s).Remove()
+		}
+	}
+
+	if target, ok := selectTarget(body, fragment, selector); ok {
+		return target, nil
+	}
+
+	if rule != nil {
+		for _, s := range rule.ContentSelectors {
+			if content := body.Find(s); content.Length() > 0 {
+				return outerHTML(content), nil
+			}
+		}
+	}
+
+	return body.Html()
+}
+
+const (
+	renderTimeout = 30 * time.Second // 单次浏览器渲染的超时时间，请求的截止时间更早时以截止时间为准
+	renderReserve = time.Second      // 渲染之后清洗、分块需要的时间，渲染需在截止时间前这么久结束
+	minRenderTime = 3 * time.Second  // 剩余时间少于该值时不再尝试浏览器渲染
+)
+
+// renderContext 由请求的ctx派生渲染用的ctx，为后续处理预留时间；剩余时间不足以渲染时返回false
+func renderContext(ctx context.Context) (context.Context, context.CancelFunc, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		renderCtx, cancel := context.WithCancel(ctx)
+		return renderCtx, cancel, true
+	}
+	if time.Until(deadline) < minRenderTime+renderReserve {
+		return nil, nil, false
+	}
+	renderCtx, cancel := context.WithDeadline(ctx, deadline.Add(-renderReserve))
+	return renderCtx, cancel, true
+}
+
+// renderPage 使用浏览器渲染页面并返回渲染后的完整HTML
+// 设置了waitSelector时等待该元素出现，否则等待固定时间；ctx取消时放弃渲染并关闭浏览器
+func renderPage(ctx context.Context, url string, rule *utils.SiteRule) (string, error) {
+	select {
+	case chromedpSem <- struct{}{}: // 与异步抓取共用浏览器并发限制
+	case <-ctx.Done():
+		return "", fmt.Errorf("浏览器渲染失败: 等待浏览器时超时: %v", ctx.Err())
+	}
+	defer func() { <-chromedpSem }()
+
+	opts := []chromedp.ExecAllocatorOption{
+		chromedp.NoFirstRun,
+		chromedp.NoDefaultBrowserCheck,
+		chromedp.Headless,
+	}
+	if proxy := os.Getenv("http_proxy"); proxy != "" {
+		opts = append(opts, chromedp.ProxyServer(proxy))
+	} else if proxy := os.Getenv("https_proxy"); proxy != "" {
+		opts = append(opts, chromedp.ProxyServer(proxy))
+	}
+
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
+	defer cancel()
+	ctx, cancel = chromedp.NewContext(allocCtx)
+	defer cancel()
+	ctx, cancel = context.WithTimeout(ctx, renderTimeout)
+	defer cancel()
+
+	var actions []chromedp.Action
+	if rule != nil && len(rule.Headers) > 0 {
+		headers := make(network.Headers, len(rule.Headers))
+		for k, v := range rule.Headers {
+			headers[k] = v
+		}
+		actions = append(actions, network.Enable(), network.SetExtraHTTPHeaders(headers))
+	}
+	actions = append(actions, chromedp.Navigate(url))
+	if rule != nil && rule.WaitSelector != "" {
+		actions = append(actions, chromedp.WaitVisible(rule.WaitSelector, chromedp.ByQuery))
+	} else {
+		actions = append(actions, chromedp.Sleep(2*time.Second))
+	}
+
+	var html string
+	actions = append(actions, chromedp.OuterHTML("html", &html))
+	if err := chromedp.Run(ctx, actions...); err != nil {
+		return "", fmt.Errorf("浏览器渲染失败: %v", err)
+	}
+	if containsErrorMessages(html) {
+		log.Printf("⚠️ 渲染结果中包含错误信息: %s", url)
+	}
+	return html, nil
+}
+
+// crawlRendered 渲染页面后按站点规则抽取正文
+func crawlRendered(ctx context.Context, url string, rule *utils.SiteRule, fragment, selector string) (string, error) {
+	html, err := renderPage(ctx, url, rule)
+	if err != nil {
+		return "", err
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return "", fmt.Errorf("网页解析失败: %v", err)
+	}
+	return extractBody(doc.Find("body"), rule, fragment, selector)
+}
@@ -0,0 +1,47 @@
+package colly
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRenderContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining time.Duration // 0表示没有截止时间
+		ok        bool
+	}{
+		{"no deadline", 0, true},
+		{"plenty of time", 20 * time.Second, true},
+		{"just enough", minRenderTime + renderReserve + time.Second, true},
+		{"too little", minRenderTime, false},
+		{"expired", -time.Second, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.remaining != 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), tt.remaining)
+			}
+			defer cancel()
+
+			renderCtx, cancelRender, ok := renderContext(ctx)
+			if ok != tt.ok {
+				t.Fatalf("renderContext() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			defer cancelRender()
+			parent, hasParent := ctx.Deadline()
+			deadline, has := renderCtx.Deadline()
+			if has != hasParent {
+				t.Fatalf("renderContext() has deadline = %v, want %v", has, hasParent)
+			}
+			if has && parent.Sub(deadline) != renderReserve {
+				t.Errorf("renderContext() reserve = %v, want %v", parent.Sub(deadline), renderReserve)
+			}
+		})
+	}
+}
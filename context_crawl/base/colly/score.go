@@ -0,0 +1,256 @@
+package colly
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"context_crawl/base/sentence"
+	"context_crawl/base/summary"
+	"context_crawl/types"
+)
+
+var (
+	// 评分用的词：单个汉字，或连续的非汉字字母数字
+	reWord       = regexp.MustCompile(`\p{Han}|[^\s\p{Han}\p{P}\p{S}]+`)
+	reLinkMarker = regexp.MustCompile(`\[(\d+)\]`)
+)
+
+// boilerplatePhrases 常见的页面外围文案，命中越多越像模板内容
+var boilerplatePhrases = []string{
+	"cookie", "all rights reserved", "privacy policy", "terms of service", "terms of use", "share this",
+	"subscribe", "sign in", "sign up", "log in", "skip to content", "back to top", "related posts",
+	"follow us", "newsletter", "copyright ©", "powered by",
+	"版权所有", "隐私政策", "用户协议", "免责声明", "返回顶部", "上一篇", "下一篇", "扫码", "关注我们",
+	"登录", "注册", "分享到", "相关推荐", "联系我们", "备案号", "icp备",
+}
+
+// DefaultScorer 默认的分块质量评分器，综合以下信号：
+// 有效字符占比、链接密度、虚词占比、句子完整度、重复度以及模板文案
+type DefaultScorer struct{}
+
+// defaultScorer 分块器未设置Scorer时使用的默认评分器
+var defaultScorer = NewDefaultScorer()
+
+// NewDefaultScorer 创建一个默认的评分器
+func NewDefaultScorer() *DefaultScorer {
+	return &DefaultScorer{}
+}
+
+// Score 对分块文本评分，实现types.Scorer接口；链接密度只按文本中的锚文本标记计算
+func (s *DefaultScorer) Score(text string) float64 {
+	return s.ScoreWithLinks(text, nil)
+}
+
+// ScoreWithLinks 对分块文本评分，实现types.LinkScorer接口
+// 链接密度按outlinks对应的「锚文本[n]」计算，未保留超链接时按清洗器留下的锚文本标记计算
+func (s *DefaultScorer) ScoreWithLinks(text string, outlinks []types.Outlink) float64 {
+	text = strings.TrimSpace(text)
+	if stripAnchors(text) == "" {
+		return 0
+	}
+
+	density := linkDensity(text, outlinks)
+	text = stripAnchors(text)
+	words := reWord.FindAllString(strings.ToLower(text), -1)
+	content := contentRatio(text)
+
+	// 行文质量：虚词、完整句子越多越像正文，链接越密集越像导航
+	quality := 0.35 +
+		0.25*stopwordScore(text, words) +
+		0.2*completeness(text) +
+		0.2*(1-density)
+
+	score := content * quality * repetitionFactor(words) * boilerplateFactor(text)
+	if score > 1.0 {
+		score = 1.0
+	}
+	return score
+}
+
+// contentRatio 字母、数字、汉字占全部非空白字符的比例，按字符而不是字节计算
+func contentRatio(text string) float64 {
+	total, useful := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			useful++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(useful) / float64(total)
+}
+
+// stopwordScore 虚词占比相对正常行文的接近程度，中英文分别计算后取较大者
+func stopwordScore(text string, words []string) float64 {
+	en := 0.0
+	if latin := countLatinWords(words); latin > 0 {
+		hits := 0
+		for _, w := range words {
+			if summary.IsStopword(w) {
+				hits++
+			}
+		}
+		en = clamp(float64(hits) / float64(latin) / 0.3)
+	}
+
+	zh := 0.0
+	han, hits := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			han++
+			if summary.IsStopword(string(r)) {
+				hits++
+			}
+		}
+	}
+	if han > 0 {
+		zh = clamp(float64(hits) / float64(han) / 0.08)
+	}
+
+	if en > zh {
+		return en
+	}
+	return zh
+}
+
+// completeness 以句末标点结束的句子所占比例
+func completeness(text string) float64 {
+	sentences := sentence.Split(text)
+	if len(sentences) == 0 {
+		return 0
+	}
+	complete := 0
+	for _, s := range sentences {
+		s = strings.TrimRight(s, "\"'”’」』）)]】》")
+		last, _ := utf8.DecodeLastRuneInString(s)
+		if strings.ContainsRune(".!?。！？；…:：", last) {
+			complete++
+		}
+	}
+	return float64(complete) / float64(len(sentences))
+}
+
+// linkDensity 锚文本的词数占全部词数的比例，导航栏、目录等往往全是链接
+// 未保留超链接时锚文本由anchorStart、anchorEnd包围；保留时正文中的链接以「锚文本[n]」标记，
+// n对应outlinks的序号，不对应任何outlink的 [n] 是引用文献等，不计入
+func linkDensity(text string, outlinks []types.Outlink) float64 {
+	if strings.ContainsAny(text, anchorStart+anchorEnd) {
+		return hiddenLinkDensity(text)
+	}
+	words := reWord.FindAllString(strings.ToLower(text), -1)
+	if len(words) == 0 || len(outlinks) == 0 {
+		return 0
+	}
+	anchorWords, markers := 0, 0
+	for _, m := range reLinkMarker.FindAllStringSubmatchIndex(text, -1) {
+		index, _ := strconv.Atoi(text[m[2]:m[3]])
+		if index < 1 || index > len(outlinks) || outlinks[index-1].Index != index {
+			continue
+		}
+		markers++ // 标记中的序号也被计为一个词，不算在正文词数内
+		// 同一地址的锚文本可能不同，对不上时只按一个词计
+		anchor := outlinks[index-1].Text
+		if anchor == "" || !strings.HasSuffix(strings.TrimRight(text[:m[0]], " "), anchor) {
+			anchorWords++
+			continue
+		}
+		anchorWords += max(1, len(reWord.FindAllString(strings.ToLower(anchor), -1)))
+	}
+	if markers == 0 || len(words) <= markers {
+		return clamp(float64(markers))
+	}
+	return clamp(float64(anchorWords) / float64(len(words)-markers))
+}
+
+// hiddenLinkDensity 按锚文本标记计算链接密度；分块边界可能把标记拆开，缺少的一端按分块的开头或结尾处理
+func hiddenLinkDensity(text string) float64 {
+	total := len(reWord.FindAllString(strings.ToLower(stripAnchors(text)), -1))
+	if total == 0 {
+		return 0
+	}
+	anchorWords := 0
+	inside := strings.Index(text, anchorEnd) >= 0 &&
+		(strings.Index(text, anchorStart) < 0 || strings.Index(text, anchorEnd) < strings.Index(text, anchorStart))
+	for text != "" {
+		next := anchorStart
+		if inside {
+			next = anchorEnd
+		}
+		i := strings.Index(text, next)
+		if i < 0 {
+			i = len(text)
+		}
+		if inside {
+			anchorWords += len(reWord.FindAllString(strings.ToLower(text[:i]), -1))
+		}
+		if i == len(text) {
+			break
+		}
+		text = text[i+len(next):]
+		inside = !inside
+	}
+	return clamp(float64(anchorWords) / float64(total))
+}
+
+// repetitionFactor 词汇重复度惩罚：同一批词反复出现（如重复的按钮、标签）时降低分数
+func repetitionFactor(words []string) float64 {
+	if len(words) < 20 {
+		return 1
+	}
+	unique := make(map[string]bool, len(words))
+	for _, w := range words {
+		unique[w] = true
+	}
+	ratio := float64(len(unique)) / float64(len(words))
+	if ratio >= 0.3 {
+		return 1
+	}
+	return 0.5 + ratio/0.6
+}
+
+// boilerplateFactor 模板文案惩罚，每命中一个短语分数降低，最多降到三成
+func boilerplateFactor(text string) float64 {
+	lower := strings.ToLower(text)
+	hits := 0
+	for _, phrase := range boilerplatePhrases {
+		if strings.Contains(lower, phrase) {
+			hits++
+		}
+	}
+	// 长文本偶尔出现一两个短语属于正常，按长度折算
+	density := float64(hits) * 200 / float64(utf8.RuneCountInString(text)+200)
+	factor := 1 - 0.35*density
+	if factor < 0.3 {
+		return 0.3
+	}
+	return factor
+}
+
+func countLatinWords(words []string) int {
+	n := 0
+	for _, w := range words {
+		r, _ := utf8.DecodeRuneInString(w)
+		if r < utf8.RuneSelf {
+			n++
+		}
+	}
+	return n
+}
+
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package colly
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestLinkDensity(t *testing.T) {
+	outlinks := []types.Outlink{
+		{Index: 1, Text: "Home"},
+		{Index: 2, Text: "Getting started"},
+		{Index: 3, Text: "API reference"},
+	}
+	tests := []struct {
+		name     string
+		text     string
+		outlinks []types.Outlink
+		want     float64
+	}{
+		{"navigation", "Home[1] Getting started[2] API reference[3]", outlinks, 1},
+		{"prose with one link", "Read the Getting started[2] guide before you install anything on the server.", outlinks, 2.0 / 12},
+		{"citations are not links", "Transformers were introduced in 2017 [7] and scaled later [9].", outlinks, 0},
+		{"no outlinks", "Home[1] Getting started[2]", nil, 0},
+		{"different anchor text", "see docs[3] for more", outlinks, 1.0 / 4},
+		{"empty", "", outlinks, 0},
+		// 未保留超链接时按清洗器留下的锚文本标记计算
+		{"hidden navigation", anchorStart + "Home" + anchorEnd + " " + anchorStart + "Getting started" + anchorEnd, nil, 1},
+		{"hidden link in prose", "Read the " + anchorStart + "Getting started" + anchorEnd + " guide before you install anything.", nil, 2.0 / 9},
+		{"hidden anchor split by chunk boundary", "started" + anchorEnd + " then read the rest of the page", nil, 1.0 / 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linkDensity(tt.text, tt.outlinks); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("linkDensity(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreWithLinks(t *testing.T) {
+	outlinks := []types.Outlink{
+		{Index: 1, Text: "Home"}, {Index: 2, Text: "Blog"}, {Index: 3, Text: "Pricing"}, {Index: 4, Text: "Docs"},
+	}
+	prose := "The scheduler assigns each job to the worker with the fewest pending tasks. If a worker stops responding, its jobs are moved to the queue again."
+	nav := "Home[1] Blog[2] Pricing[3] Docs[4]"
+
+	scorer := NewDefaultScorer()
+	if p, n := scorer.ScoreWithLinks(prose, outlinks), scorer.ScoreWithLinks(nav, outlinks); p <= n {
+		t.Errorf("prose score %v should be higher than navigation score %v", p, n)
+	}
+	// 不传outlinks时导航文本中的 [n] 不计为链接
+	if with, without := scorer.ScoreWithLinks(nav, outlinks), scorer.Score(nav); with >= without {
+		t.Errorf("score with links %v should be lower than without %v", with, without)
+	}
+}
+
+func TestNavigationPenalizedWithoutPreserveLinks(t *testing.T) {
+	html := `<nav><ul><li><a href="/">Home</a></li><li><a href="/blog">Blog</a></li><li><a href="/pricing">Pricing</a></li>` +
+		`<li><a href="/docs">Docs</a></li><li><a href="/about">About us</a></li></ul></nav>` +
+		`<p>The scheduler assigns each job to the worker with the fewest pending tasks. ` +
+		`If a worker stops responding, its jobs are moved to the <a href="/queue">queue</a> again.</p>`
+	score := func(preserve bool) (nav, prose types.Chunk) {
+		input := types.Type{Url: "https://example.com/", Text: html, Options: types.Options{PreserveLinks: preserve}}
+		cleaned, err := NewBasicCleaner().Clean(input)
+		if err != nil {
+			t.Fatalf("Clean() error = %v", err)
+		}
+		chunker := NewSectionChunker(0)
+		chunker.ChunkSize = 60
+		result, err := chunker.Chunk(cleaned)
+		if err != nil {
+			t.Fatalf("Chunk() error = %v", err)
+		}
+		for _, c := range result.Chunks {
+			if strings.Contains(c.Text, anchorStart) || strings.Contains(c.Text, anchorEnd) {
+				t.Errorf("chunk %q contains anchor markers", c.Text)
+			}
+			if strings.Contains(c.Text, "scheduler") {
+				prose = c
+			} else if strings.Contains(c.Text, "Pricing") {
+				nav = c
+			}
+		}
+		if !preserve && (result.Outlinks != nil || strings.Contains(result.Text, "[1]")) {
+			t.Errorf("links leaked into the response without preserve_links: %q %v", result.Text, result.Outlinks)
+		}
+		return nav, prose
+	}
+
+	for _, preserve := range []bool{false, true} {
+		nav, prose := score(preserve)
+		if nav.Text == "" || prose.Text == "" {
+			t.Fatalf("preserve_links=%v: missing chunks nav=%q prose=%q", preserve, nav.Text, prose.Text)
+		}
+		if nav.Score >= prose.Score {
+			t.Errorf("preserve_links=%v: navigation score %v should be lower than prose score %v", preserve, nav.Score, prose.Score)
+		}
+	}
+	// 链接标记被隐藏后导航块仍应按链接密度扣分，而不是按纯文本评分
+	hidden, _ := score(false)
+	if plain := NewDefaultScorer().Score(hidden.Text); hidden.Score >= plain {
+		t.Errorf("navigation score without preserve_links = %v, want lower than plain text score %v", hidden.Score, plain)
+	}
+}
@@ -0,0 +1,140 @@
+package colly
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// headingLevel 返回h1~h6的级别，非标题返回0
+func headingLevel(node *html.Node) int {
+	if node == nil || node.Type != html.ElementNode || len(node.Data) != 2 || node.Data[0] != 'h' {
+		return 0
+	}
+	if level := int(node.Data[1] - '0'); level >= 1 && level <= 6 {
+		return level
+	}
+	return 0
+}
+
+// fragmentOf 取出URL中的锚点
+func fragmentOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Fragment
+}
+
+// selectTarget 按请求的CSS选择器或URL锚点截取页面中的目标区域
+// 选择器优先；都未命中时返回false，由调用方使用完整页面
+func selectTarget(body *goquery.Selection, fragment, selector string) (string, bool) {
+	if selector != "" {
+		if target := body.Find(selector); target.Length() > 0 {
+			return outerHTML(target), true
+		}
+	}
+	if fragment == "" {
+		return "", false
+	}
+
+	anchor := findAnchor(body, fragment)
+	if anchor == nil {
+		return "", false
+	}
+	return sectionHTML(anchor), true
+}
+
+// findAnchor 查找id或name等于锚点的元素
+// GitHub渲染的README会给标题id加上user-content-前缀，一并尝试
+func findAnchor(body *goquery.Selection, fragment string) *goquery.Selection {
+	candidates := []string{fragment, "user-content-" + fragment}
+	for _, name := range candidates {
+		var found *goquery.Selection
+		body.Find("[id], [name]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+			id, _ := s.Attr("id")
+			n, _ := s.Attr("name")
+			if id == name || n == name {
+				found = s
+				return false
+			}
+			return true
+		})
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// sectionHTML 根据锚点元素确定章节范围
+// 锚点是标题（或位于标题内、紧挨着标题的空锚点）时，取该标题到下一个同级或更高级标题之间的内容；
+// 否则认为锚点元素本身就是章节容器（如 <section id="...">）
+func sectionHTML(anchor *goquery.Selection) string {
+	heading := anchor
+	if headingLevel(anchor.Get(0)) == 0 {
+		if parent := anchor.Closest("h1, h2, h3, h4, h5, h6"); parent.Length() > 0 {
+			heading = parent
+		} else if strings.TrimSpace(anchor.Text()) == "" {
+			if next := anchor.NextAll().First(); next.Length() > 0 && headingLevel(next.Get(0)) > 0 {
+				heading = next
+			}
+		}
+	}
+
+	level := headingLevel(heading.Get(0))
+	if level == 0 {
+		return outerHTML(anchor)
+	}
+
+	var parts []string
+	parts = append(parts, outerHTML(heading))
+	for node := heading.Get(0).NextSibling; node != nil; node = node.NextSibling {
+		if l := headingLevel(node); l > 0 && l <= level {
+			break
+		}
+		// 章节结束标题也可能被包在div等容器中
+		if node.Type == html.ElementNode {
+			if containsHeadingAtOrAbove(goquery.NewDocumentFromNode(node).Selection, level) {
+				break
+			}
+		}
+		parts = append(parts, renderNode(node))
+	}
+	return strings.Join(parts, "")
+}
+
+// containsHeadingAtOrAbove 判断容器中是否包含同级或更高级的标题
+func containsHeadingAtOrAbove(sel *goquery.Selection, level int) bool {
+	stop := false
+	sel.Find("h1, h2, h3, h4, h5, h6").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if headingLevel(s.Get(0)) <= level {
+			stop = true
+			return false
+		}
+		return true
+	})
+	return stop
+}
+
+// outerHTML 拼接选区中所有元素的HTML
+func outerHTML(sel *goquery.Selection) string {
+	var parts []string
+	sel.Each(func(i int, s *goquery.Selection) {
+		if h, err := goquery.OuterHtml(s); err == nil {
+			parts = append(parts, h)
+		}
+	})
+	return strings.Join(parts, "\n")
+}
+
+// renderNode 输出单个节点（包括文本节点）的HTML
+func renderNode(node *html.Node) string {
+	var b strings.Builder
+	if err := html.Render(&b, node); err != nil {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,171 @@
+package colly
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// parseBody 解析HTML片段，返回body选区
+func parseBody(t *testing.T, page string) *goquery.Selection {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><body>" + page + "</body></html>"))
+	if err != nil {
+		t.Fatalf("parse HTML: %v", err)
+	}
+	return doc.Find("body")
+}
+
+// visibleText 截取结果中各文本节点的文字，以一个空格分隔
+func visibleText(t *testing.T, fragment string) string {
+	t.Helper()
+	var words []string
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			words = append(words, strings.Fields(node.Data)...)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(parseBody(t, fragment).Get(0))
+	return strings.Join(words, " ")
+}
+
+func TestFragmentOf(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/docs#install", "install"},
+		{"https://example.com/docs#%E5%AE%89%E8%A3%85", "安装"},
+		{"https://example.com/docs", ""},
+		{"https://example.com/docs#", ""},
+		{"://bad url#install", ""},
+	}
+	for _, tt := range tests {
+		if got := fragmentOf(tt.url); got != tt.want {
+			t.Errorf("fragmentOf(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestExtractBodySection(t *testing.T) {
+	const readme = `<h1>Tool</h1><p>intro</p>` +
+		`<h2 id="install">Install</h2><p>run make</p><h3>From source</h3><p>clone it</p>` +
+		`<h2 id="usage">Usage</h2><p>call it</p>`
+	tests := []struct {
+		name     string
+		page     string
+		fragment string
+		selector string
+		want     string
+	}{
+		{
+			name:     "heading id up to the next heading of the same level",
+			page:     readme,
+			fragment: "install",
+			want:     "Install run make From source clone it",
+		},
+		{
+			name:     "last section runs to the end",
+			page:     readme,
+			fragment: "usage",
+			want:     "Usage call it",
+		},
+		{
+			name:     "empty anchor before the heading",
+			page:     `<p>intro</p><a id="usage"></a><h2>Usage</h2><p>call it</p><h2>License</h2><p>MIT</p>`,
+			fragment: "usage",
+			want:     "Usage call it",
+		},
+		{
+			name:     "named anchor inside the heading",
+			page:     `<h2><a name="usage"></a>Usage</h2><p>call it</p><h1>Appendix</h1><p>more</p>`,
+			fragment: "usage",
+			want:     "Usage call it",
+		},
+		{
+			name: "next heading wrapped in a div",
+			page: `<h2 id="install">Install</h2><p>run make</p>` +
+				`<div class="sub"><h3>From source</h3><p>clone it</p></div>` +
+				`<div class="section"><h2>Usage</h2><p>call it</p></div>`,
+			fragment: "install",
+			want:     "Install run make From source clone it",
+		},
+		{
+			name:     "user-content prefix from rendered READMEs",
+			page:     `<h2 id="user-content-install">Install</h2><p>run make</p><h2 id="user-content-usage">Usage</h2>`,
+			fragment: "install",
+			want:     "Install run make",
+		},
+		{
+			name:     "anchor on a section container",
+			page:     `<p>intro</p><section id="faq"><h3>Why?</h3><p>because</p></section><p>footer</p>`,
+			fragment: "faq",
+			want:     "Why? because",
+		},
+		{
+			name:     "selector wins over the fragment",
+			page:     `<main><p>main text</p></main>` + readme,
+			fragment: "install",
+			selector: "main",
+			want:     "main text",
+		},
+		{
+			name:     "unmatched selector falls back to the fragment",
+			page:     readme,
+			fragment: "usage",
+			selector: "article",
+			want:     "Usage call it",
+		},
+		{
+			name:     "missing fragment returns the whole page",
+			page:     readme,
+			fragment: "changelog",
+			want:     "Tool intro Install run make From source clone it Usage call it",
+		},
+		{
+			name: "no fragment or selector returns the whole page",
+			page: `<p>intro</p><script>track()</script><h2 id="usage">Usage</h2>`,
+			want: "intro Usage",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractBody(parseBody(t, tt.page), nil, tt.fragment, tt.selector)
+			if err != nil {
+				t.Fatalf("extractBody() error = %v", err)
+			}
+			if text := visibleText(t, got); text != tt.want {
+				t.Errorf("extractBody(%q, %q) text = %q, want %q", tt.fragment, tt.selector, text, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindAnchor(t *testing.T) {
+	body := parseBody(t, `<h2 id="user-content-usage">Rendered</h2><h2 id="usage">Plain</h2><a name="api"></a>`)
+	tests := []struct {
+		fragment string
+		want     string // 命中元素的id或name，空表示未命中
+	}{
+		{"usage", "usage"}, // 原样的id优先于带前缀的
+		{"api", "api"},
+		{"user-content-usage", "user-content-usage"},
+		{"missing", ""},
+	}
+	for _, tt := range tests {
+		anchor := findAnchor(body, tt.fragment)
+		got := ""
+		if anchor != nil {
+			got = anchor.AttrOr("id", anchor.AttrOr("name", ""))
+		}
+		if got != tt.want {
+			t.Errorf("findAnchor(%q) = %q, want %q", tt.fragment, got, tt.want)
+		}
+	}
+}
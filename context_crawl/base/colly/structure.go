@@ -0,0 +1,193 @@
+package colly
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"context_crawl/base/sentence"
+	"context_crawl/base/tokenizer"
+	"context_crawl/types"
+)
+
+var (
+	reHeadingLine = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*$`)
+	reCodeToken   = regexp.MustCompile(`@CODE_\d+@`)
+	rePageLine    = regexp.MustCompile(`^@PAGE_(\d+)@$`)
+)
+
+// maxInlineCode 不含换行且不超过该长度的代码视为行内代码，保留在正文中
+const maxInlineCode = 80
+
+// SectionChunker 按文档结构分块：先按标题切分章节，再在章节内按段落、列表项和代码块打包
+// 每个分块记录所在章节的标题路径；大小、重叠和评分沿用ScoredChunker的配置
+type SectionChunker struct {
+	*ScoredChunker
+}
+
+// NewSectionChunker 创建一个新的SectionChunker实例
+func NewSectionChunker(scoreThreshold float64) *SectionChunker {
+	return &SectionChunker{
+		ScoredChunker: NewScoredChunker(scoreThreshold),
+	}
+}
+
+// heading 标题栈中的一项
+type heading struct {
+	level int
+	text  string
+}
+
+// Chunk 对带结构标记的文本进行分块，实现types.Chunker接口
+// 输入文本中以 # 开头的行为标题，单独一行的页码标记（types.PageMarker）更新当前页，其余每个非空行为一个段落或列表项
+func (c *SectionChunker) Chunk(input types.Type) (types.Type, error) {
+	chunkSize, overlap, counter := c.settings(input.Options)
+	codeMap := input.CodeMap
+	if codeMap == nil {
+		codeMap = make(map[string]string)
+	}
+
+	var chunks []types.Chunk
+	var stack []heading
+	var paragraphs []string
+	var pages []int // 与paragraphs一一对应的页码
+	page := 0       // 当前页码，文本中没有页码标记时始终为0
+
+	headingPath := func() []string {
+		path := make([]string, 0, len(stack))
+		for _, h := range stack {
+			path = append(path, h.text)
+		}
+		return path
+	}
+
+	// 将当前章节内累积的段落打包成分块
+	flushSection := func() {
+		if len(paragraphs) == 0 {
+			return
+		}
+		path := headingPath()
+		units, owners := paragraphUnits(paragraphs, chunkSize, counter)
+		for _, current := range packSpans(units, chunkSize, overlap, counter) {
+			score := c.chunkScore(current.text, input.Outlinks)
+			if score >= c.ScoreThreshold {
+				chunks = append(chunks, types.Chunk{
+					Text:        stripAnchors(current.text),
+					Score:       score,
+					HeadingPath: path,
+					PageStart:   pages[owners[current.first]],
+					PageEnd:     pages[owners[current.last]],
+				})
+			}
+		}
+		paragraphs, pages = nil, nil
+	}
+
+	for _, line := range strings.Split(input.Text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// 页码标记只更新当前页，章节可以跨页
+		if m := rePageLine.FindStringSubmatch(line); m != nil {
+			page, _ = strconv.Atoi(m[1])
+			continue
+		}
+
+		if m := reHeadingLine.FindStringSubmatch(line); m != nil {
+			flushSection()
+			level := len(m[1])
+			for len(stack) > 0 && stack[len(stack)-1].level >= level {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, heading{level: level, text: inlineCode(stripAnchors(m[2]), codeMap)})
+			continue
+		}
+
+		// 行内代码还原到段落中，代码块单独成块；独占一行的代码视为代码块，即使只有一行
+		if !reCodeToken.MatchString(line) || reCodeToken.FindString(line) != line {
+			line = inlineCode(line, codeMap)
+		}
+		placeholders := reCodeToken.FindAllString(line, -1)
+		for i, segment := range reCodeToken.Split(line, -1) {
+			if segment = strings.TrimSpace(segment); segment != "" {
+				paragraphs = append(paragraphs, segment)
+				pages = append(pages, page)
+			}
+			if i < len(placeholders) {
+				flushSection()
+				chunks = append(chunks, types.Chunk{
+					Text:        codeMap[placeholders[i]],
+					Score:       1.0,
+					IsCode:      true,
+					Lang:        input.CodeLang[placeholders[i]],
+					HeadingPath: headingPath(),
+					PageStart:   page,
+					PageEnd:     page,
+				})
+			}
+		}
+	}
+	flushSection()
+
+	chunks = collapseDuplicates(chunks, input.Url, !input.Options.KeepDuplicates)
+
+	if len(chunks) == 0 {
+		chunks = append(chunks, types.Chunk{
+			Text:  types.EmptyChunkText,
+			Score: 0.0,
+		})
+	}
+
+	result := types.Type{
+		Url:      input.Url,
+		Text:     types.FormatChunks(chunks),
+		Options:  input.Options,
+		Outlinks: input.Outlinks,
+		Images:   input.Images,
+		Chunks:   chunks,
+		Metadata: input.Metadata,
+		Toc:      input.Toc,
+	}
+	if input.Options.Summary {
+		result.Summary, result.Keywords = summarize(stripAnchors(input.Text))
+	}
+	return result, nil
+}
+
+// paragraphUnits 将段落转为打包单位：放得下的段落整体作为一个单位，
+// 超长段落按句子拆开；每个段落的最后一个单位以换行结尾，拼接时保留段落边界
+// 第二个返回值与单位一一对应，记录该单位来自第几个段落
+func paragraphUnits(paragraphs []string, chunkSize int, counter tokenizer.Counter) ([]string, []int) {
+	var units []string
+	var owners []int
+	for i, p := range paragraphs {
+		if counter.Count(p) <= chunkSize {
+			units = append(units, p+"\n")
+			owners = append(owners, i)
+			continue
+		}
+		sentences := sentence.Split(p)
+		if len(sentences) > 0 {
+			sentences[len(sentences)-1] += "\n"
+		}
+		units = append(units, sentences...)
+		for range sentences {
+			owners = append(owners, i)
+		}
+	}
+	return units, owners
+}
+
+// inlineCode 将短小的单行代码占位符还原为 `code`，代码块占位符保持不变
+func inlineCode(line string, codeMap map[string]string) string {
+	return reCodeToken.ReplaceAllStringFunc(line, func(ph string) string {
+		code, ok := codeMap[ph]
+		if !ok || strings.Contains(code, "\n") || utf8.RuneCountInString(code) > maxInlineCode {
+			return ph
+		}
+		return "`" + code + "`"
+	})
+}
@@ -0,0 +1,178 @@
+package colly
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestMarkStructure(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "heading levels",
+			html: `<h1>Guide</h1><p>intro</p><h3 class="x">Deep <em>dive</em></h3>`,
+			want: "# Guide\nintro\n### Deep dive",
+		},
+		{
+			name: "heading split across lines",
+			html: "<h2>\n  Install\n  steps\n</h2>text",
+			want: "## Install steps\ntext",
+		},
+		{
+			name: "empty heading dropped",
+			html: `<p>before</p><h2><a id="top"></a></h2><p>after</p>`,
+			want: "before\nafter",
+		},
+		{
+			name: "list items",
+			html: `<ul><li>first step</li><li class="done">second step</li><li></li></ul>`,
+			want: "- first step\n- second step",
+		},
+		{
+			name: "block tags and breaks",
+			html: `<div>one<br>two<br/>three</div><hr><blockquote>quote</blockquote><span>inline</span> text`,
+			want: "one\ntwo\nthree\nquote\ninline text",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 与清洗流程一样，标记结构后再去掉剩余的标签
+			if got := collapseSpaces(reTag.ReplaceAllString(markStructure(tt.html), "")); got != tt.want {
+				t.Errorf("markStructure(%q) = %q, want %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
+
+// chunkView 分块中参与比较的字段
+type chunkView struct {
+	Text      string
+	Path      string // 标题路径，以 " > " 连接
+	IsCode    bool
+	Lang      string
+	PageStart int
+	PageEnd   int
+}
+
+func TestSectionChunker(t *testing.T) {
+	longCode := "docker run --rm -it -v $(pwd):/workspace -w /workspace golang:1.22 go test -race -count=1 ./..."
+	notice := "We use cookies to improve your experience on our website and to show relevant ads."
+	tests := []struct {
+		name     string
+		text     string
+		codeMap  map[string]string
+		codeLang map[string]string
+		want     []chunkView
+	}{
+		{
+			name: "heading paths across levels",
+			text: "# Guide\nintro\n## Install\nrun make\n### Linux\nuse apt\n## Usage\ncall it\n# FAQ\nask away",
+			want: []chunkView{
+				{Text: "intro", Path: "Guide"},
+				{Text: "run make", Path: "Guide > Install"},
+				{Text: "use apt", Path: "Guide > Install > Linux"},
+				{Text: "call it", Path: "Guide > Usage"},
+				{Text: "ask away", Path: "FAQ"},
+			},
+		},
+		{
+			name: "skipped level pops deeper headings",
+			text: "## Install\n#### Notes\nsee below\n### Linux\nuse apt",
+			want: []chunkView{
+				{Text: "see below", Path: "Install > Notes"},
+				{Text: "use apt", Path: "Install > Linux"},
+			},
+		},
+		{
+			name: "text before the first heading",
+			text: "preface\n# Guide\nintro",
+			want: []chunkView{
+				{Text: "preface"},
+				{Text: "intro", Path: "Guide"},
+			},
+		},
+		{
+			name: "list items stay in one chunk",
+			text: "# Steps\n- first step\n- second step",
+			want: []chunkView{{Text: "- first step\n- second step", Path: "Steps"}},
+		},
+		{
+			name:     "inline code restored into the paragraph",
+			text:     "# Test\nRun @CODE_0@ before pushing.",
+			codeMap:  map[string]string{"@CODE_0@": "go test ./..."},
+			codeLang: map[string]string{"@CODE_0@": "bash"},
+			want:     []chunkView{{Text: "Run `go test ./...` before pushing.", Path: "Test"}},
+		},
+		{
+			name:     "inline code in a heading",
+			text:     "# The @CODE_0@ flag\nenables the race detector",
+			codeMap:  map[string]string{"@CODE_0@": "-race"},
+			codeLang: map[string]string{},
+			want:     []chunkView{{Text: "enables the race detector", Path: "The `-race` flag"}},
+		},
+		{
+			name:     "long code splits the paragraph",
+			text:     "# Test\nRun @CODE_0@ in CI.",
+			codeMap:  map[string]string{"@CODE_0@": longCode},
+			codeLang: map[string]string{"@CODE_0@": "bash"},
+			want: []chunkView{
+				{Text: "Run", Path: "Test"},
+				{Text: longCode, Path: "Test", IsCode: true, Lang: "bash"},
+				{Text: "in CI.", Path: "Test"},
+			},
+		},
+		{
+			name:     "multi-line and standalone code are blocks",
+			text:     "# Build\n@CODE_0@\nthen\n@CODE_1@",
+			codeMap:  map[string]string{"@CODE_0@": "make\nmake install", "@CODE_1@": "make test"},
+			codeLang: map[string]string{"@CODE_0@": "sh"},
+			want: []chunkView{
+				{Text: "make\nmake install", Path: "Build", IsCode: true, Lang: "sh"},
+				{Text: "then", Path: "Build"},
+				{Text: "make test", Path: "Build", IsCode: true},
+			},
+		},
+		{
+			name: "duplicate chunks collapsed",
+			text: "# Home\n" + notice + "\n# Blog\n" + notice + "\n# About\nWe build tools for reading documentation offline.",
+			want: []chunkView{
+				{Text: notice, Path: "Home"},
+				{Text: "We build tools for reading documentation offline.", Path: "About"},
+			},
+		},
+		{
+			name: "empty page",
+			text: "# Title only\n@PAGE_1@",
+			want: []chunkView{{Text: types.EmptyChunkText}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := types.Type{Url: "https://example.com", Text: tt.text, CodeMap: tt.codeMap, CodeLang: tt.codeLang}
+			result, err := NewSectionChunker(0).Chunk(input)
+			if err != nil {
+				t.Fatalf("Chunk() error = %v", err)
+			}
+			got := make([]chunkView, 0, len(result.Chunks))
+			for _, c := range result.Chunks {
+				got = append(got, chunkView{
+					Text:      c.Text,
+					Path:      strings.Join(c.HeadingPath, " > "),
+					IsCode:    c.IsCode,
+					Lang:      c.Lang,
+					PageStart: c.PageStart,
+					PageEnd:   c.PageEnd,
+				})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Chunk() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package colly
+
+import (
+	"regexp"
+	"strings"
+
+	"context_crawl/base/sentence"
+	"context_crawl/base/summary"
+)
+
+// reImageMarkdown 正文中的图片占位 ![alt](src)
+var reImageMarkdown = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+
+// summarize 从清洗后的文本生成摘要和关键词：标题行、代码、图片和链接标记不参与
+func summarize(text string) (string, []string) {
+	var sentences []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || reHeadingLine.MatchString(line) {
+			continue
+		}
+		line = reCodeToken.ReplaceAllString(line, " ")
+		line = rePageToken.ReplaceAllString(line, " ")
+		line = reImageMarkdown.ReplaceAllString(line, " ")
+		line = reLinkMarker.ReplaceAllString(line, "")
+		sentences = append(sentences, sentence.Split(line)...)
+	}
+
+	keywords := summary.Keywords(sentences, summary.DefaultKeywords)
+	if keywords == nil {
+		keywords = []string{}
+	}
+	return joinSentences(summary.Summarize(sentences, summary.DefaultSentences)), keywords
+}
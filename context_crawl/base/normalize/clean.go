@@ -0,0 +1,42 @@
+package normalize
+
+import "context_crawl/types"
+
+// Cleaner 在任意清洗器之后执行文本规范化，实现types.Cleaner接口
+// 只处理正文、锚文本和图片说明，代码块保持原样
+type Cleaner struct {
+	Inner   types.Cleaner
+	Options Options
+}
+
+// NewCleaner 创建一个包装inner的规范化清洗器
+func NewCleaner(inner types.Cleaner, options Options) *Cleaner {
+	return &Cleaner{
+		Inner:   inner,
+		Options: options,
+	}
+}
+
+// Clean 先调用内部清洗器，再规范化其结果
+func (c *Cleaner) Clean(input types.Type) (types.Type, error) {
+	result, err := c.Inner.Clean(input)
+	if err != nil {
+		return result, err
+	}
+
+	options := c.Options
+	if input.Options.Simplified {
+		options.Simplified = true
+	}
+
+	result.Text = Text(result.Text, options)
+	for i := range result.Outlinks {
+		result.Outlinks[i].Text = Text(result.Outlinks[i].Text, options)
+	}
+	for i := range result.Images {
+		result.Images[i].Alt = Text(result.Images[i].Alt, options)
+		result.Images[i].Caption = Text(result.Images[i].Caption, options)
+		result.Images[i].Heading = Text(result.Images[i].Heading, options)
+	}
+	return result, nil
+}
@@ -0,0 +1,207 @@
+// ================== 文本规范化：清理Unicode噪声 ===================
+package normalize
+
+import (
+	_ "embed"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// t2sData 繁体到简体的单字对照表，每行两个字：繁体在前；取自OpenCC的字典，来源和许可见仓库根目录的NOTICE
+//
+//go:embed t2s.txt
+var t2sData string
+
+var t2s = make(map[rune]rune)
+
+func init() {
+	for _, line := range strings.Split(t2sData, "\n") {
+		runes := []rune(strings.TrimSpace(line))
+		if len(runes) == 2 {
+			t2s[runes[0]] = runes[1]
+		}
+	}
+}
+
+// Options 每一项规范化都可以单独开关
+type Options struct {
+	NFKC        bool // 兼容字符规范化：全角字母数字、半角片假名、连字、兼容汉字等；不改变中文全角标点、上标和省略号
+	Invisible   bool // 去除零宽字符、软连字符、方向控制符、BOM和控制字符
+	Whitespace  bool // 不换行空格、全角空格、制表符等统一为普通空格，合并连续空格
+	Punctuation bool // 统一中英文标点：汉字之间的半角标点转为全角，英文之间的全角标点转为半角
+	Simplified  bool // 繁体转简体（按单字对照）
+}
+
+// DefaultOptions 默认开启除繁简转换以外的全部规范化
+func DefaultOptions() Options {
+	return Options{NFKC: true, Invisible: true, Whitespace: true, Punctuation: true}
+}
+
+// Text 按options规范化文本，换行保留
+func Text(text string, options Options) string {
+	if options.NFKC {
+		text = compatibility(text)
+	}
+	if options.Invisible {
+		text = stripInvisible(text)
+	}
+	if options.Simplified {
+		text = simplify(text)
+	}
+	if options.Punctuation {
+		text = unifyPunctuation(text)
+	}
+	if options.Whitespace {
+		text = unifyWhitespace(text)
+	}
+	return text
+}
+
+// compatibility 先做NFC，再只对安全范围内的字符做NFKC
+func compatibility(text string) string {
+	text = norm.NFC.String(text)
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		if !safeNFKC(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if r >= 0xFF01 && r <= 0xFF5E {
+			// 全角字母数字转半角，全角标点交给标点统一处理
+			if narrow := []rune(width.Narrow.String(string(r))); len(narrow) == 1 && isASCIIAlnum(narrow[0]) {
+				b.WriteRune(narrow[0])
+				continue
+			}
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteString(norm.NFKC.String(string(r)))
+	}
+	return b.String()
+}
+
+// safeNFKC 兼容分解不会改变含义的字符范围
+func safeNFKC(r rune) bool {
+	switch {
+	case r >= 0xFF01 && r <= 0xFF5E: // 全角ASCII
+		return true
+	case r >= 0xFF61 && r <= 0xFFDC: // 半角片假名、半角谚文
+		return true
+	case r >= 0xFB00 && r <= 0xFB06: // 拉丁连字 ﬁ ﬂ
+		return true
+	case r >= 0xF900 && r <= 0xFAFF: // 兼容汉字
+		return true
+	case r >= 0x2F00 && r <= 0x2FDF: // 康熙部首
+		return true
+	}
+	return false
+}
+
+// isInvisible 需要去除的不可见字符；零宽连接符（U+200D）用于组合emoji，保留
+func isInvisible(r rune) bool {
+	switch r {
+	case 0x200B, 0x200C, 0x2060, 0xFEFF, 0x00AD, 0x200E, 0x200F, 0x061C, 0x180E:
+		return true
+	}
+	if (r >= 0x202A && r <= 0x202E) || (r >= 0x2066 && r <= 0x2069) {
+		return true
+	}
+	// 控制字符中只保留换行和制表符
+	return unicode.IsControl(r) && r != '\n' && r != '\t' && r != '\r'
+}
+
+// stripInvisible 去除不可见字符
+func stripInvisible(text string) string {
+	return strings.Map(func(r rune) rune {
+		if isInvisible(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// simplify 繁体转简体
+func simplify(text string) string {
+	return strings.Map(func(r rune) rune {
+		if s, ok := t2s[r]; ok {
+			return s
+		}
+		return r
+	}, text)
+}
+
+// 中英文标点对照
+var (
+	toFullwidth = map[rune]rune{',': '，', ';': '；', ':': '：', '?': '？', '!': '！', '(': '（', ')': '）'}
+	toHalfwidth = map[rune]rune{'，': ',', '；': ';', '：': ':', '？': '?', '！': '!', '（': '(', '）': ')'}
+)
+
+// unifyPunctuation 汉字之间的半角标点转全角，英文字母数字之间的全角标点转半角
+// 只有两侧（跳过空白后）都是同一种文字时才转换，中英混排的边界保持原样
+func unifyPunctuation(text string) string {
+	runes := []rune(text)
+	for i, r := range runes {
+		prev, next := neighbor(runes, i, -1), neighbor(runes, i, 1)
+		if full, ok := toFullwidth[r]; ok {
+			// 左括号看右侧，右括号看左侧
+			switch {
+			case r == '(' && isHan(next) && (prev == 0 || isHan(prev)):
+				runes[i] = full
+			case r == ')' && isHan(prev) && (next == 0 || isHan(next) || isFullwidthPunct(next)):
+				runes[i] = full
+			case r != '(' && r != ')' && isHan(prev) && (next == 0 || isHan(next)):
+				runes[i] = full
+			}
+			continue
+		}
+		if half, ok := toHalfwidth[r]; ok && isASCIIAlnum(prev) && (next == 0 || isASCIIAlnum(next)) {
+			runes[i] = half
+		}
+	}
+	return string(runes)
+}
+
+// neighbor 向dir方向跳过空格找到相邻字符，遇到换行或到达边界返回0
+func neighbor(runes []rune, i, dir int) rune {
+	for j := i + dir; j >= 0 && j < len(runes); j += dir {
+		if runes[j] == '\n' {
+			return 0
+		}
+		if runes[j] != ' ' && runes[j] != '\t' {
+			return runes[j]
+		}
+	}
+	return 0
+}
+
+// unifyWhitespace 各种空白统一为普通空格，合并连续空格并去掉行首行尾空格，换行保留
+func unifyWhitespace(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.Map(func(r rune) rune {
+			if r != '\n' && (unicode.IsSpace(r) || r == 0x3000 || r == 0x00A0 || r == 0x202F || r == 0x2007) {
+				return ' '
+			}
+			return r
+		}, line)
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isHan(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
+
+func isFullwidthPunct(r rune) bool {
+	return (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF01 && r <= 0xFF0F)
+}
+
+func isASCIIAlnum(r rune) bool {
+	return r < 0x80 && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
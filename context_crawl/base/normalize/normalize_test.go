@@ -0,0 +1,87 @@
+package normalize
+
+import (
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		options Options
+		want    string
+	}{
+		{"fullwidth alnum", "ＧＰＴ－４和ｖ２", DefaultOptions(), "GPT－4和v2"},
+		{"fullwidth punctuation kept between han", "你好，世界！", DefaultOptions(), "你好，世界！"},
+		{"halfwidth comma between han", "你好,世界", DefaultOptions(), "你好，世界"},
+		{"fullwidth comma between latin", "a，b", DefaultOptions(), "a,b"},
+		{"mixed boundary unchanged", "GPU，显卡", DefaultOptions(), "GPU，显卡"},
+		{"ligature", "ﬁle", DefaultOptions(), "file"},
+		{"ellipsis and superscript kept", "x² …", DefaultOptions(), "x² …"},
+		{"zero width and soft hyphen", "to\u200bken\u00adizer\ufeff", DefaultOptions(), "tokenizer"},
+		{"zwj kept", "👩\u200d💻", DefaultOptions(), "👩\u200d💻"},
+		{"control characters", "a\x07b\nc", DefaultOptions(), "ab\nc"},
+		{"whitespace", "a\u00a0\u00a0b\u3000c\t d \nnext", DefaultOptions(), "a b c d\nnext"},
+		{"simplified off by default", "這個軟體", DefaultOptions(), "這個軟體"},
+		{"simplified", "這個", Options{Simplified: true}, "这个"},
+		{"all off", "ＡＢ\u200b", Options{}, "ＡＢ\u200b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Text(tt.text, tt.options); got != tt.want {
+				t.Errorf("Text(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// rawCleaner 原样返回输入，用于测试包装后的规范化
+type rawCleaner struct{}
+
+func (rawCleaner) Clean(input types.Type) (types.Type, error) { return input, nil }
+
+func TestCleaner(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      types.Type
+		wantText   string
+		wantAnchor string
+		wantCode   string
+	}{
+		{
+			name: "text and anchors normalized, code untouched",
+			input: types.Type{
+				Text:     "ＡＰＩ\u200b文档[1] @CODE_0@",
+				Outlinks: []types.Outlink{{Index: 1, Text: "ｄｏｃｓ\u00a0page"}},
+				CodeMap:  map[string]string{"@CODE_0@": "x\u00a0=\u200b1"},
+			},
+			wantText:   "API文档[1] @CODE_0@",
+			wantAnchor: "docs page",
+			wantCode:   "x\u00a0=\u200b1",
+		},
+		{
+			name:     "request enables simplified",
+			input:    types.Type{Text: "軟體", Options: types.Options{Simplified: true}},
+			wantText: "软体",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCleaner(rawCleaner{}, DefaultOptions()).Clean(tt.input)
+			if err != nil {
+				t.Fatalf("Clean() error = %v", err)
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("Clean() text = %q, want %q", got.Text, tt.wantText)
+			}
+			if tt.wantAnchor != "" && got.Outlinks[0].Text != tt.wantAnchor {
+				t.Errorf("Clean() anchor = %q, want %q", got.Outlinks[0].Text, tt.wantAnchor)
+			}
+			if tt.wantCode != "" && got.CodeMap["@CODE_0@"] != tt.wantCode {
+				t.Errorf("Clean() code = %q, want %q", got.CodeMap["@CODE_0@"], tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// ================== 结构化抽取：在DOM上按字段定义取值 ===================
+package scrape
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+var (
+	reSpace  = regexp.MustCompile(`\s+`)
+	reNumber = regexp.MustCompile(`-?\d[\d,]*(?:\.\d+)?|-?\.\d+`)
+)
+
+// urlAttrs 取值时需要解析为绝对地址的属性
+var urlAttrs = map[string]bool{"href": true, "src": true, "data-src": true, "action": true, "poster": true}
+
+// Extract 按字段定义从HTML中抽取结构化数据
+// pageURL用于把href/src等属性解析为绝对地址
+func Extract(htmlText string, pageURL string, fields []Field) (map[string]interface{}, error) {
+	if err := Validate(fields); err != nil {
+		return nil, err
+	}
+	root, err := htmlquery.Parse(strings.NewReader(htmlText))
+	if err != nil {
+		return nil, fmt.Errorf("网页解析失败: %v", err)
+	}
+	base, _ := url.Parse(pageURL)
+	return extractFields(root, base, fields), nil
+}
+
+// extractFields 在指定节点下抽取一组字段
+func extractFields(node *html.Node, base *url.URL, fields []Field) map[string]interface{} {
+	result := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		// count()、string()等XPath函数返回标量而不是节点
+		if raw, ok := evaluateScalar(node, f); ok {
+			result[f.Name] = convertValue(raw, f.Type)
+			continue
+		}
+
+		matches := selectNodes(node, f)
+
+		if !f.List {
+			if len(matches) == 0 {
+				result[f.Name] = emptyValue(f)
+				continue
+			}
+			result[f.Name] = fieldValue(matches[0], base, f)
+			continue
+		}
+
+		values := make([]interface{}, 0, len(matches))
+		for _, m := range matches {
+			values = append(values, fieldValue(m, base, f))
+		}
+		result[f.Name] = values
+	}
+	return result
+}
+
+// selectNodes 按CSS选择器或XPath查找节点，选择器已在Validate中校验
+func selectNodes(node *html.Node, f Field) []*html.Node {
+	if f.XPath != "" {
+		nodes, err := htmlquery.QueryAll(node, f.XPath)
+		if err != nil {
+			return nil
+		}
+		return nodes
+	}
+	return goquery.NewDocumentFromNode(node).Find(f.Selector).Nodes
+}
+
+// evaluateScalar 计算返回标量的XPath表达式
+func evaluateScalar(node *html.Node, f Field) (string, bool) {
+	if f.XPath == "" || len(f.Fields) > 0 {
+		return "", false
+	}
+	expr, err := xpath.Compile(f.XPath)
+	if err != nil {
+		return "", false
+	}
+	switch v := expr.Evaluate(htmlquery.CreateXPathNavigator(node)).(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case string:
+		return strings.TrimSpace(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// fieldValue 计算单个节点的字段值
+func fieldValue(node *html.Node, base *url.URL, f Field) interface{} {
+	if len(f.Fields) > 0 {
+		return extractFields(node, base, f.Fields)
+	}
+
+	var raw string
+	switch f.Extract {
+	case ExtractHTML:
+		raw = strings.TrimSpace(htmlquery.OutputHTML(node, false))
+	case ExtractAttr:
+		raw = strings.TrimSpace(htmlquery.SelectAttr(node, f.Attr))
+		if raw != "" && urlAttrs[strings.ToLower(f.Attr)] && base != nil {
+			if ref, err := url.Parse(raw); err == nil {
+				raw = base.ResolveReference(ref).String()
+			}
+		}
+	default:
+		raw = strings.TrimSpace(reSpace.ReplaceAllString(htmlquery.InnerText(node), " "))
+	}
+
+	return convertValue(raw, f.Type)
+}
+
+// convertValue 将字符串转换为字段声明的类型，无法转换时返回nil
+func convertValue(raw string, valueType string) interface{} {
+	switch valueType {
+	case TypeNumber, TypeInteger:
+		m := reNumber.FindString(raw)
+		if m == "" {
+			return nil
+		}
+		n, err := strconv.ParseFloat(strings.ReplaceAll(m, ",", ""), 64)
+		if err != nil {
+			return nil
+		}
+		if valueType == TypeInteger {
+			return int64(n)
+		}
+		return n
+	case TypeBoolean:
+		switch strings.ToLower(raw) {
+		case "", "false", "0", "no", "off":
+			return false
+		}
+		return true
+	default:
+		return raw
+	}
+}
+
+// emptyValue 未命中时的取值：列表为空数组，布尔为false，其余为nil
+func emptyValue(f Field) interface{} {
+	if f.Type == TypeBoolean && len(f.Fields) == 0 {
+		return false
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package scrape
+
+import (
+	"reflect"
+	"testing"
+)
+
+const productPage = `<html><body>
+<h1 class="title">  Widget   Pro </h1>
+<span class="price">$1,299.50</span>
+<span class="stock">In stock: 42 units</span>
+<span class="sale">yes</span>
+<span class="discontinued">false</span>
+<div class="desc"><p>Fast <b>and</b> small</p></div>
+<img class="hero" src="/img/hero.png">
+<ul class="reviews">
+  <li><a href="/u/ann">Ann</a><span class="stars">5</span></li>
+  <li><a href="https://other.org/bob">Bob</a><span class="stars">3</span></li>
+</ul>
+</body></html>`
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name  string
+		field Field
+		want  interface{}
+	}{
+		{"text collapses spaces", Field{Name: "v", Selector: "h1.title"}, "Widget Pro"},
+		{"inner html", Field{Name: "v", Selector: ".desc", Extract: ExtractHTML}, "<p>Fast <b>and</b> small</p>"},
+		{"number strips currency and commas", Field{Name: "v", Selector: ".price", Type: TypeNumber}, 1299.5},
+		{"integer from text", Field{Name: "v", Selector: ".stock", Type: TypeInteger}, int64(42)},
+		{"integer truncates", Field{Name: "v", Selector: ".price", Type: TypeInteger}, int64(1299)},
+		{"number without digits", Field{Name: "v", Selector: "h1", Type: TypeNumber}, nil},
+		{"boolean yes", Field{Name: "v", Selector: ".sale", Type: TypeBoolean}, true},
+		{"boolean false text", Field{Name: "v", Selector: ".discontinued", Type: TypeBoolean}, false},
+		{"boolean missing element", Field{Name: "v", Selector: ".missing", Type: TypeBoolean}, false},
+		{"missing element", Field{Name: "v", Selector: ".missing"}, nil},
+		{"relative src resolved", Field{Name: "v", Selector: "img.hero", Extract: ExtractAttr, Attr: "src"}, "https://shop.example.com/img/hero.png"},
+		{"non url attr kept", Field{Name: "v", Selector: "img.hero", Extract: ExtractAttr, Attr: "class"}, "hero"},
+		{"xpath node", Field{Name: "v", XPath: "//span[@class='price']"}, "$1,299.50"},
+		{"xpath count", Field{Name: "v", XPath: "count(//ul/li)", Type: TypeInteger}, int64(2)},
+		{"xpath string", Field{Name: "v", XPath: "string(//h1)"}, "Widget   Pro"},
+		{"xpath boolean", Field{Name: "v", XPath: "boolean(//img)", Type: TypeBoolean}, true},
+		{"text list", Field{Name: "v", Selector: ".reviews a", List: true}, []interface{}{"Ann", "Bob"}},
+		{"empty list", Field{Name: "v", Selector: ".missing", List: true}, []interface{}{}},
+		{
+			name: "nested list fields",
+			field: Field{Name: "v", Selector: ".reviews li", List: true, Fields: []Field{
+				{Name: "author", Selector: "a"},
+				{Name: "profile", Selector: "a", Extract: ExtractAttr, Attr: "href"},
+				{Name: "stars", Selector: ".stars", Type: TypeInteger},
+			}},
+			want: []interface{}{
+				map[string]interface{}{"author": "Ann", "profile": "https://shop.example.com/u/ann", "stars": int64(5)},
+				map[string]interface{}{"author": "Bob", "profile": "https://other.org/bob", "stars": int64(3)},
+			},
+		},
+		{
+			name: "nested single object",
+			field: Field{Name: "v", XPath: "//ul/li[2]", Fields: []Field{
+				{Name: "author", XPath: ".//a"},
+			}},
+			want: map[string]interface{}{"author": "Bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Extract(productPage, "https://shop.example.com/p/widget", []Field{tt.field})
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if !reflect.DeepEqual(got["v"], tt.want) {
+				t.Errorf("Extract() v = %#v, want %#v", got["v"], tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractInvalidSchema(t *testing.T) {
+	if _, err := Extract(productPage, "https://shop.example.com/", []Field{{Name: "v"}}); err == nil {
+		t.Error("Extract() error = nil, want schema error")
+	}
+}
@@ -0,0 +1,93 @@
+// ================== 结构化抽取：字段定义 ===================
+package scrape
+
+import (
+	"fmt"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/xpath"
+)
+
+// 取值方式
+const (
+	ExtractText = "text" // 元素的纯文本（默认）
+	ExtractHTML = "html" // 元素的内部HTML
+	ExtractAttr = "attr" // 元素的属性值，需要同时指定Attr
+)
+
+// 值类型
+const (
+	TypeString  = "string" // 字符串（默认）
+	TypeNumber  = "number" // 浮点数，自动去掉货币符号和千分位
+	TypeInteger = "integer"
+	TypeBoolean = "boolean" // 元素存在且取值非空、非false/0/no即为true
+)
+
+// Field 描述一个需要抽取的字段
+// Selector与XPath二选一；List为true时返回所有命中元素的取值，否则只取第一个
+// 设置了Fields时，每个命中元素作为一个对象，子字段相对该元素抽取
+type Field struct {
+	Name     string  `json:"name"`
+	Selector string  `json:"selector"` // CSS选择器
+	XPath    string  `json:"xpath"`    // XPath表达式
+	Extract  string  `json:"extract"`  // text / html / attr
+	Attr     string  `json:"attr"`     // Extract为attr时读取的属性名
+	Type     string  `json:"type"`     // string / number / integer / boolean
+	List     bool    `json:"list"`     // 是否返回列表
+	Fields   []Field `json:"fields"`   // 嵌套字段
+}
+
+// Validate 校验字段定义，返回第一个发现的问题
+func Validate(fields []Field) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("schema不能为空")
+	}
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if f.Name == "" {
+			return fmt.Errorf("字段缺少name")
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("字段名重复: %s", f.Name)
+		}
+		seen[f.Name] = true
+
+		switch {
+		case f.Selector != "" && f.XPath != "":
+			return fmt.Errorf("字段%s的selector与xpath只能二选一", f.Name)
+		case f.Selector != "":
+			if _, err := cascadia.Compile(f.Selector); err != nil {
+				return fmt.Errorf("字段%s的selector无效: %v", f.Name, err)
+			}
+		case f.XPath != "":
+			if _, err := xpath.Compile(f.XPath); err != nil {
+				return fmt.Errorf("字段%s的xpath无效: %v", f.Name, err)
+			}
+		default:
+			return fmt.Errorf("字段%s缺少selector或xpath", f.Name)
+		}
+
+		switch f.Extract {
+		case "", ExtractText, ExtractHTML:
+		case ExtractAttr:
+			if f.Attr == "" {
+				return fmt.Errorf("字段%s按属性取值但未指定attr", f.Name)
+			}
+		default:
+			return fmt.Errorf("字段%s的extract不支持: %s", f.Name, f.Extract)
+		}
+
+		switch f.Type {
+		case "", TypeString, TypeNumber, TypeInteger, TypeBoolean:
+		default:
+			return fmt.Errorf("字段%s的type不支持: %s", f.Name, f.Type)
+		}
+
+		if len(f.Fields) > 0 {
+			if err := Validate(f.Fields); err != nil {
+				return fmt.Errorf("字段%s的子字段: %v", f.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package scrape
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []Field
+		wantErr string // 空表示校验通过
+	}{
+		{
+			name:   "valid selector and xpath",
+			fields: []Field{{Name: "title", Selector: "h1"}, {Name: "count", XPath: "count(//li)", Type: TypeInteger}},
+		},
+		{
+			name:   "valid nested fields",
+			fields: []Field{{Name: "items", Selector: "li", List: true, Fields: []Field{{Name: "link", Selector: "a", Extract: ExtractAttr, Attr: "href"}}}},
+		},
+		{name: "empty schema", fields: nil, wantErr: "schema不能为空"},
+		{name: "missing name", fields: []Field{{Selector: "h1"}}, wantErr: "缺少name"},
+		{name: "duplicate name", fields: []Field{{Name: "a", Selector: "h1"}, {Name: "a", Selector: "h2"}}, wantErr: "字段名重复"},
+		{name: "selector and xpath", fields: []Field{{Name: "a", Selector: "h1", XPath: "//h1"}}, wantErr: "二选一"},
+		{name: "no selector", fields: []Field{{Name: "a"}}, wantErr: "缺少selector或xpath"},
+		{name: "bad selector", fields: []Field{{Name: "a", Selector: "div[["}}, wantErr: "selector无效"},
+		{name: "bad xpath", fields: []Field{{Name: "a", XPath: "//div["}}, wantErr: "xpath无效"},
+		{name: "attr without name", fields: []Field{{Name: "a", Selector: "a", Extract: ExtractAttr}}, wantErr: "未指定attr"},
+		{name: "unknown extract", fields: []Field{{Name: "a", Selector: "a", Extract: "json"}}, wantErr: "extract不支持"},
+		{name: "unknown type", fields: []Field{{Name: "a", Selector: "a", Type: "date"}}, wantErr: "type不支持"},
+		{
+			name:    "invalid nested field",
+			fields:  []Field{{Name: "items", Selector: "li", Fields: []Field{{Name: "x"}}}},
+			wantErr: "字段items的子字段",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.fields)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// ================== 中英文分句 ===================
+package sentence
+
+import (
+	"strings"
+	"unicode"
+)
+
+// abbreviations 以点结尾但通常不结束句子的英文缩写（小写，不含末尾的点）
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true, "st": true,
+	"vs": true, "e.g": true, "i.e": true, "cf": true, "al": true, "approx": true,
+	"fig": true, "figs": true, "eq": true, "eqs": true, "nos": true, "vol": true, "vols": true,
+	"p": true, "pp": true, "ch": true, "sec": true, "ref": true, "refs": true, "eds": true,
+	"inc": true, "ltd": true, "corp": true, "dept": true, "univ": true,
+	"etc": true, "esp": true, "incl": true, "resp": true, "viz": true, "ca": true, "est": true, "min": true, "max": true,
+	"jan": true, "feb": true, "mar": true, "apr": true, "jun": true, "jul": true, "aug": true,
+	"sep": true, "sept": true, "oct": true, "nov": true, "dec": true,
+	"u.s": true, "u.k": true, "a.m": true, "p.m": true, "ph.d": true,
+}
+
+// closers 句末标点之后仍属于本句的右引号和右括号
+const closers = "\"'”’」』）)]】》〉"
+
+// cjkTerminators 中文句末标点，遇到即断句
+const cjkTerminators = "。！？；"
+
+// Split 将文本切分为句子，返回的句子已去除首尾空白并保留句末标点
+// 换行视为句子边界；英文句点只有在其后是空白、且下一个词像句首时才断句
+// （句点前是数字或普通小写单词时，下一个词小写也断句），
+// 因此版本号（1.24.2）、小数、文件名（config.yaml）、网址和常见缩写（e.g. Dr.）不会被切开
+func Split(text string) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+
+	emit := func(end int) {
+		if s := strings.TrimSpace(string(runes[start:end])); s != "" {
+			sentences = append(sentences, s)
+		}
+		start = end
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			emit(i + 1)
+
+		case strings.ContainsRune(cjkTerminators, r):
+			// 连续的句末标点（如「？！」）与右引号一并归入本句
+			j := i + 1
+			for j < len(runes) && (strings.ContainsRune(cjkTerminators, runes[j]) || runes[j] == '!' || runes[j] == '?') {
+				j++
+			}
+			j = skipClosers(runes, j)
+			emit(j)
+			i = j - 1
+
+		case r == '…':
+			j := i + 1
+			for j < len(runes) && runes[j] == '…' {
+				j++
+			}
+			j = skipClosers(runes, j)
+			// 中文省略号后直接接下一句；英文中的省略号需要后面是空白加句首词
+			if j >= len(runes) || IsCJK(runes[j]) || (unicode.IsSpace(runes[j]) && startsSentence(runes, j)) {
+				emit(j)
+				i = j - 1
+			}
+
+		case r == '!' || r == '?':
+			j := i + 1
+			for j < len(runes) && (runes[j] == '!' || runes[j] == '?') {
+				j++
+			}
+			quoted := j
+			j = skipClosers(runes, j)
+			// 引号内的问句、感叹句后接小写词时是对话的说明部分（"Yes!" she said.），不断句
+			if j > quoted && j < len(runes) && unicode.IsSpace(runes[j]) && !startsSentence(runes, j) {
+				i = j - 1
+				continue
+			}
+			// 网址中的?后面不是空白，不断句
+			if j >= len(runes) || unicode.IsSpace(runes[j]) || IsCJK(runes[j]) {
+				emit(j)
+				i = j - 1
+			}
+
+		case r == '.':
+			j := i + 1
+			for j < len(runes) && runes[j] == '.' {
+				j++
+			}
+			j = skipClosers(runes, j)
+			if j < len(runes) && !unicode.IsSpace(runes[j]) && !IsCJK(runes[j]) {
+				// 1.24.2、config.yaml、example.com 等点后紧跟字符的情况
+				i = j - 1
+				continue
+			}
+			if j-i == 1 && !endsSentence(runes, start, i) {
+				i = j - 1
+				continue
+			}
+			// 下一个词小写时通常是缩写后的句中词，只有句点前明显是句尾（如数字、普通小写单词）时才断句
+			if j < len(runes) && !IsCJK(runes[j]) && !startsSentence(runes, j) && !(j-i == 1 && plainWordEnd(runes, start, i)) {
+				i = j - 1
+				continue
+			}
+			emit(j)
+			i = j - 1
+		}
+	}
+	emit(len(runes))
+
+	return sentences
+}
+
+// skipClosers 跳过句末标点之后的右引号和右括号
+func skipClosers(runes []rune, j int) int {
+	for j < len(runes) && strings.ContainsRune(closers, runes[j]) {
+		j++
+	}
+	return j
+}
+
+// wordBefore 取出位于dot处的句点前面的词及其起始位置
+func wordBefore(runes []rune, start, dot int) (string, int) {
+	k := dot
+	for k > start && !unicode.IsSpace(runes[k-1]) && !strings.ContainsRune("(\"'“‘「（[", runes[k-1]) {
+		k--
+	}
+	return string(runes[k:dot]), k
+}
+
+// endsSentence 判断位于dot处的单个句点前面的词是否允许在此结束句子
+func endsSentence(runes []rune, start, dot int) bool {
+	word, k := wordBefore(runes, start, dot)
+	if word == "" {
+		return true
+	}
+	lower := strings.ToLower(word)
+	if abbreviations[lower] {
+		return false
+	}
+
+	wordRunes := []rune(word)
+	// 单个大写字母通常是人名缩写，如 J. Smith
+	if len(wordRunes) == 1 && unicode.IsUpper(wordRunes[0]) {
+		return false
+	}
+	// 句首的列表序号，如 "1. 安装" 或 "a. Install"
+	if k == start || strings.TrimSpace(string(runes[start:k])) == "" {
+		if isListMarker(wordRunes) {
+			return false
+		}
+	}
+	return true
+}
+
+// plainWordEnd 判断句点前的词是否明显不是缩写：含数字（3.14、v2、2024），
+// 或是三个字母以上、不含内部句点的小写单词；此时即使下一个词小写也断句
+func plainWordEnd(runes []rune, start, dot int) bool {
+	word, _ := wordBefore(runes, start, dot)
+	if word == "" || abbreviations[strings.ToLower(word)] {
+		return false
+	}
+	wordRunes := []rune(word)
+	for _, r := range wordRunes {
+		if unicode.IsDigit(r) {
+			return true
+		}
+	}
+	if len(wordRunes) < 3 || strings.ContainsRune(word, '.') || !unicode.IsLower(wordRunes[0]) {
+		return false
+	}
+	for _, r := range wordRunes {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isListMarker 判断是否为一到两位数字或单个字母的列表序号
+func isListMarker(word []rune) bool {
+	if len(word) == 1 && unicode.IsLetter(word[0]) {
+		return true
+	}
+	if len(word) > 2 {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// startsSentence 判断从位置j（空白）之后的内容是否像一个新句子的开头：
+// 大写字母、数字、左引号/括号或中文
+func startsSentence(runes []rune, j int) bool {
+	for j < len(runes) && unicode.IsSpace(runes[j]) {
+		if runes[j] == '\n' {
+			return true
+		}
+		j++
+	}
+	if j >= len(runes) {
+		return true
+	}
+	r := runes[j]
+	return unicode.IsUpper(r) || unicode.IsDigit(r) || IsCJK(r) || strings.ContainsRune("\"'“‘「（([#*-@`", r)
+}
+
+// IsCJK 判断是否为中日韩文字或全角标点
+func IsCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
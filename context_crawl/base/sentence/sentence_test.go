@@ -0,0 +1,57 @@
+package sentence
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestSplitGolden 使用testdata/golden.json中的用例，覆盖缩写、版本号、小数、中文和中英混排
+func TestSplitGolden(t *testing.T) {
+	data, err := os.ReadFile("testdata/golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []struct {
+		Name  string   `json:"name"`
+		Input string   `json:"input"`
+		Want  []string `json:"want"`
+	}
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := Split(tt.Input); !reflect.DeepEqual(got, tt.Want) {
+				t.Errorf("Split(%q)\n got  %q\n want %q", tt.Input, got, tt.Want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"No terminator", []string{"No terminator"}},
+		{"Hello. World.", []string{"Hello.", "World."}},
+		{"Pi is 3.14159 exactly.", []string{"Pi is 3.14159 exactly."}},
+		{"Released in 2024. next year too.", []string{"Released in 2024.", "next year too."}},
+		{"Mr. and Mrs. Smith.", []string{"Mr. and Mrs. Smith."}},
+		{"Ask at the U.S. embassy.", []string{"Ask at the U.S. embassy."}},
+		{"It is ok. fine.", []string{"It is ok. fine."}},
+		{"中文。English.", []string{"中文。", "English."}},
+		{"(See above.) Next.", []string{"(See above.)", "Next."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Split(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// ================== SimHash文本指纹 ===================
+package simhash
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+
+	"context_crawl/base/wordseg"
+)
+
+const (
+	DefaultDistance = 3 // 两个指纹的汉明距离不超过该值时视为近重复
+	MinTokens       = 3 // 词数少于该值的文本（按钮、标签等）指纹碰撞多，不参与近重复判断
+)
+
+// Compute 计算文本的指纹，词数少于MinTokens时返回false，此时不应参与近重复判断
+func Compute(text string) (uint64, bool) {
+	tokens := wordseg.Words(text)
+	if len(tokens) < MinTokens {
+		return 0, false
+	}
+	return fingerprint(tokens), true
+}
+
+// Fingerprint 计算文本的64位SimHash指纹
+// 特征为相邻三个词（中文按分词结果）组成的片段，词数不足三个时使用单个词；大小写、标点和空白不影响结果
+func Fingerprint(text string) uint64 {
+	tokens := wordseg.Words(text)
+	if len(tokens) == 0 {
+		return 0
+	}
+	return fingerprint(tokens)
+}
+
+func fingerprint(tokens []string) uint64 {
+
+	var features []string
+	if len(tokens) < 3 {
+		features = tokens
+	} else {
+		for i := 0; i+3 <= len(tokens); i++ {
+			features = append(features, strings.Join(tokens[i:i+3], " "))
+		}
+	}
+
+	var weights [64]int
+	for _, feature := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for i, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << uint(i)
+		}
+	}
+	return fingerprint
+}
+
+// Distance 两个指纹的汉明距离
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Near 判断两个指纹是否近重复
+func Near(a, b uint64) bool {
+	return Distance(a, b) <= DefaultDistance
+}
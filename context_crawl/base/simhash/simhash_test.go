@@ -0,0 +1,64 @@
+package simhash
+
+import "testing"
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		text string
+		ok   bool
+	}{
+		{"", false},
+		{"Next", false},
+		{"Read more", false},
+		{"Accept all cookies", true},
+		{"机器学习", false},
+		{"机器学习是人工智能的一个分支", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			fp, ok := Compute(tt.text)
+			if ok != tt.ok {
+				t.Fatalf("Compute(%q) ok = %v, want %v", tt.text, ok, tt.ok)
+			}
+			if ok && fp != Fingerprint(tt.text) {
+				t.Errorf("Compute(%q) = %x, Fingerprint = %x", tt.text, fp, Fingerprint(tt.text))
+			}
+		})
+	}
+}
+
+func TestNear(t *testing.T) {
+	base := "The scheduler assigns each job to the worker with the fewest pending tasks and retries failed jobs on another worker after a short delay."
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"identical", base, base, true},
+		{"case and punctuation", base, "the scheduler assigns each job to the worker, with the fewest pending tasks; and retries failed jobs on another worker after a short delay", true},
+		{"different text", base, "Install the package with pip and import it in your notebook to start plotting charts.", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Near(Fingerprint(tt.a), Fingerprint(tt.b)); got != tt.want {
+				t.Errorf("Near() = %v (distance %d), want %v", got, Distance(Fingerprint(tt.a), Fingerprint(tt.b)), tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0b1011, 0b0001, 2},
+		{^uint64(0), 0, 64},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%b, %b) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// ================== 关键词抽取：RAKE + TF-IDF ===================
+package summary
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"context_crawl/base/wordseg"
+)
+
+// 关键短语的长度范围
+const (
+	maxPhraseWords = 3 // 英文短语最多的词数
+	maxHanPhrase   = 6 // 中文复合短语最多的字数
+)
+
+// reHanRun 连续的汉字
+var reHanRun = regexp.MustCompile(`\p{Han}+`)
+
+// reLatinToken 英文的词，或一段标点、汉字等其他字符，后者用于切断短语
+var reLatinToken = regexp.MustCompile(`[\p{Latin}\p{N}][\p{Latin}\p{N}_'+#.-]*[\p{Latin}\p{N}+#]|[\p{Latin}\p{N}]|[^\s\p{Latin}\p{N}]+`)
+
+// keyphrase 候选关键短语
+type keyphrase struct {
+	text  string
+	score float64
+}
+
+// Keywords 从句子中抽取最多n个关键短语，按重要性从高到低返回
+// 英文使用RAKE（以虚词和标点为界切出候选短语，按词的共现度/词频打分），
+// 中文先分词，取两字以上的词及相邻两词组成的复合词按TF-IDF打分（句子视为文档）；
+// 两者分别归一化后合并排序，被更高分短语包含或包含更高分短语的候选会被去掉
+func Keywords(sentences []string, n int) []string {
+	if n <= 0 || len(sentences) == 0 {
+		return nil
+	}
+	candidates := append(rakePhrases(sentences), hanPhrases(sentences)...)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	var result []string
+	var lowered []string
+	for _, c := range candidates {
+		if len(result) >= n {
+			break
+		}
+		key := strings.ToLower(c.text)
+		overlapped := false
+		for _, picked := range lowered {
+			if contains(picked, key) || contains(key, picked) {
+				overlapped = true
+				break
+			}
+		}
+		if overlapped {
+			continue
+		}
+		result = append(result, c.text)
+		lowered = append(lowered, key)
+	}
+	return result
+}
+
+// contains 判断短语a是否包含短语b：英文按整词比较，中文按子串比较
+func contains(a, b string) bool {
+	if reHanRun.MatchString(b) {
+		return strings.Contains(a, b)
+	}
+	return strings.Contains(" "+a+" ", " "+b+" ")
+}
+
+// rakePhrases 英文候选短语及其RAKE得分（已归一化到0~1）
+func rakePhrases(sentences []string) []keyphrase {
+	var phrases [][]string          // 每个候选短语的小写词
+	original := map[string]string{} // 小写短语 -> 首次出现时的原文
+	df := map[string]int{}          // 包含该词的句子数
+	for _, s := range sentences {
+		inSentence := map[string]bool{}
+		var current []string
+		var raw []string
+		flush := func() {
+			if len(current) > 0 && len(current) <= maxPhraseWords {
+				phrases = append(phrases, current)
+				key := strings.Join(current, " ")
+				if _, ok := original[key]; !ok {
+					original[key] = strings.Join(raw, " ")
+				}
+			}
+			current, raw = nil, nil
+		}
+		for _, token := range reLatinToken.FindAllString(s, -1) {
+			r, _ := utf8.DecodeRuneInString(token)
+			lower := strings.ToLower(token)
+			if !(unicode.Is(unicode.Latin, r) || unicode.IsDigit(r)) || IsStopword(lower) || isNumber(lower) {
+				flush()
+				continue
+			}
+			current = append(current, lower)
+			raw = append(raw, token)
+			inSentence[lower] = true
+		}
+		flush()
+		for w := range inSentence {
+			df[w]++
+		}
+	}
+	if len(phrases) == 0 {
+		return nil
+	}
+
+	// 词的得分 = 共现度 / 词频
+	freq := map[string]float64{}
+	degree := map[string]float64{}
+	phraseFreq := map[string]int{}
+	for _, words := range phrases {
+		phraseFreq[strings.Join(words, " ")]++
+		for _, w := range words {
+			freq[w]++
+			degree[w] += float64(len(words))
+		}
+	}
+
+	// 短语得分 = 平均词得分 × 平均TF-IDF × 短语出现次数的对数，
+	// 到处出现的词（如页面主题名本身）idf低，不会让所有含它的短语都排在前面
+	var result []keyphrase
+	max := 0.0
+	total := float64(len(sentences))
+	for key, count := range phraseFreq {
+		words := strings.Fields(key)
+		// 只出现一次的单个词一般不是关键词
+		if len(words) == 1 && count < 2 {
+			continue
+		}
+		rake, tfidf := 0.0, 0.0
+		for _, w := range words {
+			rake += degree[w] / freq[w]
+			tfidf += math.Log(1+freq[w]) * math.Log(1+total/float64(df[w]))
+		}
+		n := float64(len(words))
+		score := rake / n * tfidf / n * math.Log(1+float64(count))
+		if score > max {
+			max = score
+		}
+		result = append(result, keyphrase{text: original[key], score: score})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].text < result[j].text })
+	return normalize(result, max)
+}
+
+// hanPhrases 中文候选短语及其TF-IDF得分（已归一化到0~1）
+func hanPhrases(sentences []string) []keyphrase {
+	tf := map[string]int{}
+	df := map[string]int{}
+	for _, s := range sentences {
+		inSentence := map[string]bool{}
+		for _, run := range reHanRun.FindAllString(s, -1) {
+			for _, phrase := range hanCandidates(wordseg.Cut(run)) {
+				tf[phrase]++
+				inSentence[phrase] = true
+			}
+		}
+		for gram := range inSentence {
+			df[gram]++
+		}
+	}
+
+	var result []keyphrase
+	max := 0.0
+	total := float64(len(sentences))
+	for gram, count := range tf {
+		if count < 2 {
+			continue
+		}
+		idf := math.Log(1 + total/float64(df[gram]))
+		score := float64(count) * idf * math.Sqrt(float64(utf8.RuneCountInString(gram)))
+		if score > max {
+			max = score
+		}
+		result = append(result, keyphrase{text: gram, score: score})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].text < result[j].text })
+	return normalize(result, max)
+}
+
+// hanCandidates 分词结果中的候选短语：两字及以上的非虚词，以及相邻两个这样的词组成的复合词（如"并发模型"）
+func hanCandidates(words []string) []string {
+	var candidates []string
+	for i, w := range words {
+		if !isHanKeyword(w) {
+			continue
+		}
+		candidates = append(candidates, w)
+		if i+1 < len(words) && isHanKeyword(words[i+1]) && utf8.RuneCountInString(w+words[i+1]) <= maxHanPhrase {
+			candidates = append(candidates, w+words[i+1])
+		}
+	}
+	return candidates
+}
+
+// isHanKeyword 可以作为关键词的中文词
+func isHanKeyword(word string) bool {
+	return wordseg.IsHanWord(word) && !IsStopword(word)
+}
+
+// normalize 将得分归一化到0~1并按得分排序，得分相同的按文本顺序保证结果稳定
+func normalize(phrases []keyphrase, max float64) []keyphrase {
+	if max <= 0 {
+		return phrases
+	}
+	for i := range phrases {
+		phrases[i].score /= max
+	}
+	sort.SliceStable(phrases, func(i, j int) bool { return phrases[i].score > phrases[j].score })
+	return phrases
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) && r != '.' && r != ',' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package summary
+
+import "strings"
+
+// englishStopwords 英文常用虚词，正常行文中约占三到五成，导航、标签云等几乎没有
+var englishStopwords = toSet(strings.Fields(`a an the and or but if then else of to in on at by for with from as into onto about
+	over under between through during before after above below up down out off is are was were be been being am
+	do does did doing have has had having it its this that these those there here which who whom whose what when
+	where why how not no nor so than too very can could will would shall should may might must we you he she they
+	i me my our your his her their them us all any both each few more most other some such only own same just
+	also however use used using via per etc`))
+
+// chineseStopchars 中文常用虚字，正常行文中约占一成左右
+var chineseStopchars = toSet(strings.Split("的了是在和也就都而及与着或这那不有为以等把被从对但并且所如其之于即因则又", ""))
+
+// IsStopword 判断小写的英文单词或单个汉字是否为虚词
+func IsStopword(word string) bool {
+	return englishStopwords[word] || chineseStopchars[word]
+}
+
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
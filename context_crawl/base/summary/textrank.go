@@ -0,0 +1,175 @@
+// ================== 抽取式摘要：TextRank ===================
+package summary
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"context_crawl/base/wordseg"
+)
+
+// 默认参数
+const (
+	DefaultSentences = 3    // 摘要句子数
+	DefaultKeywords  = 10   // 关键词数
+	damping          = 0.85 // PageRank阻尼系数
+	maxIterations    = 50
+	tolerance        = 1e-4
+	minSentenceRunes = 8   // 过短的句子（如"Note:"）不参与摘要
+	maxCandidates    = 500 // 参与排序的句子数上限，超长文档只取前面的句子
+	maxNeighbors     = 20  // 每个句子只保留相似度最高的若干条边，构成稀疏图
+)
+
+// edge 句子图中的一条边
+type edge struct {
+	to     int
+	weight float64
+}
+
+// Summarize 用TextRank从句子中挑出最具代表性的n句，按原文顺序返回
+// 句子之间的相似度为共同词数除以两句词数的对数和，去掉了虚词；
+// 句子数不超过n时原样返回；超长文档只取前maxCandidates句，每句只连接最相似的maxNeighbors句
+func Summarize(sentences []string, n int) []string {
+	var candidates []string
+	var terms []map[string]bool
+	seen := make(map[string]bool)
+	for _, s := range sentences {
+		s = strings.TrimSpace(s)
+		if utf8.RuneCountInString(s) < minSentenceRunes || seen[s] {
+			continue
+		}
+		seen[s] = true
+		candidates = append(candidates, s)
+		terms = append(terms, termSet(s))
+		if len(candidates) == maxCandidates {
+			break
+		}
+	}
+	if n <= 0 || len(candidates) <= n {
+		return candidates
+	}
+
+	size := len(candidates)
+	graph := sparseGraph(terms)
+	outSum := make([]float64, size)
+	for i, edges := range graph {
+		for _, e := range edges {
+			outSum[i] += e.weight
+		}
+	}
+
+	// 迭代计算每个句子的得分
+	scores := make([]float64, size)
+	for i := range scores {
+		scores[i] = 1
+	}
+	for iter := 0; iter < maxIterations; iter++ {
+		next := make([]float64, size)
+		delta := 0.0
+		for i := 0; i < size; i++ {
+			// 图是无向的，i的邻居即指向i的句子
+			sum := 0.0
+			for _, e := range graph[i] {
+				sum += e.weight / outSum[e.to] * scores[e.to]
+			}
+			next[i] = (1 - damping) + damping*sum
+			delta += math.Abs(next[i] - scores[i])
+		}
+		scores = next
+		if delta < tolerance {
+			break
+		}
+	}
+
+	// 取得分最高的n句，按原文顺序输出
+	order := make([]int, size)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	picked := order[:n]
+	sort.Ints(picked)
+
+	result := make([]string, 0, n)
+	for _, i := range picked {
+		result = append(result, candidates[i])
+	}
+	return result
+}
+
+// sparseGraph 构建句子相似度的稀疏图：借助倒排索引只计算有共同词的句子对，
+// 每个句子保留相似度最高的maxNeighbors条边，再合并为无向图，邻接表按句子下标排序
+func sparseGraph(terms []map[string]bool) [][]edge {
+	postings := make(map[string][]int)
+	for i, set := range terms {
+		for t := range set {
+			postings[t] = append(postings[t], i)
+		}
+	}
+
+	neighbors := make([]map[int]float64, len(terms))
+	for i := range neighbors {
+		neighbors[i] = make(map[int]float64)
+	}
+	for i, set := range terms {
+		common := make(map[int]int)
+		for t := range set {
+			for _, j := range postings[t] {
+				if j != i {
+					common[j]++
+				}
+			}
+		}
+		var edges []edge
+		for j, c := range common {
+			if w := similarity(c, len(set), len(terms[j])); w > 0 {
+				edges = append(edges, edge{to: j, weight: w})
+			}
+		}
+		sort.Slice(edges, func(a, b int) bool {
+			if edges[a].weight != edges[b].weight {
+				return edges[a].weight > edges[b].weight
+			}
+			return edges[a].to < edges[b].to
+		})
+		if len(edges) > maxNeighbors {
+			edges = edges[:maxNeighbors]
+		}
+		for _, e := range edges {
+			neighbors[i][e.to] = e.weight
+			neighbors[e.to][i] = e.weight
+		}
+	}
+
+	graph := make([][]edge, len(terms))
+	for i, m := range neighbors {
+		for j, w := range m {
+			graph[i] = append(graph[i], edge{to: j, weight: w})
+		}
+		sort.Slice(graph[i], func(a, b int) bool { return graph[i][a].to < graph[i][b].to })
+	}
+	return graph
+}
+
+// similarity TextRank原论文的句子相似度，common为两句的共同词数，a、b为两句的词数
+func similarity(common, a, b int) float64 {
+	if a < 2 || b < 2 || common == 0 {
+		return 0
+	}
+	return float64(common) / (math.Log(float64(a)) + math.Log(float64(b)))
+}
+
+// termSet 句子中去掉虚词后的词集合
+func termSet(sentence string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range wordseg.Words(sentence) {
+		if !IsStopword(t) {
+			set[t] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,126 @@
+package summary
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name      string
+		sentences []string
+		n         int
+		want      []string
+	}{
+		{
+			name:      "fewer sentences than n",
+			sentences: []string{"Go is a compiled language.", "Short", "Go is a compiled language."},
+			n:         3,
+			want:      []string{"Go is a compiled language."},
+		},
+		{
+			name: "off-topic sentences dropped, original order kept",
+			sentences: []string{
+				"The garbage collector in Go pauses goroutines briefly.",
+				"Our office cafeteria serves pasta on Fridays.",
+				"Go's garbage collector marks reachable objects concurrently with goroutines.",
+				"The garbage collector then sweeps unreachable objects while goroutines keep running.",
+				"Parking is available behind the building.",
+			},
+			n: 3,
+			want: []string{
+				"The garbage collector in Go pauses goroutines briefly.",
+				"Go's garbage collector marks reachable objects concurrently with goroutines.",
+				"The garbage collector then sweeps unreachable objects while goroutines keep running.",
+			},
+		},
+		{
+			name: "chinese",
+			sentences: []string{
+				"深度学习是机器学习的一个分支。",
+				"今天中午食堂的饭菜很好吃。",
+				"机器学习通过数据训练模型，深度学习使用多层神经网络训练模型。",
+				"神经网络模型需要大量数据进行训练。",
+			},
+			n:    1,
+			want: []string{"机器学习通过数据训练模型，深度学习使用多层神经网络训练模型。"},
+		},
+		{
+			name:      "n is zero",
+			sentences: []string{"First sentence is here.", "Second sentence is here."},
+			n:         0,
+			want:      []string{"First sentence is here.", "Second sentence is here."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.sentences, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summarize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeLargeDocument(t *testing.T) {
+	var sentences []string
+	for i := 0; i < 5*maxCandidates; i++ {
+		sentences = append(sentences, fmt.Sprintf("Section %d explains how the scheduler handles queue %d and worker %d.", i, i%7, i%11))
+	}
+	got := Summarize(sentences, 3)
+	if len(got) != 3 {
+		t.Fatalf("Summarize() returned %d sentences", len(got))
+	}
+	// 只有前maxCandidates句参与排序
+	for _, s := range got {
+		var section int
+		fmt.Sscanf(s, "Section %d", &section)
+		if section >= maxCandidates {
+			t.Errorf("sentence %q is beyond the candidate cap", s)
+		}
+	}
+}
+
+func TestSparseGraph(t *testing.T) {
+	shared := map[string]bool{"alpha": true, "beta": true, "gamma": true}
+	terms := []map[string]bool{{"solo": true, "words": true}}
+	for i := 0; i < maxNeighbors+10; i++ {
+		set := map[string]bool{fmt.Sprintf("own%d", i): true}
+		for t := range shared {
+			set[t] = true
+		}
+		terms = append(terms, set)
+	}
+
+	graph := sparseGraph(terms)
+	if len(graph[0]) != 0 {
+		t.Errorf("sentence without common terms has edges: %v", graph[0])
+	}
+	for i := 1; i < len(graph); i++ {
+		// 每个句子自己选出的邻居不超过maxNeighbors，合并为无向图后其他句子可能再连过来
+		if len(graph[i]) < maxNeighbors || len(graph[i]) > len(terms)-2 {
+			t.Errorf("sentence %d has %d edges", i, len(graph[i]))
+		}
+		for k := 1; k < len(graph[i]); k++ {
+			if graph[i][k-1].to >= graph[i][k].to {
+				t.Fatalf("adjacency list of %d is not sorted: %v", i, graph[i])
+			}
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		common, a, b int
+		want         bool // 是否大于0
+	}{
+		{0, 5, 5, false},
+		{1, 1, 5, false},
+		{2, 4, 4, true},
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.common, tt.a, tt.b); (got > 0) != tt.want {
+			t.Errorf("similarity(%d, %d, %d) = %v", tt.common, tt.a, tt.b, got)
+		}
+	}
+}
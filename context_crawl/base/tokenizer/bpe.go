@@ -0,0 +1,164 @@
+// ================== cl100k_base的BPE编码 ===================
+package tokenizer
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// cl100kData 内置的cl100k_base词表，与tiktoken发布的cl100k_base.tiktoken相同，
+// 每行为 "base64编码的字节序列 rank"，rank即token id，也是BPE合并的优先级
+//
+//go:embed cl100k_base.tiktoken
+var cl100kData string
+
+// Encoding 字节级BPE编码，词表从tiktoken格式的文件加载，不需要联网
+type Encoding struct {
+	ranks map[string]int
+}
+
+var (
+	cl100k     *Encoding
+	cl100kOnce sync.Once
+)
+
+// CL100K 返回使用内置词表的cl100k_base编码，首次调用时解析词表
+func CL100K() *Encoding {
+	cl100kOnce.Do(func() {
+		enc, err := LoadEncoding(strings.NewReader(cl100kData))
+		if err != nil {
+			// 内置词表随代码一起发布，解析失败只可能是打包出错
+			panic(fmt.Sprintf("内置cl100k_base词表无效: %v", err))
+		}
+		cl100k = enc
+	})
+	return cl100k
+}
+
+// LoadEncoding 从tiktoken格式的词表加载编码，每行 "base64编码的字节序列 rank"
+func LoadEncoding(r io.Reader) (*Encoding, error) {
+	ranks := make(map[string]int, 100352)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("第%d行格式错误: %q", lineNo, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("第%d行的token无效: %v", lineNo, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("第%d行的rank无效: %v", lineNo, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("词表为空")
+	}
+	return &Encoding{ranks: ranks}, nil
+}
+
+// LoadEncodingFile 从文件加载tiktoken格式的词表，见LoadEncoding
+func LoadEncodingFile(path string) (*Encoding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadEncoding(f)
+}
+
+// Encode 将文本编码为token id，先按cl100k的规则预切分，每个片段再做BPE合并
+// 不识别<|endoftext|>等特殊token，按普通文本编码
+func (e *Encoding) Encode(text string) []int {
+	var ids []int
+	for _, piece := range Pretokenize(text) {
+		if rank, ok := e.ranks[piece]; ok {
+			ids = append(ids, rank)
+			continue
+		}
+		for _, part := range e.merge([]byte(piece)) {
+			ids = append(ids, e.ranks[part])
+		}
+	}
+	return ids
+}
+
+// Count 返回文本编码后的token数
+func (e *Encoding) Count(text string) int {
+	total := 0
+	for _, piece := range Pretokenize(text) {
+		if _, ok := e.ranks[piece]; ok {
+			total++
+			continue
+		}
+		total += len(e.merge([]byte(piece)))
+	}
+	return total
+}
+
+// merge 对单个片段做字节级BPE：从单字节开始，反复合并rank最小的相邻两段，直到没有可合并的组合
+func (e *Encoding) merge(piece []byte) []string {
+	// bounds[i]为第i段的起始位置，最后一个元素为片段长度
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	// pairRank 第i段与第i+1段合并后的rank，不在词表中时为MaxInt
+	pairRank := func(i int) int {
+		if i+2 >= len(bounds) {
+			return math.MaxInt
+		}
+		if rank, ok := e.ranks[string(piece[bounds[i]:bounds[i+2]])]; ok {
+			return rank
+		}
+		return math.MaxInt
+	}
+	ranks := make([]int, len(bounds)-1)
+	for i := range ranks {
+		ranks[i] = pairRank(i)
+	}
+
+	for len(bounds) > 2 {
+		best := 0
+		for i := 1; i < len(ranks); i++ {
+			if ranks[i] < ranks[best] {
+				best = i
+			}
+		}
+		if ranks[best] == math.MaxInt {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+		ranks = append(ranks[:best], ranks[best+1:]...)
+		ranks[best] = pairRank(best)
+		if best > 0 {
+			ranks[best-1] = pairRank(best - 1)
+		}
+	}
+
+	parts := make([]string, 0, len(bounds)-1)
+	for i := 0; i+1 < len(bounds); i++ {
+		parts = append(parts, string(piece[bounds[i]:bounds[i+1]]))
+	}
+	return parts
+}
@@ -0,0 +1,139 @@
+// ================== 文本长度计数：按字符或按token ===================
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 计数单位
+const (
+	UnitRune  = "rune"  // 按Unicode字符计数
+	UnitToken = "token" // 按cl100k_base编码的token计数
+)
+
+// Counter 统计文本长度
+type Counter interface {
+	Count(text string) int
+}
+
+// NewCounter 根据计数单位创建Counter，未知单位按字符计数
+func NewCounter(unit string) Counter {
+	if unit == UnitToken {
+		return TokenCounter{}
+	}
+	return RuneCounter{}
+}
+
+// RuneCounter 按Unicode字符计数，中英文长度一致
+type RuneCounter struct{}
+
+// Count 返回文本的字符数
+func (RuneCounter) Count(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// TokenCounter 按cl100k_base（GPT-3.5/4所用编码）计数token，与tiktoken的结果一致
+type TokenCounter struct{}
+
+// Count 返回文本的token数
+func (TokenCounter) Count(text string) int {
+	return CL100K().Count(text)
+}
+
+// Pretokenize 按cl100k_base的预切分规则切分文本：
+// 's|'t|'re|'ve|'m|'ll|'d | [^\r\n\p{L}\p{N}]?\p{L}+ | \p{N}{1,3} | ?[^\s\p{L}\p{N}]+[\r\n]* | \s*[\r\n]+ | \s+(?!\S) | \s+
+// Go的正则不支持环视，这里手写扫描实现
+func Pretokenize(text string) []string {
+	runes := []rune(text)
+	var pieces []string
+	i := 0
+	for i < len(runes) {
+		n := matchPiece(runes, i)
+		pieces = append(pieces, string(runes[i:i+n]))
+		i += n
+	}
+	return pieces
+}
+
+// matchPiece 返回从位置i开始匹配到的片段长度（至少为1）
+func matchPiece(r []rune, i int) int {
+	// 英文缩写：'s 't 're 've 'm 'll 'd（不区分大小写）
+	if r[i] == '\'' && i+1 < len(r) {
+		for _, suffix := range []string{"s", "t", "re", "ve", "m", "ll", "d"} {
+			if hasFoldPrefix(r[i+1:], suffix) {
+				return 1 + len(suffix)
+			}
+		}
+	}
+
+	// [^\r\n\p{L}\p{N}]?\p{L}+
+	j := i
+	if !isNewline(r[j]) && !unicode.IsLetter(r[j]) && !unicode.IsNumber(r[j]) && j+1 < len(r) && unicode.IsLetter(r[j+1]) {
+		j++
+	}
+	if unicode.IsLetter(r[j]) {
+		for j < len(r) && unicode.IsLetter(r[j]) {
+			j++
+		}
+		return j - i
+	}
+
+	// \p{N}{1,3}
+	if unicode.IsNumber(r[i]) {
+		j = i
+		for j < len(r) && j-i < 3 && unicode.IsNumber(r[j]) {
+			j++
+		}
+		return j - i
+	}
+
+	// ?[^\s\p{L}\p{N}]+[\r\n]*
+	j = i
+	if r[j] == ' ' && j+1 < len(r) && isPunct(r[j+1]) {
+		j++
+	}
+	if isPunct(r[j]) {
+		for j < len(r) && isPunct(r[j]) {
+			j++
+		}
+		for j < len(r) && isNewline(r[j]) {
+			j++
+		}
+		return j - i
+	}
+
+	// 空白：\s*[\r\n]+ | \s+(?!\S) | \s+
+	j = i
+	lastNewline := -1
+	for j < len(r) && unicode.IsSpace(r[j]) {
+		if isNewline(r[j]) {
+			lastNewline = j
+		}
+		j++
+	}
+	if lastNewline >= 0 {
+		return lastNewline + 1 - i
+	}
+	// 后面紧跟非空白时，最后一个空白留给下一个片段（即 " word" 的前导空格）
+	if j < len(r) && j-i > 1 {
+		return j - i - 1
+	}
+	return j - i
+}
+
+func hasFoldPrefix(r []rune, prefix string) bool {
+	if len(r) < len(prefix) {
+		return false
+	}
+	return strings.EqualFold(string(r[:len(prefix)]), prefix)
+}
+
+func isNewline(r rune) bool {
+	return r == '\r' || r == '\n'
+}
+
+func isPunct(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
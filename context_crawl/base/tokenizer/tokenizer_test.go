@@ -0,0 +1,94 @@
+package tokenizer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPretokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello world", []string{"Hello", " world"}},
+		{"I'm fine", []string{"I", "'m", " fine"}},
+		{"They'LL go", []string{"They", "'LL", " go"}},
+		{"12345", []string{"123", "45"}},
+		{"a  b", []string{"a", " ", " b"}},
+		{"foo\n\nbar", []string{"foo", "\n\n", "bar"}},
+		{"x = 1;", []string{"x", " =", " ", "1", ";"}},
+		{"end.\n", []string{"end", ".\n"}},
+		{"你好，世界", []string{"你好", "，世界"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Pretokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Pretokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	// 期望值与tiktoken的cl100k_base编码结果一致
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"", nil},
+		{"hello world", []int{15339, 1917}},
+		{"Hello world", []int{9906, 1917}},
+		{"tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+		{"The quick brown fox jumps over the lazy dog.", []int{791, 4062, 14198, 39935, 35308, 927, 279, 16053, 5679, 13}},
+		{"1234567", []int{4513, 10961, 22}},
+		{"internationalization", []int{98697, 2065}},
+		{"你好", []int{57668, 53901}},
+		{"  indented\n\ncode();", []int{220, 1280, 16243, 271, 1889, 2178}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := CL100K().Encode(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			if got := (TokenCounter{}).Count(tt.text); got != len(tt.want) {
+				t.Errorf("Count(%q) = %d, want %d", tt.text, got, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestLoadEncoding(t *testing.T) {
+	// "a"=0 "b"=1 "ab"=2
+	enc, err := LoadEncoding(strings.NewReader("YQ== 0\nYg== 1\n\nYWI= 2\n"))
+	if err != nil {
+		t.Fatalf("LoadEncoding() error = %v", err)
+	}
+	if got, want := enc.Encode("ababa"), []int{2, 2, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Encode(%q) = %v, want %v", "ababa", got, want)
+	}
+
+	for _, data := range []string{"", "YQ==\n", "!!! 0\n", "YQ== x\n"} {
+		if _, err := LoadEncoding(strings.NewReader(data)); err == nil {
+			t.Errorf("LoadEncoding(%q) error = nil, want error", data)
+		}
+	}
+}
+
+func TestNewCounter(t *testing.T) {
+	tests := []struct {
+		unit string
+		want Counter
+	}{
+		{UnitRune, RuneCounter{}},
+		{UnitToken, TokenCounter{}},
+		{"", RuneCounter{}},
+		{"words", RuneCounter{}},
+	}
+	for _, tt := range tests {
+		if got := NewCounter(tt.unit); got != tt.want {
+			t.Errorf("NewCounter(%q) = %T, want %T", tt.unit, got, tt.want)
+		}
+	}
+}
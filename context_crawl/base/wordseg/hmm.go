@@ -0,0 +1,191 @@
+package wordseg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// 字在词中的位置：词首、词中、词尾、单字成词
+const (
+	stateB = iota
+	stateM
+	stateE
+	stateS
+	numStates
+)
+
+// minLogProb 不可能事件的对数概率
+const minLogProb = -3.14e100
+
+// 初始状态与状态转移的对数概率，取自jieba（finalseg/prob_start.py、prob_trans.py）在人民日报语料上的统计，许可见NOTICE
+var (
+	startProb = [numStates]float64{
+		stateB: -0.26268660809250016,
+		stateM: minLogProb,
+		stateE: minLogProb,
+		stateS: -1.4652633398537678,
+	}
+	transProb = [numStates][numStates]float64{
+		stateB: {stateB: minLogProb, stateM: -0.916290731874155, stateE: -0.51082562376599, stateS: minLogProb},
+		stateM: {stateB: minLogProb, stateM: -1.2603623820268226, stateE: -0.33344856811948514, stateS: minLogProb},
+		stateE: {stateB: -0.5897149736854513, stateM: minLogProb, stateE: minLogProb, stateS: -0.8085250474669937},
+		stateS: {stateB: -0.7211965654669841, stateM: minLogProb, stateE: minLogProb, stateS: -0.6658631448798212},
+	}
+	// prevStates 每个状态可能的前一个状态
+	prevStates = [numStates][]int{
+		stateB: {stateE, stateS},
+		stateM: {stateM, stateB},
+		stateE: {stateB, stateM},
+		stateS: {stateS, stateE},
+	}
+)
+
+// hmm 用于识别未登录词的隐马尔可夫模型
+// 发射概率由词典统计得到：每个词按词频累计其首字、中间字、尾字和单字出现在各状态的次数
+// 也可以通过LoadHMM加载在标注语料上训练好的发射概率，此后更换词典不再重新估计
+type hmm struct {
+	emit    [numStates]map[rune]float64
+	floor   float64 // 词典中从未出现过的字的发射概率
+	trained bool    // 发射概率是否来自训练好的参数文件
+}
+
+// newHMM 根据词典估计发射概率
+func newHMM(words []string, freqs []float64) *hmm {
+	var counts [numStates]map[rune]float64
+	var totals [numStates]float64
+	for i := range counts {
+		counts[i] = make(map[rune]float64)
+	}
+	for i, word := range words {
+		runes := []rune(word)
+		// 高频虚字的词频远高于一般词，取对数避免它们压倒其他字
+		weight := math.Log(1 + freqs[i])
+		if len(runes) == 1 {
+			counts[stateS][runes[0]] += weight
+			totals[stateS] += weight
+			continue
+		}
+		for j, r := range runes {
+			state := stateM
+			switch j {
+			case 0:
+				state = stateB
+			case len(runes) - 1:
+				state = stateE
+			}
+			counts[state][r] += weight
+			totals[state] += weight
+		}
+	}
+
+	h := &hmm{floor: math.Log(1e-6)}
+	for state := range counts {
+		h.emit[state] = make(map[rune]float64, len(counts[state]))
+		for r, c := range counts[state] {
+			// 加一平滑后取对数
+			h.emit[state][r] = math.Log((c + 1) / (totals[state] + float64(len(counts[state]))))
+		}
+	}
+	return h
+}
+
+// stateNames 参数文件中各状态的名称，与jieba的prob_emit相同
+var stateNames = [numStates]string{stateB: "B", stateM: "M", stateE: "E", stateS: "S"}
+
+// parseEmit 解析训练好的发射概率：JSON对象 {"B": {"字": 对数概率, ...}, "M": ..., "E": ..., "S": ...}
+// jieba的finalseg/prob_emit.py去掉开头的 "P=" 、把单引号换成双引号后即为此格式，这里直接兼容
+func parseEmit(r io.Reader) (*hmm, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "P=") || strings.HasPrefix(text, "P =") {
+		text = strings.TrimSpace(text[strings.Index(text, "=")+1:])
+		text = strings.ReplaceAll(text, "'", `"`)
+	}
+
+	var raw map[string]map[string]float64
+	if err := json.Unmarshal([]byte(text), &raw); err != nil {
+		return nil, fmt.Errorf("HMM参数格式无效: %w", err)
+	}
+	h := &hmm{floor: 0, trained: true}
+	for state, name := range stateNames {
+		probs, ok := raw[name]
+		if !ok || len(probs) == 0 {
+			return nil, fmt.Errorf("HMM参数缺少状态%s的发射概率", name)
+		}
+		h.emit[state] = make(map[rune]float64, len(probs))
+		for key, p := range probs {
+			runes := []rune(key)
+			if len(runes) != 1 {
+				return nil, fmt.Errorf("HMM参数中状态%s的键不是单个字: %q", name, key)
+			}
+			h.emit[state][runes[0]] = p
+			// 参数中没有的字按出现过的最小概率计算
+			if p < h.floor {
+				h.floor = p
+			}
+		}
+	}
+	return h, nil
+}
+
+// emitProb 状态state发射字r的对数概率
+func (h *hmm) emitProb(state int, r rune) float64 {
+	if p, ok := h.emit[state][r]; ok {
+		return p
+	}
+	return h.floor
+}
+
+// cut 用Viterbi算法求最可能的状态序列，按B...E和S切出词
+func (h *hmm) cut(runes []rune) []string {
+	n := len(runes)
+	prob := make([][numStates]float64, n)
+	path := make([][numStates]int, n)
+
+	for state := 0; state < numStates; state++ {
+		prob[0][state] = startProb[state] + h.emitProb(state, runes[0])
+	}
+	for t := 1; t < n; t++ {
+		for state := 0; state < numStates; state++ {
+			best, from := math.Inf(-1), prevStates[state][0]
+			for _, prev := range prevStates[state] {
+				if p := prob[t-1][prev] + transProb[prev][state]; p > best {
+					best, from = p, prev
+				}
+			}
+			prob[t][state] = best + h.emitProb(state, runes[t])
+			path[t][state] = from
+		}
+	}
+
+	// 最后一个字只能是词尾或单字
+	state := stateE
+	if prob[n-1][stateS] > prob[n-1][stateE] {
+		state = stateS
+	}
+	states := make([]int, n)
+	for t := n - 1; t >= 0; t-- {
+		states[t] = state
+		state = path[t][state]
+	}
+
+	var words []string
+	begin := 0
+	for t, state := range states {
+		switch state {
+		case stateB:
+			begin = t
+		case stateE:
+			words = append(words, string(runes[begin:t+1]))
+		case stateS:
+			words = append(words, string(runes[t]))
+		}
+	}
+	return words
+}
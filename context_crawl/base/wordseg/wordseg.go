@@ -0,0 +1,353 @@
+// ================== 中文分词：词典 + HMM ===================
+package wordseg
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// dictData 内置词典，每行为 "词 词频"；从jieba的dict.txt中选取的常用词，词频为jieba的原值，来源和许可见仓库根目录的NOTICE
+//
+//go:embed dict.txt
+var dictData string
+
+// DefaultUserFreq 用户词典中未给出词频的词使用的词频，足以让它在切分时优先成词
+const DefaultUserFreq = 3000
+
+var (
+	// reBlock 切分前先把文本分成汉字段、英文数字段和其他字符
+	reBlock = regexp.MustCompile(`\p{Han}+|[^\s\p{Han}\p{P}\p{S}]+(?:[_+#.-]+[^\s\p{Han}\p{P}\p{S}]+)*[+#]*|\S`)
+	reHan   = regexp.MustCompile(`^\p{Han}+$`)
+	// reContent 至少包含一个字母或数字的词
+	reContent = regexp.MustCompile(`[\p{L}\p{N}]`)
+)
+
+// Segmenter 基于词典的中文分词器
+// 汉字段先按词典构建所有可能成词的有向无环图，用动态规划取词频乘积最大的切分；
+// 切分后连续的、词典中没有的单字再交给HMM识别新词（人名、术语等）
+type Segmenter struct {
+	mu    sync.RWMutex
+	freq  map[string]float64 // 词频；词的所有前缀也在其中，词频为0，用于提前结束查找
+	total float64            // 词频总和
+	hmm   *hmm
+}
+
+var (
+	defaultSegmenter *Segmenter
+	defaultOnce      sync.Once
+)
+
+// Default 返回使用内置词典的全局分词器，用户词典加载到它上面后对所有调用方生效
+func Default() *Segmenter {
+	defaultOnce.Do(func() {
+		defaultSegmenter = New()
+	})
+	return defaultSegmenter
+}
+
+// Cut 使用全局分词器切分文本
+func Cut(text string) []string {
+	return Default().Cut(text)
+}
+
+// Words 使用全局分词器切分文本，返回小写的词，去掉标点和符号；用于排序、关键词和指纹计算
+func Words(text string) []string {
+	var words []string
+	for _, w := range Cut(strings.ToLower(text)) {
+		if reContent.MatchString(w) {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// New 创建一个加载了内置词典的分词器
+func New() *Segmenter {
+	s := &Segmenter{}
+	words, freqs, _ := readDict(strings.NewReader(dictData))
+	s.setDict(words, freqs)
+	return s
+}
+
+// LoadDict 用完整的词典替换内置词典，格式与jieba的dict.txt相同：每行 "词 词频 [词性]"
+// 已通过AddWord、LoadUserDict加入的词会被丢弃，需要在替换词典之后再加载用户词典；
+// 没有加载训练好的HMM参数时，发射概率按新词典重新估计
+func (s *Segmenter) LoadDict(r io.Reader) error {
+	words, freqs, err := readDict(r)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("词典中没有有效的词")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setDict(words, freqs)
+	return nil
+}
+
+// LoadDictFile 从文件加载完整的词典，见LoadDict
+func (s *Segmenter) LoadDictFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.LoadDict(f)
+}
+
+// LoadHMM 加载训练好的HMM发射概率，替换按词典估计的参数，格式见parseEmit
+func (s *Segmenter) LoadHMM(r io.Reader) error {
+	h, err := parseEmit(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hmm = h
+	return nil
+}
+
+// LoadHMMFile 从文件加载训练好的HMM发射概率
+func (s *Segmenter) LoadHMMFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.LoadHMM(f)
+}
+
+// AddWord 向词典中加入一个词，freq不大于0时使用DefaultUserFreq
+func (s *Segmenter) AddWord(word string, freq float64) {
+	word = strings.TrimSpace(word)
+	if word == "" {
+		return
+	}
+	if freq <= 0 {
+		freq = DefaultUserFreq
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addWord(word, freq)
+}
+
+// LoadUserDict 加载用户词典，格式与jieba相同：每行 "词 [词频] [词性]"，词性会被忽略
+func (s *Segmenter) LoadUserDict(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		freq := 0.0
+		if len(fields) > 1 {
+			f, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return fmt.Errorf("用户词典第%d行词频无效: %s", lineNo, line)
+			}
+			freq = f
+		}
+		s.AddWord(fields[0], freq)
+	}
+	return scanner.Err()
+}
+
+// LoadUserDictFile 从文件加载用户词典
+func (s *Segmenter) LoadUserDictFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.LoadUserDict(f)
+}
+
+// Cut 将文本切分为词，返回的词不含空白；英文单词、数字、版本号等保持完整，标点单独成词
+func (s *Segmenter) Cut(text string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var words []string
+	for _, block := range reBlock.FindAllString(text, -1) {
+		if reHan.MatchString(block) {
+			words = append(words, s.cutHan([]rune(block))...)
+			continue
+		}
+		words = append(words, block)
+	}
+	return words
+}
+
+// cutHan 切分一段连续的汉字
+func (s *Segmenter) cutHan(runes []rune) []string {
+	dag := s.dag(runes)
+	route := s.route(runes, dag)
+
+	var words []string
+	var buf []rune // 连续的单字
+	flush := func() {
+		switch {
+		case len(buf) == 0:
+		case len(buf) == 1:
+			words = append(words, string(buf))
+		case s.freq[string(buf)] > 0:
+			for _, r := range buf {
+				words = append(words, string(r))
+			}
+		default:
+			words = append(words, s.hmm.cut(buf)...)
+		}
+		buf = nil
+	}
+
+	for x := 0; x < len(runes); {
+		y := route[x].end + 1
+		if y-x == 1 {
+			buf = append(buf, runes[x])
+		} else {
+			flush()
+			words = append(words, string(runes[x:y]))
+		}
+		x = y
+	}
+	flush()
+	return words
+}
+
+// dag 每个位置可以成词的所有结束位置
+func (s *Segmenter) dag(runes []rune) [][]int {
+	dag := make([][]int, len(runes))
+	for k := range runes {
+		var ends []int
+		for i := k; i < len(runes); i++ {
+			freq, ok := s.freq[string(runes[k:i+1])]
+			if !ok {
+				break
+			}
+			if freq > 0 {
+				ends = append(ends, i)
+			}
+		}
+		if len(ends) == 0 {
+			ends = []int{k}
+		}
+		dag[k] = ends
+	}
+	return dag
+}
+
+// step 动态规划中每个位置的最优切分
+type step struct {
+	logProb float64
+	end     int
+}
+
+// route 从后向前计算每个位置开始的最大概率切分，未登录的单字词频按1计算
+func (s *Segmenter) route(runes []rune, dag [][]int) []step {
+	n := len(runes)
+	route := make([]step, n+1)
+	logTotal := math.Log(s.total)
+	for idx := n - 1; idx >= 0; idx-- {
+		best := step{logProb: math.Inf(-1)}
+		for _, x := range dag[idx] {
+			freq := s.freq[string(runes[idx:x+1])]
+			if freq <= 0 {
+				freq = 1
+			}
+			p := math.Log(freq) - logTotal + route[x+1].logProb
+			if p > best.logProb || (p == best.logProb && x > best.end) {
+				best = step{logProb: p, end: x}
+			}
+		}
+		route[idx] = best
+	}
+	return route
+}
+
+// setDict 用给定的词典重建词频表，调用方负责加锁
+func (s *Segmenter) setDict(words []string, freqs []float64) {
+	s.freq = make(map[string]float64, len(words)*2)
+	s.total = 0
+	for i, word := range words {
+		s.addWord(word, freqs[i])
+	}
+	if s.hmm == nil || !s.hmm.trained {
+		s.hmm = newHMM(words, freqs)
+	}
+}
+
+// addWord 加入词及其所有前缀，调用方负责加锁
+func (s *Segmenter) addWord(word string, freq float64) {
+	if old, ok := s.freq[word]; ok {
+		s.total -= old
+	}
+	s.freq[word] = freq
+	s.total += freq
+	runes := []rune(word)
+	for i := 1; i < len(runes); i++ {
+		prefix := string(runes[:i])
+		if _, ok := s.freq[prefix]; !ok {
+			s.freq[prefix] = 0
+		}
+	}
+}
+
+// readDict 读取 "词 词频 [词性]" 格式的词典，空行和#开头的行被跳过
+func readDict(r io.Reader) ([]string, []float64, error) {
+	var words []string
+	var freqs []float64
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		word, freq, ok := parseLine(line)
+		if !ok {
+			return nil, nil, fmt.Errorf("词典第%d行格式无效: %s", lineNo, line)
+		}
+		words = append(words, word)
+		freqs = append(freqs, freq)
+	}
+	return words, freqs, scanner.Err()
+}
+
+// parseLine 解析词典中的一行
+func parseLine(line string) (string, float64, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return "", 0, false
+	}
+	freq, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || freq <= 0 {
+		return "", 0, false
+	}
+	return fields[0], freq, true
+}
+
+// IsHanWord 判断词是否由汉字组成且长度至少为2，用于过滤关键词、查询词中的单字和符号
+func IsHanWord(word string) bool {
+	n := 0
+	for _, r := range word {
+		if !unicode.Is(unicode.Han, r) {
+			return false
+		}
+		n++
+	}
+	return n >= 2
+}
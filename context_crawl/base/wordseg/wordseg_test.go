@@ -0,0 +1,199 @@
+package wordseg
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCut(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"南京市长江大桥", []string{"南京市", "长江大桥"}},
+		{"中国科学院", []string{"中国科学院"}},
+		{"深造", []string{"深造"}},
+		{"分布式", []string{"分布式"}},
+		{"他去国外深造了", []string{"他", "去", "国外", "深造", "了"}},
+		{"这是一个分布式系统", []string{"这是", "一个", "分布式系统"}},
+		{"中国科学院院士在北京开会", []string{"中国科学院", "院士", "在", "北京", "开会"}},
+		{"市长来到南京", []string{"市长", "来到", "南京"}},
+		{"使用Go 1.22开发", []string{"使用", "Go", "1.22", "开发"}},
+		{"C++和Node.js", []string{"C++", "和", "Node.js"}},
+		{"你好，世界！", []string{"你好", "，", "世界", "！"}},
+	}
+	s := New()
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := s.Cut(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cut(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Go语言的分布式系统。", []string{"go", "语言", "的", "分布式系统"}},
+		{"——", nil},
+	}
+	for _, tt := range tests {
+		if got := Words(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Words(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLoadUserDict(t *testing.T) {
+	tests := []struct {
+		name    string
+		dict    string
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "word without frequency",
+			dict: "云原生网关\n",
+			text: "部署云原生网关",
+			want: []string{"部署", "云原生网关"},
+		},
+		{
+			name: "frequency and tag",
+			dict: "# 注释\n上下文窗口 5000 n\n",
+			text: "扩大上下文窗口",
+			want: []string{"扩大", "上下文窗口"},
+		},
+		{
+			name:    "invalid frequency",
+			dict:    "词 abc\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			err := s.LoadUserDict(strings.NewReader(tt.dict))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadUserDict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := s.Cut(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cut(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDict(t *testing.T) {
+	tests := []struct {
+		name    string
+		dict    string
+		text    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "replaces builtin dict",
+			dict: "南京 100 ns\n市长 200 n\n江大桥 50 nr\n",
+			text: "南京市长江大桥",
+			want: []string{"南京", "市长", "江大桥"},
+		},
+		{
+			name:    "invalid line",
+			dict:    "南京\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty dict",
+			dict:    "# 只有注释\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			err := s.LoadDict(strings.NewReader(tt.dict))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadDict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				// 加载失败时保留原来的词典
+				if got := s.Cut("中国科学院"); !reflect.DeepEqual(got, []string{"中国科学院"}) {
+					t.Errorf("Cut() after failed LoadDict = %q", got)
+				}
+				return
+			}
+			if got := s.Cut(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cut(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadHMM(t *testing.T) {
+	// 只含"甲乙丙丁"四个字的发射概率：甲乙成词，丙丁各自单字成词
+	const jiebaStyle = `P={'B': {'甲': -0.1, '乙': -9.0, '丙': -9.0, '丁': -9.0},
+ 'E': {'甲': -9.0, '乙': -0.1, '丙': -9.0, '丁': -9.0},
+ 'M': {'甲': -9.0, '乙': -9.0, '丙': -9.0, '丁': -9.0},
+ 'S': {'甲': -9.0, '乙': -9.0, '丙': -0.1, '丁': -0.1}}`
+	const jsonStyle = `{"B": {"甲": -0.1, "乙": -9}, "E": {"甲": -9, "乙": -0.1}, "M": {"甲": -9}, "S": {"丙": -0.1, "丁": -0.1}}`
+
+	tests := []struct {
+		name    string
+		params  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "jieba prob_emit", params: jiebaStyle, want: []string{"甲乙", "丙", "丁"}},
+		{name: "json", params: jsonStyle, want: []string{"甲乙", "丙", "丁"}},
+		{name: "missing state", params: `{"B": {"甲": -1}}`, wantErr: true},
+		{name: "key is not a rune", params: `{"B": {"甲乙": -1}, "M": {"甲": -1}, "E": {"甲": -1}, "S": {"甲": -1}}`, wantErr: true},
+		{name: "not json", params: `B 甲 -1`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New()
+			err := s.LoadHMM(strings.NewReader(tt.params))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadHMM() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := s.Cut("甲乙丙丁"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cut() = %q, want %q", got, tt.want)
+			}
+			// 更换词典后仍使用加载的参数
+			if err := s.LoadDict(strings.NewReader("南京 100\n")); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Cut("甲乙丙丁"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cut() after LoadDict = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHanWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{"分布式", true},
+		{"式", false},
+		{"Go语言", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsHanWord(tt.word); got != tt.want {
+			t.Errorf("IsHanWord(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
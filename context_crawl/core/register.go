@@ -4,7 +4,10 @@ package core
 
 import (
 	"context_crawl/base/colly"
+	"context_crawl/custom/arxiv"
+	"context_crawl/custom/doi"
 	"context_crawl/custom/github"
+	"context_crawl/custom/markup"
 	"context_crawl/custom/md"
 	"context_crawl/custom/pdf"
 	"context_crawl/types"
@@ -41,6 +44,17 @@ func register() {
 	// 4️⃣ 注册PDF pipeline
 	RegisterPipeline(25, "pdf", pdf.NewPDFPipeline())
 
+	// 5️⃣ 注册arXiv pipeline
+	RegisterPipeline(30, "arxiv", arxiv.NewArxivPipeline())
+
+	// 6️⃣ 注册DOI pipeline
+	RegisterPipeline(35, "doi", doi.NewDOIPipeline())
+
+	// 7️⃣ 注册reStructuredText、AsciiDoc、Org-mode pipeline，与markdown同级
+	RegisterPipeline(20, "rst", markup.NewRSTPipeline())
+	RegisterPipeline(20, "asciidoc", markup.NewAsciiDocPipeline())
+	RegisterPipeline(20, "org", markup.NewOrgPipeline())
+
 }
 
 // RegisterPipeline 注册一个pipeline
@@ -77,6 +91,16 @@ func GetAllPipelines() []PipelineEntry {
 	return pipelines
 }
 
+// GetPipeline 按注册名称查找pipeline
+func GetPipeline(name string) (types.Pipeline, bool) {
+	for _, entry := range pipelines {
+		if entry.Name == name {
+			return entry.Pipeline, true
+		}
+	}
+	return nil, false
+}
+
 // GetPipelinesAfter 返回第一个匹配指定URL的pipeline之后，下一个也匹配该URL的pipeline
 // 用于保底机制：当前pipeline失败后，尝试下一个匹配的pipeline
 func GetPipelinesAfter(url string) []PipelineEntry {
@@ -0,0 +1,186 @@
+package arxiv
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"context_crawl/types"
+)
+
+// apiInterval arXiv API的使用条款要求每3秒最多请求一次
+const apiInterval = 3 * time.Second
+
+// apiLimiter 所有ArxivPipeline实例共用，export.arxiv.org按来源限流，各实例分别计时没有意义
+var apiLimiter = newRateLimiter(apiInterval)
+
+// rateLimiter 保证相邻两次请求的开始时间至少间隔interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time // 下一次请求最早的开始时间
+}
+
+// newRateLimiter 创建一个新的rateLimiter实例
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval}
+}
+
+// Wait 等到可以发出请求为止；轮到之前ctx就会到期时立即返回错误，不占用请求名额
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	start := time.Now()
+	if l.next.After(start) {
+		start = l.next
+	}
+	if deadline, ok := ctx.Deadline(); ok && !start.Before(deadline) {
+		l.mu.Unlock()
+		return fmt.Errorf("截止时间前轮不到请求arXiv API")
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// atomFeed arXiv元数据API返回的Atom feed，只解析需要的字段
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	Summary   string `xml:"summary"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Authors   []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+	PrimaryCategory struct {
+		Term string `xml:"term,attr"`
+	} `xml:"http://arxiv.org/schemas/atom primary_category"`
+	DOI        string `xml:"http://arxiv.org/schemas/atom doi"`
+	JournalRef string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	Comment    string `xml:"http://arxiv.org/schemas/atom comment"`
+}
+
+// fetchMetadata 从arXiv元数据API获取论文的标题、作者、摘要、分类和日期
+// 请求经过限流并在options.Deadline前结束；编号不存在时返回 arxiv_not_found 错误
+func (p *ArxivPipeline) fetchMetadata(options types.Options, id ID) (map[string]string, error) {
+	ctx, cancel := options.Context()
+	defer cancel()
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("等待arXiv元数据API限流失败: %v", err)
+	}
+
+	apiURL := fmt.Sprintf("%s?id_list=%s&max_results=1", p.apiURL, url.QueryEscape(id.String()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建arXiv元数据请求失败: %v", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求arXiv元数据API失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// 编号格式不合法时API返回400
+		if resp.StatusCode == http.StatusBadRequest {
+			return nil, notFoundError(id)
+		}
+		return nil, fmt.Errorf("arXiv元数据API返回状态码: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取arXiv元数据失败: %v", err)
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("解析arXiv元数据失败: %v", err)
+	}
+	// 编号不存在时没有entry，或者entry是一条错误说明
+	if len(feed.Entries) == 0 || strings.Contains(feed.Entries[0].ID, "/api/errors") || collapse(feed.Entries[0].Title) == "" {
+		return nil, notFoundError(id)
+	}
+	return entryMetadata(feed.Entries[0], id), nil
+}
+
+// entryMetadata 把Atom entry转换为元数据，键名与PDF元数据保持一致（title、author）
+func entryMetadata(entry atomEntry, id ID) map[string]string {
+	metadata := map[string]string{
+		"arxiv_id": id.Base,
+		"title":    collapse(entry.Title),
+		"abstract": collapse(entry.Summary),
+	}
+
+	// 未指定版本时，entry的id是最新版本的地址
+	version := id.Version
+	if i := strings.LastIndex(entry.ID, "/abs/"); i >= 0 {
+		if entryID, ok := parseID(entry.ID[i+len("/abs/"):]); ok && entryID.Version != "" {
+			version = entryID.Version
+		}
+	}
+	if version != "" {
+		metadata["version"] = version
+	}
+
+	authors := make([]string, 0, len(entry.Authors))
+	for _, author := range entry.Authors {
+		if name := collapse(author.Name); name != "" {
+			authors = append(authors, name)
+		}
+	}
+	categories := make([]string, 0, len(entry.Categories))
+	for _, category := range entry.Categories {
+		if category.Term != "" {
+			categories = append(categories, category.Term)
+		}
+	}
+
+	optional := map[string]string{
+		"author":           strings.Join(authors, ", "),
+		"categories":       strings.Join(categories, ", "),
+		"primary_category": entry.PrimaryCategory.Term,
+		"published":        strings.TrimSpace(entry.Published),
+		"updated":          strings.TrimSpace(entry.Updated),
+		"doi":              strings.TrimSpace(entry.DOI),
+		"journal_ref":      collapse(entry.JournalRef),
+		"comment":          collapse(entry.Comment),
+	}
+	for key, value := range optional {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+// collapse 把换行和连续空白压缩为一个空格，API返回的标题和摘要带有换行缩进
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// notFoundError 论文编号不存在，其他pipeline同样无法抓取
+func notFoundError(id ID) error {
+	return types.NewError(ErrCodeNotFound, "arXiv上没有编号为 "+id.String()+" 的论文", true, nil)
+}
@@ -0,0 +1,66 @@
+package arxiv
+
+import (
+	"regexp"
+	"strings"
+
+	"context_crawl/base/colly"
+	"context_crawl/types"
+)
+
+// articleSelector LaTeXML渲染的HTML全文中正文所在的元素，不含站点导航和页脚
+const articleSelector = "article.ltx_document"
+
+var (
+	// LaTeXML把公式渲染为MathML，alttext属性保存了原始的TeX源码
+	reMath    = regexp.MustCompile(`(?is)<math\b[^>]*>.*?</math>`)
+	reAltText = regexp.MustCompile(`(?is)\balttext\s*=\s*"([^"]*)"`)
+)
+
+// HTMLCrawler 抓取arXiv的HTML全文，复用colly爬虫
+type HTMLCrawler struct {
+	crawler *colly.CollyCrawler
+}
+
+// NewHTMLCrawler 创建一个新的HTMLCrawler实例
+func NewHTMLCrawler() *HTMLCrawler {
+	return &HTMLCrawler{
+		crawler: colly.NewCollyCrawler(),
+	}
+}
+
+// Crawl 抓取input.Url指向的HTML全文，实现types.Crawler接口
+// 论文没有HTML全文时arXiv返回404，此时返回 arxiv_no_html 错误
+func (hc *HTMLCrawler) Crawl(input types.Type) (types.Type, error) {
+	// 没有指定选择器和锚点时只取正文
+	if input.Options.Selector == "" && !strings.Contains(input.Url, "#") {
+		input.Options.Selector = articleSelector
+	}
+
+	result, err := hc.crawler.Crawl(input)
+	if err != nil {
+		return types.Type{}, err
+	}
+	if !strings.Contains(result.Text, "ltx_") {
+		return types.Type{}, types.NewError(ErrCodeNoHTML, "没有HTML全文: "+input.Url, false, nil)
+	}
+
+	result.Text = replaceMath(result.Text)
+	result.Options = input.Options
+	return result, nil
+}
+
+// replaceMath 把MathML公式替换为 $TeX$，避免清洗后只剩下零散的符号
+func replaceMath(text string) string {
+	return reMath.ReplaceAllStringFunc(text, func(math string) string {
+		m := reAltText.FindStringSubmatch(math)
+		if m == nil {
+			return math
+		}
+		tex := strings.TrimSpace(m[1])
+		if tex == "" {
+			return ""
+		}
+		return " $" + tex + "$ "
+	})
+}
@@ -0,0 +1,7 @@
+package arxiv
+
+// arXiv处理的错误码，见types.Error
+const (
+	ErrCodeNotFound = "arxiv_not_found" // 论文编号不存在
+	ErrCodeNoHTML   = "arxiv_no_html"   // 没有HTML全文，改用PDF
+)
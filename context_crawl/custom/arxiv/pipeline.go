@@ -0,0 +1,132 @@
+package arxiv
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"context_crawl/base/colly"
+	"context_crawl/base/normalize"
+	"context_crawl/custom/pdf"
+	"context_crawl/types"
+)
+
+// ArxivPipeline 处理arXiv论文：从元数据API获取标题、作者、摘要等信息，
+// 优先抓取HTML全文，没有HTML全文时改用PDF
+type ArxivPipeline struct {
+	Crawler types.Crawler  // HTML全文的爬虫组件
+	Cleaner types.Cleaner  // 清洗组件
+	Chunker types.Chunker  // 分块组件
+	PDF     types.Pipeline // 没有HTML全文时使用的PDF pipeline
+
+	baseURL    string // 论文页面的地址前缀
+	apiURL     string // 元数据API地址
+	httpClient *http.Client
+	limiter    *rateLimiter // 元数据API的限流器
+}
+
+// pdfReserve 为PDF全文预留的时间：元数据和HTML全文最晚在请求截止时间前这么久结束
+const pdfReserve = 4 * time.Second
+
+// NewArxivPipeline 创建一个新的ArxivPipeline实例
+func NewArxivPipeline() *ArxivPipeline {
+	return &ArxivPipeline{
+		Crawler: NewHTMLCrawler(),
+		// 复用colly的清洗和分块组件，与PDF一样按章节分块
+		Cleaner:    normalize.NewCleaner(colly.NewBasicCleaner(), normalize.DefaultOptions()),
+		Chunker:    colly.NewSectionChunker(0.2),
+		PDF:        pdf.NewPDFPipeline(),
+		baseURL:    "https://arxiv.org",
+		apiURL:     "https://export.arxiv.org/api/query",
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		limiter:    apiLimiter,
+	}
+}
+
+// Process 执行arXiv pipeline处理流程，实现types.Pipeline接口
+// 无论全文来自HTML还是PDF，结果都带有相同的元数据，metadata.format 标明全文来源；
+// 各步骤共用input.Options.Deadline，元数据和HTML全文为PDF预留pdfReserve
+func (p *ArxivPipeline) Process(input types.Type) (types.Type, error) {
+	id, ok := ParseURL(input.Url)
+	if !ok {
+		return types.Type{}, fmt.Errorf("不是arXiv论文链接: %s", input.Url)
+	}
+	early := input.Options.Reserve(pdfReserve)
+
+	// 元数据获取失败不影响全文，编号不存在时直接返回错误
+	metadata, err := p.fetchMetadata(early, id)
+	if err != nil {
+		if typed, ok := types.AsError(err); ok && typed.NoFallback {
+			return types.Type{}, err
+		}
+		log.Printf("⚠️ 获取arXiv元数据失败: %v, ID: %s", err, id)
+	}
+
+	// 指定了页码范围时直接使用PDF，剩余时间只够PDF时不再尝试HTML
+	if input.Options.Pages == "" && !early.Expired() {
+		doc, err := p.processHTML(types.Type{Url: input.Url, Options: early}, id)
+		if err == nil {
+			doc.Url = input.Url
+			doc.Options = input.Options
+			doc.Metadata = mergeMetadata(metadata, doc.Metadata, id, "html")
+			return doc, nil
+		}
+		log.Printf("⚠️ arXiv HTML全文不可用，改用PDF: %v, ID: %s", err, id)
+	}
+
+	doc, err := p.PDF.Process(types.Type{Url: p.baseURL + "/pdf/" + id.String(), Options: input.Options})
+	if err != nil {
+		return types.Type{}, err
+	}
+	doc.Url = input.Url
+	doc.Metadata = mergeMetadata(metadata, doc.Metadata, id, "pdf")
+	return doc, nil
+}
+
+// Match 匹配方法，检查URL是否是arXiv论文的abs/pdf/html页面
+func (p *ArxivPipeline) Match(url string) bool {
+	return IsArxivURL(url)
+}
+
+// processHTML 抓取、清洗并分块HTML全文，保留原链接中的锚点以便只返回目标章节
+func (p *ArxivPipeline) processHTML(input types.Type, id ID) (types.Type, error) {
+	htmlURL := p.baseURL + "/html/" + id.String()
+	if u, err := url.Parse(input.Url); err == nil && u.Fragment != "" {
+		htmlURL += "#" + u.Fragment
+	}
+
+	result, err := p.Crawler.Crawl(types.Type{Url: htmlURL, Options: input.Options})
+	if err != nil {
+		return types.Type{}, err
+	}
+
+	cleanResult, err := p.Cleaner.Clean(result)
+	if err != nil {
+		return types.Type{}, err
+	}
+
+	return p.Chunker.Chunk(cleanResult)
+}
+
+// mergeMetadata 合并元数据：以arXiv API的为准，PDF只补充总页数；
+// API不可用时保留PDF信息字典中的元数据
+func mergeMetadata(api, doc map[string]string, id ID, format string) map[string]string {
+	merged := make(map[string]string, len(api)+2)
+	if api == nil {
+		for key, value := range doc {
+			merged[key] = value
+		}
+	} else {
+		for key, value := range api {
+			merged[key] = value
+		}
+		if pages, ok := doc["pages"]; ok {
+			merged["pages"] = pages
+		}
+	}
+	merged["arxiv_id"] = id.Base
+	merged["format"] = format
+	return merged
+}
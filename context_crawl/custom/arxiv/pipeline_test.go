@@ -0,0 +1,298 @@
+package arxiv
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"context_crawl/types"
+)
+
+// feed 只有一篇论文的Atom feed
+const feed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:arxiv="http://arxiv.org/schemas/atom">
+  <entry>
+    <id>http://arxiv.org/abs/2401.01234v2</id>
+    <title>Attention Is
+      Still All You Need</title>
+    <summary>  We revisit attention.
+    </summary>
+    <published>2024-01-02T00:00:00Z</published>
+    <author><name>Ada Lovelace</name></author>
+    <author><name>Alan Turing</name></author>
+    <category term="cs.CL"/>
+    <category term="cs.LG"/>
+    <arxiv:primary_category term="cs.CL"/>
+  </entry>
+</feed>`
+
+// stubStep 记录收到的选项；delay大于0时阻塞到delay结束或截止时间到期
+type stubStep struct {
+	mu      sync.Mutex
+	calls   []types.Options
+	delay   time.Duration
+	text    string
+	metaKey string
+}
+
+func (s *stubStep) run(input types.Type) (types.Type, error) {
+	s.mu.Lock()
+	s.calls = append(s.calls, input.Options)
+	s.mu.Unlock()
+
+	if s.delay > 0 {
+		ctx, cancel := input.Options.Context()
+		defer cancel()
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return types.Type{}, ctx.Err()
+		}
+	}
+	return types.Type{Url: input.Url, Text: s.text, Options: input.Options, Metadata: map[string]string{s.metaKey: "1"}}, nil
+}
+
+func (s *stubStep) Crawl(input types.Type) (types.Type, error)   { return s.run(input) }
+func (s *stubStep) Process(input types.Type) (types.Type, error) { return s.run(input) }
+func (s *stubStep) Match(string) bool                            { return true }
+
+// passThrough 原样返回的清洗和分块组件
+type passThrough struct{}
+
+func (passThrough) Clean(input types.Type) (types.Type, error) { return input, nil }
+func (passThrough) Chunk(input types.Type) (types.Type, error) { return input, nil }
+
+// testPipeline 使用本地API、桩HTML爬虫和桩PDF pipeline的ArxivPipeline，不限流
+func testPipeline(apiURL string, html, pdf *stubStep) *ArxivPipeline {
+	p := NewArxivPipeline()
+	p.Crawler, p.Cleaner, p.Chunker, p.PDF = html, passThrough{}, passThrough{}, pdf
+	p.apiURL = apiURL
+	p.limiter = newRateLimiter(0)
+	return p
+}
+
+func TestProcessDeadline(t *testing.T) {
+	tests := []struct {
+		name       string
+		budget     time.Duration // 0表示没有截止时间
+		pages      string
+		apiDelay   time.Duration
+		htmlDelay  time.Duration
+		wantFormat string
+		wantHTML   bool // 是否尝试了HTML全文
+		wantTitle  bool // 是否拿到了元数据
+	}{
+		{name: "html full text", budget: 10 * time.Second, wantFormat: "html", wantHTML: true, wantTitle: true},
+		{name: "no deadline", wantFormat: "html", wantHTML: true, wantTitle: true},
+		{name: "page range goes to pdf", budget: 10 * time.Second, pages: "1-2", wantFormat: "pdf", wantTitle: true},
+		{
+			name:       "slow html leaves time for pdf",
+			budget:     pdfReserve + 300*time.Millisecond,
+			htmlDelay:  time.Minute,
+			wantFormat: "pdf",
+			wantHTML:   true,
+			wantTitle:  true,
+		},
+		{
+			name:       "slow api does not eat the pdf reserve",
+			budget:     pdfReserve + 300*time.Millisecond,
+			apiDelay:   time.Minute,
+			wantFormat: "pdf",
+		},
+		{name: "too little time for html", budget: pdfReserve / 2, wantFormat: "pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(tt.apiDelay):
+				case <-r.Context().Done():
+					return
+				}
+				w.Write([]byte(feed))
+			}))
+			defer server.Close()
+
+			html := &stubStep{delay: tt.htmlDelay, text: "html body", metaKey: "html"}
+			pdf := &stubStep{text: "pdf body", metaKey: "pages"}
+			p := testPipeline(server.URL, html, pdf)
+
+			options := types.Options{Pages: tt.pages}
+			if tt.budget > 0 {
+				options.Deadline = time.Now().Add(tt.budget)
+			}
+			start := time.Now()
+			doc, err := p.Process(types.Type{Url: "https://arxiv.org/abs/2401.01234", Options: options})
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if tt.budget > 0 && time.Since(start) > tt.budget {
+				t.Errorf("Process() took %v, budget %v", time.Since(start), tt.budget)
+			}
+
+			if doc.Metadata["format"] != tt.wantFormat {
+				t.Errorf("format = %q, want %q", doc.Metadata["format"], tt.wantFormat)
+			}
+			if got := doc.Metadata["title"] != ""; got != tt.wantTitle {
+				t.Errorf("has title = %v, want %v (metadata %v)", got, tt.wantTitle, doc.Metadata)
+			}
+			if got := len(html.calls) > 0; got != tt.wantHTML {
+				t.Fatalf("html tried = %v, want %v", got, tt.wantHTML)
+			}
+			if !doc.Options.Deadline.Equal(options.Deadline) {
+				t.Errorf("result deadline = %v, want %v", doc.Options.Deadline, options.Deadline)
+			}
+
+			// HTML全文在PDF预留时间之前结束，PDF使用完整的截止时间
+			if tt.wantHTML && !options.Deadline.IsZero() {
+				if want := options.Deadline.Add(-pdfReserve); !html.calls[0].Deadline.Equal(want) {
+					t.Errorf("html deadline = %v, want %v", html.calls[0].Deadline, want)
+				}
+			}
+			if tt.wantFormat == "pdf" {
+				if len(pdf.calls) != 1 || !pdf.calls[0].Deadline.Equal(options.Deadline) {
+					t.Errorf("pdf calls = %v, want one with deadline %v", pdf.calls, options.Deadline)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		want     map[string]string
+		wantCode string
+		wantErr  bool
+	}{
+		{
+			name:   "entry",
+			status: http.StatusOK,
+			body:   feed,
+			want: map[string]string{
+				"arxiv_id":         "2401.01234",
+				"version":          "v2",
+				"title":            "Attention Is Still All You Need",
+				"abstract":         "We revisit attention.",
+				"author":           "Ada Lovelace, Alan Turing",
+				"categories":       "cs.CL, cs.LG",
+				"primary_category": "cs.CL",
+				"published":        "2024-01-02T00:00:00Z",
+			},
+		},
+		{name: "empty feed", status: http.StatusOK, body: `<feed xmlns="http://www.w3.org/2005/Atom"></feed>`, wantCode: ErrCodeNotFound},
+		{
+			name:     "error entry",
+			status:   http.StatusOK,
+			body:     `<feed xmlns="http://www.w3.org/2005/Atom"><entry><id>http://arxiv.org/api/errors#incorrect_id</id><title>Error</title></entry></feed>`,
+			wantCode: ErrCodeNotFound,
+		},
+		{name: "bad request", status: http.StatusBadRequest, wantCode: ErrCodeNotFound},
+		{name: "server error", status: http.StatusServiceUnavailable, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.URL.Query().Get("id_list"); got != "2401.01234" {
+					t.Errorf("id_list = %q", got)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := testPipeline(server.URL, &stubStep{}, &stubStep{})
+			got, err := p.fetchMetadata(types.Options{}, ID{Base: "2401.01234"})
+			if tt.wantCode != "" || tt.wantErr {
+				typed, ok := types.AsError(err)
+				if tt.wantCode != "" && (!ok || typed.Code != tt.wantCode || !typed.NoFallback) {
+					t.Fatalf("fetchMetadata() error = %v, want code %s", err, tt.wantCode)
+				}
+				if err == nil {
+					t.Fatal("fetchMetadata() error = nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchMetadata() error = %v", err)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("metadata[%s] = %q, want %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	tests := []struct {
+		name        string
+		calls       int
+		timeout     time.Duration // 每次等待的超时，0表示不限制
+		wantErrs    int
+		wantElapsed time.Duration // 至少经过的时间
+	}{
+		{name: "first call is immediate", calls: 1},
+		{name: "calls are spaced", calls: 3, wantElapsed: 2 * interval},
+		{name: "deadline before the next slot", calls: 3, timeout: interval / 2, wantErrs: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(interval)
+			start := time.Now()
+			errs := 0
+			for i := 0; i < tt.calls; i++ {
+				ctx, cancel := context.Background(), context.CancelFunc(func() {})
+				if tt.timeout > 0 {
+					ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				}
+				if err := l.Wait(ctx); err != nil {
+					errs++
+				}
+				cancel()
+			}
+			elapsed := time.Since(start)
+			if errs != tt.wantErrs {
+				t.Errorf("Wait() errors = %d, want %d", errs, tt.wantErrs)
+			}
+			if elapsed < tt.wantElapsed {
+				t.Errorf("elapsed = %v, want at least %v", elapsed, tt.wantElapsed)
+			}
+			// 拿不到名额时立即返回，不等到超时
+			if tt.wantErrs > 0 && elapsed > tt.timeout {
+				t.Errorf("elapsed = %v, failed waits should return immediately", elapsed)
+			}
+		})
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want ID
+		ok   bool
+	}{
+		{"https://arxiv.org/abs/2401.01234", ID{Base: "2401.01234"}, true},
+		{"https://arxiv.org/pdf/2401.01234v2.pdf", ID{Base: "2401.01234", Version: "v2"}, true},
+		{"https://arxiv.org/html/2401.01234v1/figure1.png", ID{Base: "2401.01234", Version: "v1"}, true},
+		{"https://export.arxiv.org/abs/hep-th/9901001v3", ID{Base: "hep-th/9901001", Version: "v3"}, true},
+		{"https://arxiv.org/abs/math.GT/0309136", ID{Base: "math.GT/0309136"}, true},
+		{"https://arxiv.org/list/cs.CL/recent", ID{}, false},
+		{"https://example.com/abs/2401.01234", ID{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseURL(tt.url)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseURL(%q) = %v, %v, want %v, %v", tt.url, got, ok, tt.want, tt.ok)
+		}
+	}
+}
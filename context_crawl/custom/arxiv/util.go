@@ -0,0 +1,78 @@
+package arxiv
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// 2007年4月之后的新式编号，如 2401.01234、2401.01234v2
+	reNewID = regexp.MustCompile(`^(\d{4}\.\d{4,5})(v\d+)?$`)
+	// 旧式编号：分类/年月+序号，如 hep-th/9901001、math.GT/0309136v1
+	reOldID = regexp.MustCompile(`^([a-z][a-z-]*(?:\.[A-Z]{2})?/\d{7})(v\d+)?$`)
+)
+
+// ID 一篇arXiv论文的编号
+type ID struct {
+	Base    string // 不带版本号的编号，如 2401.01234、hep-th/9901001
+	Version string // 版本号，如 v2，为空表示最新版本
+}
+
+// String 返回带版本号的编号，如 2401.01234v2
+func (id ID) String() string {
+	return id.Base + id.Version
+}
+
+// IsArxivURL 判断URL是否为arXiv论文的abs/pdf/html页面
+func IsArxivURL(rawURL string) bool {
+	_, ok := ParseURL(rawURL)
+	return ok
+}
+
+// ParseURL 从arXiv链接中解析论文编号，支持以下形式：
+// arxiv.org/abs/<id>、arxiv.org/pdf/<id>[.pdf]、arxiv.org/html/<id>[/...]，
+// 以及 export.arxiv.org 等子域名，<id> 可以带版本号
+func ParseURL(rawURL string) (ID, bool) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ID{}, false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host != "arxiv.org" && !strings.HasSuffix(host, ".arxiv.org") {
+		return ID{}, false
+	}
+
+	var rest string
+	for _, prefix := range []string{"/abs/", "/pdf/", "/html/"} {
+		if strings.HasPrefix(u.Path, prefix) {
+			rest = strings.TrimPrefix(u.Path, prefix)
+			break
+		}
+	}
+	if rest == "" {
+		return ID{}, false
+	}
+
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	// 新式编号只占一段，html链接后面可能还有资源路径
+	if id, ok := parseID(strings.TrimSuffix(segments[0], ".pdf")); ok {
+		return id, true
+	}
+	// 旧式编号占两段
+	if len(segments) >= 2 {
+		return parseID(segments[0] + "/" + strings.TrimSuffix(segments[1], ".pdf"))
+	}
+	return ID{}, false
+}
+
+// parseID 解析不带域名的编号，如API返回的 http://arxiv.org/abs/2401.01234v2 中的编号部分
+func parseID(s string) (ID, bool) {
+	if m := reNewID.FindStringSubmatch(s); m != nil {
+		return ID{Base: m[1], Version: m[2]}, true
+	}
+	if m := reOldID.FindStringSubmatch(s); m != nil {
+		return ID{Base: m[1], Version: m[2]}, true
+	}
+	return ID{}, false
+}
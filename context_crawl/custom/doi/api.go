@@ -0,0 +1,350 @@
+package doi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// baseUserAgent 请求注册机构API时使用的User-Agent
+const baseUserAgent = "context_crawl/1.0"
+
+// userAgent 当前的User-Agent，配置了联系邮箱时带有mailto，Crossref据此把请求分到polite pool
+var userAgent atomic.Value
+
+func init() {
+	userAgent.Store(baseUserAgent)
+}
+
+// SetMailto 设置请求Crossref和DataCite时附带的联系邮箱，为空时不带mailto
+func SetMailto(mailto string) {
+	ua := baseUserAgent
+	if mailto = strings.TrimSpace(mailto); mailto != "" {
+		ua += " (mailto:" + mailto + ")"
+	}
+	userAgent.Store(ua)
+}
+
+// UserAgent 返回当前请求注册机构API使用的User-Agent
+func UserAgent() string {
+	return userAgent.Load().(string)
+}
+
+// record 从注册机构获取的DOI元数据和全文候选链接
+type record struct {
+	Metadata map[string]string
+	Links    []fullTextLink // 注册机构登记的全文链接
+	Landing  string         // 落地页，通常是出版商的文章页面
+}
+
+// fullTextLink 一个全文候选链接
+type fullTextLink struct {
+	URL string
+	PDF bool // 登记的类型为PDF
+}
+
+// crossrefWork Crossref /works 接口的返回，只解析需要的字段
+type crossrefWork struct {
+	Message struct {
+		Title  []string `json:"title"`
+		Author []struct {
+			Given  string `json:"given"`
+			Family string `json:"family"`
+			Name   string `json:"name"` // 机构作者
+		} `json:"author"`
+		ContainerTitle  []string       `json:"container-title"`
+		Publisher       string         `json:"publisher"`
+		Type            string         `json:"type"`
+		Abstract        string         `json:"abstract"` // JATS XML
+		ReferenceCount  int            `json:"reference-count"`
+		ReferencesCount int            `json:"references-count"`
+		Published       crossrefDate   `json:"published"`
+		Issued          crossrefDate   `json:"issued"`
+		URL             string         `json:"URL"`
+		Link            []crossrefLink `json:"link"`
+	} `json:"message"`
+}
+
+type crossrefDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+type crossrefLink struct {
+	URL                 string `json:"URL"`
+	ContentType         string `json:"content-type"`
+	IntendedApplication string `json:"intended-application"` // text-mining、similarity-checking、syndication、unspecified
+}
+
+// restrictedApplications 这些用途的链接面向签约的文本挖掘和查重服务，需要出版商授权，不是开放全文
+var restrictedApplications = map[string]bool{
+	"text-mining":         true,
+	"similarity-checking": true,
+}
+
+// dataciteDOI DataCite /dois 接口的返回，只解析需要的字段
+type dataciteDOI struct {
+	Data struct {
+		Attributes struct {
+			Titles []struct {
+				Title string `json:"title"`
+			} `json:"titles"`
+			Creators []struct {
+				Name       string `json:"name"`
+				GivenName  string `json:"givenName"`
+				FamilyName string `json:"familyName"`
+			} `json:"creators"`
+			Container struct {
+				Title string `json:"title"`
+			} `json:"container"`
+			Publisher       string      `json:"publisher"`
+			PublicationYear interface{} `json:"publicationYear"` // 数字或字符串
+			Dates           []struct {
+				Date     string `json:"date"`
+				DateType string `json:"dateType"`
+			} `json:"dates"`
+			Descriptions []struct {
+				Description     string `json:"description"`
+				DescriptionType string `json:"descriptionType"`
+			} `json:"descriptions"`
+			Types struct {
+				ResourceTypeGeneral string `json:"resourceTypeGeneral"`
+			} `json:"types"`
+			RelatedIdentifiers []struct {
+				RelationType string `json:"relationType"`
+			} `json:"relatedIdentifiers"`
+			ContentURL []string `json:"contentUrl"`
+			URL        string   `json:"url"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+var (
+	reXMLTag    = regexp.MustCompile(`(?s)<[^>]*>`)
+	reJATSTitle = regexp.MustCompile(`(?s)<jats:title>.*?</jats:title>`)
+)
+
+// fetchRecord 依次从Crossref和DataCite获取DOI的元数据，都没有登记时found为false
+func (p *DOIPipeline) fetchRecord(ctx context.Context, doi string) (rec record, found bool, err error) {
+	var work crossrefWork
+	found, err = p.getJSON(ctx, p.crossrefURL+"/works/"+url.PathEscape(doi), &work)
+	if err != nil {
+		return record{}, false, fmt.Errorf("请求Crossref失败: %v", err)
+	}
+	if found {
+		return crossrefRecord(work, doi), true, nil
+	}
+
+	var data dataciteDOI
+	found, err = p.getJSON(ctx, p.dataciteURL+"/dois/"+url.PathEscape(doi), &data)
+	if err != nil {
+		return record{}, false, fmt.Errorf("请求DataCite失败: %v", err)
+	}
+	if found {
+		return dataciteRecord(data, doi), true, nil
+	}
+	return record{}, false, nil
+}
+
+// getJSON 请求JSON接口，404时found为false
+func (p *DOIPipeline) getJSON(ctx context.Context, apiURL string, v interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", UserAgent())
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("状态码: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("解析响应失败: %v", err)
+	}
+	return true, nil
+}
+
+// crossrefRecord 转换Crossref的返回，PDF链接排在HTML链接之前，跳过文本挖掘和查重专用的链接
+func crossrefRecord(work crossrefWork, doi string) record {
+	m := work.Message
+
+	authors := make([]string, 0, len(m.Author))
+	for _, a := range m.Author {
+		if name := strings.TrimSpace(a.Given + " " + a.Family); name != "" {
+			authors = append(authors, name)
+		} else if a.Name != "" {
+			authors = append(authors, a.Name)
+		}
+	}
+
+	published := formatDateParts(m.Published)
+	if published == "" {
+		published = formatDateParts(m.Issued)
+	}
+	references := m.ReferenceCount
+	if m.ReferencesCount > references {
+		references = m.ReferencesCount
+	}
+
+	metadata := buildMetadata(doi, "crossref", map[string]string{
+		"title":     first(m.Title),
+		"author":    strings.Join(authors, ", "),
+		"journal":   first(m.ContainerTitle),
+		"publisher": m.Publisher,
+		"type":      m.Type,
+		"published": published,
+		"abstract":  jatsText(m.Abstract),
+		"url":       m.URL,
+	})
+	if references > 0 {
+		metadata["reference_count"] = strconv.Itoa(references)
+	}
+
+	var pdfLinks, htmlLinks []fullTextLink
+	for _, link := range m.Link {
+		contentType := strings.ToLower(link.ContentType)
+		switch {
+		case link.URL == "" || restrictedApplications[strings.ToLower(link.IntendedApplication)]:
+		case strings.Contains(contentType, "pdf"):
+			pdfLinks = append(pdfLinks, fullTextLink{URL: link.URL, PDF: true})
+		case strings.Contains(contentType, "html"):
+			htmlLinks = append(htmlLinks, fullTextLink{URL: link.URL})
+		}
+	}
+
+	return record{
+		Metadata: metadata,
+		Links:    append(pdfLinks, htmlLinks...),
+		Landing:  m.URL,
+	}
+}
+
+// dataciteRecord 转换DataCite的返回，数据集、预印本等多在DataCite登记
+func dataciteRecord(data dataciteDOI, doi string) record {
+	a := data.Data.Attributes
+
+	title := ""
+	if len(a.Titles) > 0 {
+		title = a.Titles[0].Title
+	}
+
+	authors := make([]string, 0, len(a.Creators))
+	for _, c := range a.Creators {
+		if name := strings.TrimSpace(c.GivenName + " " + c.FamilyName); name != "" {
+			authors = append(authors, name)
+		} else if c.Name != "" {
+			authors = append(authors, c.Name)
+		}
+	}
+
+	published := ""
+	for _, d := range a.Dates {
+		if d.DateType == "Issued" {
+			published = d.Date
+			break
+		}
+	}
+	if published == "" && a.PublicationYear != nil {
+		published = fmt.Sprint(a.PublicationYear)
+	}
+
+	abstract := ""
+	for _, d := range a.Descriptions {
+		if d.DescriptionType == "Abstract" {
+			abstract = d.Description
+			break
+		}
+	}
+
+	references := 0
+	for _, r := range a.RelatedIdentifiers {
+		if r.RelationType == "References" {
+			references++
+		}
+	}
+
+	metadata := buildMetadata(doi, "datacite", map[string]string{
+		"title":     title,
+		"author":    strings.Join(authors, ", "),
+		"journal":   a.Container.Title,
+		"publisher": a.Publisher,
+		"type":      a.Types.ResourceTypeGeneral,
+		"published": published,
+		"abstract":  jatsText(abstract),
+		"url":       a.URL,
+	})
+	if references > 0 {
+		metadata["reference_count"] = strconv.Itoa(references)
+	}
+
+	var links []fullTextLink
+	for _, u := range a.ContentURL {
+		if u != "" {
+			links = append(links, fullTextLink{URL: u, PDF: strings.HasSuffix(strings.ToLower(u), ".pdf")})
+		}
+	}
+
+	return record{
+		Metadata: metadata,
+		Links:    links,
+		Landing:  a.URL,
+	}
+}
+
+// buildMetadata 组装元数据，省略空值，键名与PDF、arXiv的元数据保持一致（title、author）
+func buildMetadata(doi, registry string, fields map[string]string) map[string]string {
+	metadata := map[string]string{
+		"doi":      doi,
+		"registry": registry,
+	}
+	for key, value := range fields {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
+
+// formatDateParts 把Crossref的 [[2017, 6, 12]] 转为 2017-06-12，缺少月、日时只保留已知部分
+func formatDateParts(date crossrefDate) string {
+	if len(date.DateParts) == 0 || len(date.DateParts[0]) == 0 || date.DateParts[0][0] == 0 {
+		return ""
+	}
+	parts := date.DateParts[0]
+	s := fmt.Sprintf("%04d", parts[0])
+	for _, part := range parts[1:] {
+		if part == 0 {
+			break
+		}
+		s += fmt.Sprintf("-%02d", part)
+	}
+	return s
+}
+
+// jatsText 去掉摘要中的JATS标签和开头的 "Abstract" 标题
+func jatsText(abstract string) string {
+	abstract = reJATSTitle.ReplaceAllString(abstract, "")
+	return strings.TrimSpace(html.UnescapeString(reXMLTag.ReplaceAllString(abstract, " ")))
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
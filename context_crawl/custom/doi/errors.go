@@ -0,0 +1,7 @@
+package doi
+
+// DOI处理的错误码，见types.Error
+const (
+	ErrCodeNotFound   = "doi_not_found"   // Crossref和DataCite都没有登记该DOI
+	ErrCodeNoFullText = "doi_no_fulltext" // 全文链接和落地页都抓取失败，且没有摘要
+)
@@ -0,0 +1,172 @@
+package doi
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"context_crawl/base/colly"
+	"context_crawl/custom/arxiv"
+	"context_crawl/custom/pdf"
+	"context_crawl/types"
+)
+
+// maxFullTextLinks 最多尝试的全文链接数，不含落地页
+const maxFullTextLinks = 3
+
+// abstractReserve 为只返回摘要预留的时间：元数据和全文链接最晚在请求截止时间前这么久结束
+const abstractReserve = time.Second
+
+// DOIPipeline 处理DOI：从Crossref或DataCite获取元数据，
+// 再通过已有的PDF、HTML pipeline抓取登记的全文链接，都失败时抓取落地页
+type DOIPipeline struct {
+	PDF     types.Pipeline   // PDF全文链接使用的pipeline
+	HTML    []types.Pipeline // HTML全文和落地页使用的pipeline，按顺序取第一个匹配的
+	Chunker types.Chunker    // 没有全文时对标题和摘要分块
+
+	crossrefURL string // Crossref API地址
+	dataciteURL string // DataCite API地址
+	resolverURL string // DOI解析地址，注册机构没有给出落地页时使用
+	httpClient  *http.Client
+}
+
+// NewDOIPipeline 创建一个新的DOIPipeline实例
+func NewDOIPipeline() *DOIPipeline {
+	return &DOIPipeline{
+		PDF: pdf.NewPDFPipeline(),
+		// arXiv的DOI（10.48550/arXiv.*）落地页交给arXiv pipeline，其余使用通用colly pipeline
+		HTML:        []types.Pipeline{arxiv.NewArxivPipeline(), colly.NewCollyPipeline()},
+		Chunker:     colly.NewSectionChunker(0.2),
+		crossrefURL: "https://api.crossref.org",
+		dataciteURL: "https://api.datacite.org",
+		resolverURL: "https://doi.org",
+		httpClient:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Process 执行DOI pipeline处理流程，实现types.Pipeline接口
+// metadata.fulltext_url 为实际抓取到全文的链接，format 为全文格式（pdf / html），只有摘要时为 abstract；
+// 各步骤共用input.Options.Deadline，元数据和全文链接为摘要预留abstractReserve
+func (p *DOIPipeline) Process(input types.Type) (types.Type, error) {
+	doi, ok := ParseDOI(input.Url)
+	if !ok {
+		return types.Type{}, fmt.Errorf("不是DOI: %s", input.Url)
+	}
+	work := input.Options.Reserve(abstractReserve)
+
+	// 注册机构不可用时仍然尝试通过doi.org抓取落地页
+	ctx, cancel := work.Context()
+	rec, found, err := p.fetchRecord(ctx, doi)
+	cancel()
+	if err != nil {
+		log.Printf("⚠️ 获取DOI元数据失败: %v, DOI: %s", err, doi)
+		rec = record{Metadata: map[string]string{"doi": doi}}
+	} else if !found {
+		return types.Type{}, types.NewError(ErrCodeNotFound, "Crossref和DataCite都没有登记DOI "+doi, true, nil)
+	}
+	if rec.Landing == "" {
+		rec.Landing = p.resolverURL + "/" + doi
+	}
+
+	links := rec.Links
+	if len(links) > maxFullTextLinks {
+		links = links[:maxFullTextLinks]
+	}
+	links = append(links, fullTextLink{URL: rec.Landing, PDF: pdf.IsPDFFile(rec.Landing)})
+
+	for _, link := range links {
+		if work.Expired() {
+			log.Printf("⚠️ DOI全文抓取超时，只返回摘要, DOI: %s", doi)
+			break
+		}
+		pipeline, format := p.choose(link)
+		doc, err := pipeline.Process(types.Type{Url: link.URL, Options: work})
+		if err != nil {
+			log.Printf("⚠️ DOI全文链接抓取失败: %v, URL: %s", err, link.URL)
+			continue
+		}
+		if isEmpty(doc) {
+			log.Printf("⚠️ DOI全文链接没有有效内容, URL: %s", link.URL)
+			continue
+		}
+		doc.Url = input.Url
+		doc.Options = input.Options
+		doc.Metadata = mergeMetadata(rec.Metadata, doc.Metadata, link.URL, format)
+		return doc, nil
+	}
+
+	return p.abstractOnly(input, rec.Metadata)
+}
+
+// Match 匹配方法，检查输入是否是DOI或doi.org链接
+func (p *DOIPipeline) Match(url string) bool {
+	return IsDOI(url)
+}
+
+// choose 为全文链接选择pipeline：登记为PDF或以.pdf结尾的使用PDF pipeline，其余取第一个匹配的HTML pipeline
+func (p *DOIPipeline) choose(link fullTextLink) (types.Pipeline, string) {
+	if link.PDF || pdf.IsPDFFile(link.URL) {
+		return p.PDF, "pdf"
+	}
+	for _, pipeline := range p.HTML {
+		if pipeline.Match(link.URL) {
+			return pipeline, "html"
+		}
+	}
+	return p.HTML[len(p.HTML)-1], "html"
+}
+
+// isEmpty 判断抓取结果是否没有有效内容，如落地页404时分块器只返回一条空结果提示
+func isEmpty(doc types.Type) bool {
+	if doc.Text == "" {
+		return true
+	}
+	for _, chunk := range doc.Chunks {
+		if chunk.Text != types.EmptyChunkText {
+			return false
+		}
+	}
+	return len(doc.Chunks) > 0
+}
+
+// abstractOnly 全文都抓取失败时，用标题和摘要作为正文返回
+func (p *DOIPipeline) abstractOnly(input types.Type, metadata map[string]string) (types.Type, error) {
+	abstract := metadata["abstract"]
+	if abstract == "" {
+		return types.Type{}, types.NewError(ErrCodeNoFullText, "DOI "+metadata["doi"]+" 的全文和落地页都抓取失败", false, nil)
+	}
+
+	var text strings.Builder
+	if title := metadata["title"]; title != "" {
+		text.WriteString("# " + title + "\n")
+	}
+	text.WriteString(abstract)
+
+	doc, err := p.Chunker.Chunk(types.Type{Url: input.Url, Text: text.String(), Options: input.Options})
+	if err != nil {
+		return types.Type{}, err
+	}
+	doc.Metadata = mergeMetadata(metadata, nil, "", "abstract")
+	return doc, nil
+}
+
+// mergeMetadata 合并元数据：以注册机构的为准，全文pipeline的元数据（如PDF页数、arXiv分类）补充缺少的键
+func mergeMetadata(registry, doc map[string]string, fullTextURL, format string) map[string]string {
+	merged := make(map[string]string, len(registry)+len(doc)+2)
+	for key, value := range doc {
+		merged[key] = value
+	}
+	for key, value := range registry {
+		merged[key] = value
+	}
+	if fullTextURL != "" {
+		merged["fulltext_url"] = fullTextURL
+	}
+	// arXiv pipeline已经标明了全文来源
+	if _, ok := doc["format"]; !ok {
+		merged["format"] = format
+	}
+	return merged
+}
@@ -0,0 +1,471 @@
+package doi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"context_crawl/types"
+)
+
+// resolver 测试中的DOI解析地址，注册机构没有给出落地页时抓取 resolver + "/" + DOI
+const resolver = "https://resolver.example.org"
+
+// abstract 足够长、能通过分块评分的摘要
+const abstract = "Deep learning allows computational models that are composed of multiple processing layers " +
+	"to learn representations of data with multiple levels of abstraction. These methods have dramatically " +
+	"improved the state of the art in speech recognition, visual object recognition and object detection."
+
+// fakeSite 代替PDF和HTML pipeline：按链接返回预设的页面，没有预设的链接返回错误，hang中的链接挂起到截止时间
+type fakeSite struct {
+	pages map[string]types.Type
+	hang  map[string]bool
+
+	mu        sync.Mutex
+	fetched   []string    // 按顺序抓取过的链接
+	deadlines []time.Time // 每次抓取收到的截止时间
+}
+
+func (f *fakeSite) Process(input types.Type) (types.Type, error) {
+	f.mu.Lock()
+	f.fetched = append(f.fetched, input.Url)
+	f.deadlines = append(f.deadlines, input.Options.Deadline)
+	f.mu.Unlock()
+
+	if f.hang[input.Url] {
+		ctx, cancel := input.Options.Context()
+		defer cancel()
+		<-ctx.Done()
+		return types.Type{}, ctx.Err()
+	}
+	page, ok := f.pages[input.Url]
+	if !ok {
+		return types.Type{}, errors.New("状态码: 404")
+	}
+	page.Url = input.Url
+	return page, nil
+}
+
+func (f *fakeSite) Match(string) bool { return true }
+
+// page 只有一个分块的抓取结果
+func page(text string) types.Type {
+	return types.Type{Text: text, Chunks: []types.Chunk{{Text: text}}}
+}
+
+// registry 本地的Crossref（/works/）和DataCite（/dois/）接口：返回体为空时返回404，
+// status非0时Crossref返回该状态码，delay为每次请求的延迟
+type registry struct {
+	crossref string
+	datacite string
+	status   int
+	delay    time.Duration
+}
+
+func (r registry) serve() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-time.After(r.delay):
+		case <-req.Context().Done():
+			return
+		}
+		body := r.datacite
+		if strings.HasPrefix(req.URL.Path, "/works/") {
+			if r.status != 0 {
+				w.WriteHeader(r.status)
+				return
+			}
+			body = r.crossref
+		}
+		if body == "" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+// crossrefJSON Crossref的返回，落地页为 https://pub.example.com/landing
+func crossrefJSON(abstract string, links ...crossrefLink) string {
+	var work crossrefWork
+	work.Message.Title = []string{"Deep learning"}
+	work.Message.Abstract = "<jats:p>" + abstract + "</jats:p>"
+	work.Message.URL = "https://pub.example.com/landing"
+	work.Message.Link = links
+	data, _ := json.Marshal(work)
+	return string(data)
+}
+
+// dataciteJSON DataCite的返回，落地页为 https://repo.example.com/record
+func dataciteJSON(contentURL ...string) string {
+	var doi dataciteDOI
+	a := &doi.Data.Attributes
+	a.Titles = append(a.Titles, struct {
+		Title string `json:"title"`
+	}{"Measurements of ocean temperature"})
+	a.ContentURL = contentURL
+	a.URL = "https://repo.example.com/record"
+	data, _ := json.Marshal(doi)
+	return string(data)
+}
+
+// testPipeline 使用本地注册机构接口的DOIPipeline，全文和落地页都交给site
+func testPipeline(server *httptest.Server, site *fakeSite) *DOIPipeline {
+	p := NewDOIPipeline()
+	p.PDF, p.HTML = site, []types.Pipeline{site}
+	p.crossrefURL, p.dataciteURL, p.resolverURL = server.URL, server.URL, resolver
+	return p
+}
+
+func TestProcess(t *testing.T) {
+	pdfLink := crossrefLink{URL: "https://pub.example.com/a.pdf", ContentType: "application/pdf"}
+	htmlLink := crossrefLink{URL: "https://pub.example.com/a.html", ContentType: "text/html"}
+	tests := []struct {
+		name         string
+		registry     registry
+		pages        map[string]types.Type
+		wantFetched  []string
+		wantRegistry string // 为空时元数据不应带registry
+		wantURL      string // metadata.fulltext_url，只有摘要时为空
+		wantFormat   string
+		wantCode     string // 非空时期望返回该错误码
+	}{
+		{
+			name:         "crossref pdf",
+			registry:     registry{crossref: crossrefJSON(abstract, htmlLink, pdfLink)},
+			pages:        map[string]types.Type{pdfLink.URL: page("full text")},
+			wantFetched:  []string{pdfLink.URL},
+			wantRegistry: "crossref",
+			wantURL:      pdfLink.URL,
+			wantFormat:   "pdf",
+		},
+		{
+			name:         "failed pdf falls back to html",
+			registry:     registry{crossref: crossrefJSON(abstract, pdfLink, htmlLink)},
+			pages:        map[string]types.Type{htmlLink.URL: page("full text")},
+			wantFetched:  []string{pdfLink.URL, htmlLink.URL},
+			wantRegistry: "crossref",
+			wantURL:      htmlLink.URL,
+			wantFormat:   "html",
+		},
+		{
+			name: "text mining links skipped",
+			registry: registry{crossref: crossrefJSON(abstract,
+				crossrefLink{URL: "https://api.pub.example.com/tdm.pdf", ContentType: "application/pdf", IntendedApplication: "text-mining"},
+				crossrefLink{URL: "https://check.example.com/a.pdf", ContentType: "application/pdf", IntendedApplication: "similarity-checking"},
+				htmlLink,
+			)},
+			// 授权链接即使能抓到也不应尝试
+			pages: map[string]types.Type{
+				"https://api.pub.example.com/tdm.pdf": page("licensed text"),
+				"https://check.example.com/a.pdf":     page("licensed text"),
+				htmlLink.URL:                          page("full text"),
+			},
+			wantFetched:  []string{htmlLink.URL},
+			wantRegistry: "crossref",
+			wantURL:      htmlLink.URL,
+			wantFormat:   "html",
+		},
+		{
+			name: "at most three registered links before the landing page",
+			registry: registry{crossref: crossrefJSON(abstract,
+				crossrefLink{URL: "https://pub.example.com/1.pdf", ContentType: "application/pdf"},
+				crossrefLink{URL: "https://pub.example.com/2.pdf", ContentType: "application/pdf"},
+				crossrefLink{URL: "https://pub.example.com/3.pdf", ContentType: "application/pdf"},
+				crossrefLink{URL: "https://pub.example.com/4.pdf", ContentType: "application/pdf"},
+			)},
+			pages: map[string]types.Type{"https://pub.example.com/landing": page("landing text")},
+			wantFetched: []string{
+				"https://pub.example.com/1.pdf", "https://pub.example.com/2.pdf", "https://pub.example.com/3.pdf",
+				"https://pub.example.com/landing",
+			},
+			wantRegistry: "crossref",
+			wantURL:      "https://pub.example.com/landing",
+			wantFormat:   "html",
+		},
+		{
+			name:         "datacite when crossref has no record",
+			registry:     registry{datacite: dataciteJSON("https://repo.example.com/files/data.pdf")},
+			pages:        map[string]types.Type{"https://repo.example.com/record": page("record page")},
+			wantFetched:  []string{"https://repo.example.com/files/data.pdf", "https://repo.example.com/record"},
+			wantRegistry: "datacite",
+			wantURL:      "https://repo.example.com/record",
+			wantFormat:   "html",
+		},
+		{
+			name:     "registered nowhere",
+			registry: registry{},
+			wantCode: ErrCodeNotFound,
+		},
+		{
+			name:        "registry unavailable falls back to the resolver",
+			registry:    registry{status: http.StatusInternalServerError},
+			pages:       map[string]types.Type{resolver + "/10.1000/x": page("landing text")},
+			wantFetched: []string{resolver + "/10.1000/x"},
+			wantURL:     resolver + "/10.1000/x",
+			wantFormat:  "html",
+		},
+		{
+			name:     "empty pages fall back to the abstract",
+			registry: registry{crossref: crossrefJSON(abstract, pdfLink)},
+			pages: map[string]types.Type{
+				pdfLink.URL:                       page(types.EmptyChunkText),
+				"https://pub.example.com/landing": {},
+			},
+			wantFetched:  []string{pdfLink.URL, "https://pub.example.com/landing"},
+			wantRegistry: "crossref",
+			wantFormat:   "abstract",
+		},
+		{
+			name:        "no full text and no abstract",
+			registry:    registry{crossref: crossrefJSON("", pdfLink)},
+			wantFetched: []string{pdfLink.URL, "https://pub.example.com/landing"},
+			wantCode:    ErrCodeNoFullText,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.registry.serve()
+			defer server.Close()
+			site := &fakeSite{pages: tt.pages}
+			p := testPipeline(server, site)
+
+			doc, err := p.Process(types.Type{Url: "https://doi.org/10.1000/x"})
+			if !reflect.DeepEqual(site.fetched, tt.wantFetched) {
+				t.Errorf("fetched %v, want %v", site.fetched, tt.wantFetched)
+			}
+			if tt.wantCode != "" {
+				typed, ok := types.AsError(err)
+				if !ok || typed.Code != tt.wantCode {
+					t.Fatalf("Process() error = %v, want code %s", err, tt.wantCode)
+				}
+				// 没有登记的DOI换其他pipeline也抓不到
+				if typed.NoFallback != (tt.wantCode == ErrCodeNotFound) {
+					t.Errorf("NoFallback = %v for %s", typed.NoFallback, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			if doc.Url != "https://doi.org/10.1000/x" {
+				t.Errorf("Url = %q, want the requested DOI", doc.Url)
+			}
+			if doc.Metadata["doi"] != "10.1000/x" || doc.Metadata["registry"] != tt.wantRegistry {
+				t.Errorf("doi = %q, registry = %q, want 10.1000/x and %q", doc.Metadata["doi"], doc.Metadata["registry"], tt.wantRegistry)
+			}
+			if doc.Metadata["fulltext_url"] != tt.wantURL || doc.Metadata["format"] != tt.wantFormat {
+				t.Errorf("fulltext_url = %q, format = %q, want %q and %q",
+					doc.Metadata["fulltext_url"], doc.Metadata["format"], tt.wantURL, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestAbstractOnly(t *testing.T) {
+	server := registry{crossref: crossrefJSON(abstract)}.serve()
+	defer server.Close()
+	p := testPipeline(server, &fakeSite{})
+
+	doc, err := p.Process(types.Type{Url: "10.1000/x"})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if len(doc.Chunks) != 1 {
+		t.Fatalf("got %d chunks, want 1: %+v", len(doc.Chunks), doc.Chunks)
+	}
+	chunk := doc.Chunks[0]
+	// 标题作为分块的标题路径，JATS标签已去掉
+	if !reflect.DeepEqual(chunk.HeadingPath, []string{"Deep learning"}) {
+		t.Errorf("HeadingPath = %q, want [Deep learning]", chunk.HeadingPath)
+	}
+	if chunk.Text != abstract {
+		t.Errorf("chunk text = %q, want the abstract", chunk.Text)
+	}
+	if doc.Metadata["abstract"] != abstract || doc.Metadata["title"] != "Deep learning" {
+		t.Errorf("metadata = %v, want title and abstract", doc.Metadata)
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  types.Type
+		want bool
+	}{
+		{"no text", types.Type{Chunks: []types.Chunk{{Text: "stale"}}}, true},
+		{"only the empty result notice", page(types.EmptyChunkText), true},
+		{"notice and content", types.Type{Text: "x", Chunks: []types.Chunk{{Text: types.EmptyChunkText}, {Text: "content"}}}, false},
+		{"text without chunks", types.Type{Text: "raw text"}, false},
+		{"content", page("content"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEmpty(tt.doc); got != tt.want {
+				t.Errorf("isEmpty() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessDeadline(t *testing.T) {
+	pdfLink := crossrefLink{URL: "https://pub.example.com/a.pdf", ContentType: "application/pdf"}
+	budget := abstractReserve + 300*time.Millisecond
+	tests := []struct {
+		name        string
+		registry    registry
+		hang        map[string]bool
+		wantFetched []string
+		wantFormat  string // 为空时期望 doi_no_fulltext
+	}{
+		{
+			// 挂起的PDF用完全文的时间后不再抓取落地页，摘要仍能返回
+			name:        "hanging full text",
+			registry:    registry{crossref: crossrefJSON(abstract, pdfLink)},
+			hang:        map[string]bool{pdfLink.URL: true},
+			wantFetched: []string{pdfLink.URL},
+			wantFormat:  "abstract",
+		},
+		{
+			// 元数据超时后没有摘要，也没有时间抓取落地页
+			name:     "hanging registry",
+			registry: registry{crossref: crossrefJSON(abstract, pdfLink), delay: time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.registry.serve()
+			defer server.Close()
+			site := &fakeSite{hang: tt.hang}
+			p := testPipeline(server, site)
+
+			options := types.Options{Deadline: time.Now().Add(budget)}
+			start := time.Now()
+			doc, err := p.Process(types.Type{Url: "10.1000/x", Options: options})
+			if elapsed := time.Since(start); elapsed > budget {
+				t.Errorf("Process() took %v, budget %v", elapsed, budget)
+			}
+			if !reflect.DeepEqual(site.fetched, tt.wantFetched) {
+				t.Errorf("fetched %v, want %v", site.fetched, tt.wantFetched)
+			}
+			// 全文链接在摘要预留时间之前结束
+			for _, deadline := range site.deadlines {
+				if want := options.Deadline.Add(-abstractReserve); !deadline.Equal(want) {
+					t.Errorf("full text deadline = %v, want %v", deadline, want)
+				}
+			}
+			if tt.wantFormat == "" {
+				if typed, ok := types.AsError(err); !ok || typed.Code != ErrCodeNoFullText {
+					t.Fatalf("Process() error = %v, want code %s", err, ErrCodeNoFullText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if doc.Metadata["format"] != tt.wantFormat {
+				t.Errorf("format = %q, want %q", doc.Metadata["format"], tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestCrossrefRecordLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		links []crossrefLink
+		want  []fullTextLink
+	}{
+		{
+			name: "pdf before html",
+			links: []crossrefLink{
+				{URL: "https://example.com/a.html", ContentType: "text/html"},
+				{URL: "https://example.com/a.pdf", ContentType: "application/pdf"},
+			},
+			want: []fullTextLink{{URL: "https://example.com/a.pdf", PDF: true}, {URL: "https://example.com/a.html"}},
+		},
+		{
+			name: "text mining and similarity checking skipped",
+			links: []crossrefLink{
+				{URL: "https://api.example.com/tdm.pdf", ContentType: "application/pdf", IntendedApplication: "text-mining"},
+				{URL: "https://api.example.com/tdm.xml", ContentType: "text/xml", IntendedApplication: "text-mining"},
+				{URL: "https://check.example.com/a.pdf", ContentType: "application/pdf", IntendedApplication: "similarity-checking"},
+				{URL: "https://example.com/a.pdf", ContentType: "application/pdf", IntendedApplication: "syndication"},
+				{URL: "https://example.com/b.html", ContentType: "text/html", IntendedApplication: "unspecified"},
+			},
+			want: []fullTextLink{{URL: "https://example.com/a.pdf", PDF: true}, {URL: "https://example.com/b.html"}},
+		},
+		{
+			name:  "only restricted links",
+			links: []crossrefLink{{URL: "https://api.example.com/tdm.pdf", ContentType: "application/pdf", IntendedApplication: "Text-Mining"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var work crossrefWork
+			work.Message.Link = tt.links
+			if got := crossrefRecord(work, "10.1000/x").Links; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("crossrefRecord() links = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	tests := []struct {
+		mailto string
+		want   string
+	}{
+		{mailto: "", want: baseUserAgent},
+		{mailto: " ops@example.com ", want: baseUserAgent + " (mailto:ops@example.com)"},
+	}
+	defer SetMailto("")
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("User-Agent")
+				http.NotFound(w, r)
+			}))
+			defer server.Close()
+
+			SetMailto(tt.mailto)
+			p := testPipeline(server, &fakeSite{})
+			if _, err := p.Process(types.Type{Url: "10.1000/missing"}); err == nil {
+				t.Fatal("Process() error = nil, want doi_not_found")
+			}
+			if got != tt.want {
+				t.Errorf("User-Agent = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDOI(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"10.1038/nature14539", "10.1038/nature14539", true},
+		{"doi:10.1038/nature14539.", "10.1038/nature14539", true},
+		{"https://doi.org/10.1000/a%2Fb", "10.1000/a/b", true},
+		{"dx.doi.org/10.48550/arXiv.1706.03762", "10.48550/arXiv.1706.03762", true},
+		{"https://www.nature.com/articles/10.1038/nature14539", "", false},
+		{"10.12/short", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDOI(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseDOI(%q) = %q, %v, want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package doi
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// reDOI DOI的格式：10.<注册者编号>/<后缀>
+var reDOI = regexp.MustCompile(`^10\.\d{4,9}/\S+$`)
+
+// IsDOI 判断输入是否为DOI
+func IsDOI(input string) bool {
+	_, ok := ParseDOI(input)
+	return ok
+}
+
+// ParseDOI 从输入中解析DOI，支持以下形式：
+// 10.1000/xyz123、doi:10.1000/xyz123、https://doi.org/10.1000/xyz123、http://dx.doi.org/10.1000/xyz123
+// 出版商页面中带DOI的链接不算，交给通用pipeline处理
+func ParseDOI(input string) (string, bool) {
+	s := strings.TrimSpace(input)
+	lower := strings.ToLower(s)
+
+	switch {
+	case strings.HasPrefix(lower, "doi:"):
+		s = strings.TrimSpace(s[len("doi:"):])
+	case strings.HasPrefix(lower, "10."):
+	default:
+		if !strings.Contains(lower, "://") {
+			s = "https://" + s
+		}
+		u, err := url.Parse(s)
+		if err != nil {
+			return "", false
+		}
+		host := strings.ToLower(u.Hostname())
+		if host != "doi.org" && !strings.HasSuffix(host, ".doi.org") {
+			return "", false
+		}
+		s = strings.TrimPrefix(u.Path, "/") // Path已经解码了 %2F 等转义
+	}
+
+	// 从文本中复制的DOI末尾常带有标点
+	s = strings.TrimRight(s, ".,;")
+	if !reDOI.MatchString(s) {
+		return "", false
+	}
+	return s, true
+}
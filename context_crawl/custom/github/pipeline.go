@@ -2,6 +2,8 @@ package github
 
 import (
 	"context_crawl/base/colly"
+	"context_crawl/base/normalize"
+	"context_crawl/custom/md"
 	"context_crawl/types"
 	"encoding/json"
 	"fmt"
@@ -13,7 +15,11 @@ import (
 )
 
 // GitHubPipeline 用于处理GitHub仓库的爬虫管道
+// API返回的issue、讨论正文和搜索结果都是Markdown，按Markdown清洗、规范化后分块
 type GitHubPipeline struct {
+	Cleaner types.Cleaner // API结果的清洗组件
+	Chunker types.Chunker // API结果的分块组件
+
 	apiToken   string
 	httpClient *http.Client
 	baseURL    string
@@ -22,6 +28,8 @@ type GitHubPipeline struct {
 // NewGitHubPipeline 创建一个新的GitHubPipeline实例
 func NewGitHubPipeline(apiToken string) *GitHubPipeline {
 	return &GitHubPipeline{
+		Cleaner:  normalize.NewCleaner(md.NewMarkdownCleaner(), normalize.DefaultOptions()),
+		Chunker:  colly.NewSectionChunker(0.2),
 		apiToken: apiToken,
 		baseURL:  "https://api.github.com",
 		httpClient: &http.Client{
@@ -49,10 +57,7 @@ func (p *GitHubPipeline) Process(input types.Type) (types.Type, error) {
 			}, nil
 		}
 
-		return types.Type{
-			Url:  input.Url,
-			Text: results,
-		}, nil
+		return p.finish(input, results)
 	}
 
 	// 检查是否是issue页面
@@ -66,10 +71,7 @@ func (p *GitHubPipeline) Process(input types.Type) (types.Type, error) {
 			}, nil
 		}
 
-		return types.Type{
-			Url:  input.Url,
-			Text: results,
-		}, nil
+		return p.finish(input, results)
 	}
 
 	// 检查是否是其他GitHub讨论页面
@@ -83,16 +85,26 @@ func (p *GitHubPipeline) Process(input types.Type) (types.Type, error) {
 			}, nil
 		}
 
-		return types.Type{
-			Url:  input.Url,
-			Text: results,
-		}, nil
+		return p.finish(input, results)
 	}
 
 	// 回退到普通爬取
 	return p.fallbackToCollyCrawl(input)
 }
 
+// finish 清洗、规范化API返回的Markdown文本并分块，代码块由Markdown清洗器保留
+func (p *GitHubPipeline) finish(input types.Type, text string) (types.Type, error) {
+	cleaned, err := p.Cleaner.Clean(types.Type{
+		Url:     input.Url,
+		Text:    text,
+		Options: input.Options,
+	})
+	if err != nil {
+		return types.Type{}, err
+	}
+	return p.Chunker.Chunk(cleaned)
+}
+
 // fallbackToCollyCrawl 回退到普通的colly爬取
 func (p *GitHubPipeline) fallbackToCollyCrawl(input types.Type) (types.Type, error) {
 	// 创建colly pipeline实例
@@ -0,0 +1,77 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestProcessIssueNormalized(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string // 正文分块中应出现的片段
+		notWant []string // 正文分块中不应出现的片段
+		code    string   // 代码分块应原样保留
+	}{
+		{
+			name:    "invisible characters and fullwidth ascii",
+			body:    "ＧＰＵ  显存​不足，升级到 v２ 后解决­。",
+			want:    []string{"GPU 显存不足，升级到 v2 后解决。"},
+			notWant: []string{"​", " ", "­", "Ｇ"},
+		},
+		{
+			name: "code block kept verbatim",
+			body: "复现步骤：\n\n```go\nfunc main() {\n    x :=  1 // ＮＢＳＰ\n}\n```\n",
+			want: []string{"复现步骤："},
+			code: "func main() {\n    x :=  1 // ＮＢＳＰ\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/octo/demo/issues/7" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				body := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(tt.body)
+				w.Write([]byte(`{"title": "显存问题", "state": "open", "user": {"login": "octo"}, "body": "` + body + `"}`))
+			}))
+			defer server.Close()
+
+			p := NewGitHubPipeline("")
+			p.baseURL = server.URL
+			result, err := p.Process(types.Type{Url: "https://github.com/octo/demo/issues/7"})
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			var text, code strings.Builder
+			for _, chunk := range result.Chunks {
+				if chunk.IsCode {
+					code.WriteString(chunk.Text)
+				} else {
+					text.WriteString(chunk.Text)
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text.String(), want) {
+					t.Errorf("text = %q, want it to contain %q", text.String(), want)
+				}
+			}
+			for _, bad := range tt.notWant {
+				if strings.Contains(text.String(), bad) {
+					t.Errorf("text = %q, should not contain %q", text.String(), bad)
+				}
+			}
+			if tt.code != "" && !strings.Contains(code.String(), tt.code) {
+				t.Errorf("code = %q, want it to contain %q", code.String(), tt.code)
+			}
+		})
+	}
+}
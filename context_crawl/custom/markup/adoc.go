@@ -0,0 +1,552 @@
+package markup
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reAdocSection   = regexp.MustCompile(`^(={1,6}|#{1,6})\s+(.+?)(?:\s+=+)?$`)
+	reAdocAttrEntry = regexp.MustCompile(`^:(!?[\w][\w-]*!?):(?:\s+(.*))?$`)
+	reAdocBlockAttr = regexp.MustCompile(`^\[(.*)\]$`)
+	reAdocAnchor    = regexp.MustCompile(`^\[\[[^\]]*\]\]$`)
+	reAdocTitle     = regexp.MustCompile(`^\.([^.\s].*)$`)
+	reAdocDelimiter = regexp.MustCompile(`^(-{4,}|\.{4,}|={4,}|\*{4,}|_{4,}|\+{4,}|/{4,}|--|[|,:!]={3,})$`)
+	reAdocImage     = regexp.MustCompile(`^image::([^\[]+)\[(.*)\]$`)
+	reAdocMacroLine = regexp.MustCompile(`^(?:ifdef|ifndef|ifeval|endif|include|toc|video|audio)::`)
+	reAdocListItem  = regexp.MustCompile(`^(\*{1,5}|-|\.{1,5}|\d+\.)\s+(.*)$`)
+	reAdocDescItem  = regexp.MustCompile(`^(.+?)(:{2,4}|;;)(?:\s+(.*))?$`)
+	reAdocAdmon     = regexp.MustCompile(`^(NOTE|TIP|IMPORTANT|WARNING|CAUTION):\s+`)
+	reAdocColsCount = regexp.MustCompile(`^(\d+)\*`)
+	reAdocCellSpec  = regexp.MustCompile(`\s(?:\d+(?:\.\d+)?[+*][<^>]?(?:\.[<^>])?[adehlmsv]?|[<^>]?\.?[<^>]?[adehlmsv])$`)
+
+	reAdocPass      = regexp.MustCompile(`pass:[a-z,]*\[([^\]]*)\]|\+\+\+(.+?)\+\+\+`)
+	reAdocLiteral   = regexp.MustCompile("`\\+(.+?)\\+`|``(.+?)``|`([^`]+)`")
+	reAdocURLMacro  = regexp.MustCompile(`(?:link:)?((?:https?|ftp|file|mailto|irc):[^\s\[]*|link:[^\s\[]+)\[([^\]]*)\]`)
+	reAdocXref      = regexp.MustCompile(`<<([^,>]+)(?:,\s*([^>]+))?>>|xref:([^\s\[]+)\[([^\]]*)\]`)
+	reAdocInlineImg = regexp.MustCompile(`image:([^\s\[:][^\s\[]*)\[([^\]]*)\]`)
+	reAdocUIMacro   = regexp.MustCompile(`(?:kbd|btn):\[([^\]]*)\]|menu:([^\s\[]+)\[([^\]]*)\]`)
+	reAdocFootnote  = regexp.MustCompile(`footnote(?:ref)?:[\w-]*\[([^\]]*)\]`)
+	reAdocAttrRef   = regexp.MustCompile(`\{([\w][\w-]*)\}`)
+	reAdocMark      = regexp.MustCompile(`(^|[\s(])(?:\[[^\]]*\])?#([^#\s](?:[^#]*[^#\s])?)#($|[\s).,;:!?])`)
+	reAdocInlineID  = regexp.MustCompile(`\[\[[\w:.-]+(?:,[^\]]*)?\]\]|\[#[\w:.-]+\]`)
+
+	// 非受限的 **粗体**、__斜体__ 可以出现在词中；受限的 *粗体*、_斜体_ 两侧不能是字母数字
+	reAdocStrongU   = regexp.MustCompile(`()\*\*(\S(?:.*?\S)?)\*\*()`)
+	reAdocEmphasisU = regexp.MustCompile(`()__(\S(?:.*?\S)?)__()`)
+	reAdocStrong    = regexp.MustCompile(`(^|[\s(\[{"'])\*([^\s*](?:[^*]*?[^\s*])?)\*($|[\s).,;:!?\]}"'])`)
+	reAdocEmphasis  = regexp.MustCompile(`(^|[\s(\[{"'])_([^\s_](?:[^_]*?[^\s_])?)_($|[\s).,;:!?\]}"'])`)
+)
+
+// adocBuiltinAttrs AsciiDoc内置的字符替换属性
+var adocBuiltinAttrs = map[string]string{
+	"nbsp": " ", "sp": " ", "empty": "", "zwsp": "", "plus": "+", "amp": "&", "lt": "<", "gt": ">",
+	"startsb": "[", "endsb": "]", "vbar": "|", "caret": "^", "asterisk": "*", "tilde": "~",
+	"backtick": "`", "apos": "'", "quot": `"`, "deg": "°", "brvbar": "¦", "two-colons": "::", "two-semicolons": ";;",
+}
+
+// adocMetaAttrs 放入元数据的文档属性，值为元数据中的键名
+var adocMetaAttrs = map[string]string{
+	"author": "author", "authors": "author", "email": "email", "revnumber": "version", "revdate": "date",
+	"revremark": "remark", "description": "description", "keywords": "keywords", "lang": "lang",
+}
+
+// adocAdmonitions 提示类区块的标题
+var adocAdmonitions = map[string]string{
+	"NOTE": "Note", "TIP": "Tip", "IMPORTANT": "Important", "WARNING": "Warning", "CAUTION": "Caution",
+}
+
+// adocConverter 把AsciiDoc转换为Markdown
+type adocConverter struct {
+	b     *builder
+	attrs map[string]string // 文档属性，用于替换 {name}
+
+	// 作用于下一个区块的属性列表和标题
+	style string
+	args  []string
+	named map[string]string
+	title string
+}
+
+// adocToMarkdown 把AsciiDoc文档转换为Markdown
+func adocToMarkdown(text string) string {
+	c := &adocConverter{b: newBuilder(), attrs: make(map[string]string)}
+	lines := strings.Split(text, "\n")
+	c.convert(lines[c.header(lines):])
+	return c.b.String()
+}
+
+// header 解析文档头：= 标题、作者行、修订行和属性，返回正文开始的位置
+func (c *adocConverter) header(lines []string) int {
+	i := 0
+	for i < len(lines) && (isBlank(lines[i]) || isAdocComment(lines[i])) {
+		i++
+	}
+	if i >= len(lines) || !strings.HasPrefix(lines[i], "= ") {
+		return 0
+	}
+	title := strings.TrimSpace(strings.TrimPrefix(lines[i], "= "))
+	c.b.meta["title"] = c.plain(title)
+	c.b.heading(1, c.inline(title))
+
+	texts := 0
+	for i++; i < len(lines) && !isBlank(lines[i]); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		switch {
+		case isAdocComment(line):
+		case reAdocAttrEntry.MatchString(line):
+			c.attribute(line)
+		case texts == 0:
+			// 作者行：多个作者以 ; 分隔，去掉邮箱
+			var authors []string
+			for _, author := range strings.Split(line, ";") {
+				if k := strings.Index(author, "<"); k >= 0 {
+					author = author[:k]
+				}
+				if author = strings.TrimSpace(author); author != "" {
+					authors = append(authors, author)
+				}
+			}
+			c.b.meta["author"] = strings.Join(authors, ", ")
+			texts++
+		case texts == 1:
+			// 修订行：v1.0, 2024-01-01: 说明
+			rev, remark, _ := strings.Cut(line, ":")
+			version, date, found := strings.Cut(rev, ",")
+			if !found && !strings.HasPrefix(strings.TrimSpace(version), "v") {
+				version, date = "", version
+			}
+			c.setMeta("version", strings.TrimPrefix(strings.TrimSpace(version), "v"))
+			c.setMeta("date", date)
+			c.setMeta("remark", remark)
+			texts++
+		}
+	}
+	return i
+}
+
+// attribute 处理属性定义 :name: value，:name!: 取消定义
+func (c *adocConverter) attribute(line string) {
+	m := reAdocAttrEntry.FindStringSubmatch(line)
+	name, value := m[1], strings.TrimSpace(m[2])
+	if strings.HasPrefix(name, "!") || strings.HasSuffix(name, "!") {
+		delete(c.attrs, strings.Trim(name, "!"))
+		return
+	}
+	c.attrs[name] = value
+	if key, ok := adocMetaAttrs[name]; ok {
+		c.setMeta(key, c.plain(value))
+	}
+}
+
+func (c *adocConverter) setMeta(key, value string) {
+	if value = strings.Join(strings.Fields(value), " "); value != "" {
+		c.b.meta[key] = value
+	}
+}
+
+// convert 转换正文
+func (c *adocConverter) convert(lines []string) {
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], " \t")
+		switch {
+		case isBlank(line):
+			i++
+		case reAdocDelimiter.MatchString(line):
+			i = c.delimited(lines, i)
+		case isAdocComment(line):
+			i++
+		case reAdocAttrEntry.MatchString(line):
+			c.attribute(line)
+			i++
+		case reAdocMacroLine.MatchString(line), reAdocAnchor.MatchString(line), line == "+":
+			i++
+		case reAdocBlockAttr.MatchString(line):
+			c.blockAttributes(reAdocBlockAttr.FindStringSubmatch(line)[1])
+			i++
+		case reAdocTitle.MatchString(line):
+			c.title = reAdocTitle.FindStringSubmatch(line)[1]
+			i++
+		case reAdocSection.MatchString(line):
+			m := reAdocSection.FindStringSubmatch(line)
+			c.b.heading(len(m[1]), c.inline(m[2]))
+			c.reset()
+			i++
+		case reAdocImage.MatchString(line):
+			m := reAdocImage.FindStringSubmatch(line)
+			c.emitTitle()
+			c.b.paragraph(c.image(m[1], m[2]))
+			c.reset()
+			i++
+		case reAdocListItem.MatchString(line):
+			i = c.listItem(lines, i)
+		case reAdocDescItem.MatchString(line) && !strings.Contains(line, "://"):
+			i = c.descItem(lines, i)
+		case indentOf(line) > 0:
+			// 缩进的字面段落
+			end := i
+			for end < len(lines) && !isBlank(lines[end]) {
+				end++
+			}
+			c.emitTitle()
+			c.b.code(c.sourceLang(), strings.Join(dedent(lines[i:end]), "\n"))
+			c.reset()
+			i = end
+		default:
+			i = c.paragraph(lines, i)
+		}
+	}
+}
+
+// blockAttributes 解析区块属性列表，如 [source,go]、[NOTE]、[cols="1,2",options="header"]
+func (c *adocConverter) blockAttributes(list string) {
+	c.style, c.args, c.named = "", nil, make(map[string]string)
+	for k, part := range splitAttrList(list) {
+		if name, value, ok := strings.Cut(part, "="); ok && reAdocAttrName.MatchString(name) {
+			c.named[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(value), `"'`)
+			continue
+		}
+		part = strings.Trim(strings.TrimSpace(part), `"'`)
+		if k == 0 {
+			// [source%linenums#id.role] 只保留样式名
+			c.style = part
+			if j := strings.IndexAny(part, "%#."); j >= 0 {
+				c.style = part[:j]
+			}
+			continue
+		}
+		c.args = append(c.args, part)
+	}
+}
+
+var reAdocAttrName = regexp.MustCompile(`^\s*[\w-]+\s*$`)
+
+// splitAttrList 按逗号切分属性列表，引号内的逗号不切分
+func splitAttrList(list string) []string {
+	var parts []string
+	var current strings.Builder
+	quote := rune(0)
+	for _, r := range list {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if list != "" {
+		parts = append(parts, current.String())
+	}
+	if len(parts) == 0 {
+		parts = []string{""}
+	}
+	return parts
+}
+
+// reset 清除作用于下一个区块的属性
+func (c *adocConverter) reset() {
+	c.style, c.args, c.named, c.title = "", nil, nil, ""
+}
+
+// emitTitle 区块标题作为段落输出
+func (c *adocConverter) emitTitle() {
+	if c.title != "" {
+		c.b.paragraph(c.inline(c.title))
+	}
+}
+
+// sourceLang 返回 [source,lang] 指定的语言，未指定时使用 source-language 属性
+func (c *adocConverter) sourceLang() string {
+	if c.style != "source" && c.style != "listing" {
+		return ""
+	}
+	if len(c.args) > 0 && c.args[0] != "" {
+		return c.args[0]
+	}
+	if lang := c.named["language"]; lang != "" {
+		return lang
+	}
+	return c.attrs["source-language"]
+}
+
+// delimited 处理分隔的区块，返回区块结束后的位置
+func (c *adocConverter) delimited(lines []string, i int) int {
+	delim := strings.TrimRight(lines[i], " \t")
+	end := i + 1
+	for end < len(lines) && strings.TrimRight(lines[end], " \t") != delim {
+		end++
+	}
+	content := lines[i+1 : min(end, len(lines))]
+	next := min(end+1, len(lines))
+
+	style := c.style
+	if delim[0] == '/' {
+		// 注释块
+		c.reset()
+		return next
+	}
+	c.emitTitle()
+	switch {
+	case delim[0] == '-' && len(delim) >= 4:
+		c.b.code(c.sourceLang(), strings.Join(content, "\n"))
+	case delim[0] == '.':
+		c.b.code("", strings.Join(content, "\n"))
+	case delim[0] == '+':
+		// 直通块中是原始HTML，只保留公式
+		if style == "stem" || style == "latexmath" || style == "asciimath" {
+			c.b.code("math", strings.Join(content, "\n"))
+		}
+	case delim[0] != '=' && strings.HasSuffix(delim, "==="):
+		c.table(delim[0], content)
+	default:
+		// 示例、侧栏、引用、开放块：提示类样式加标题，内容递归处理
+		if title, ok := adocAdmonitions[style]; ok {
+			c.b.paragraph(title + ":")
+		}
+		if style == "source" || style == "listing" || style == "literal" {
+			c.b.code(c.sourceLang(), strings.Join(content, "\n"))
+			break
+		}
+		c.reset()
+		c.convert(content)
+	}
+	c.reset()
+	return next
+}
+
+// table 处理表格：|=== 以 | 分隔单元格，,=== 和 :=== 以逗号、冒号分隔，
+// 列数取自 cols 属性，没有时取第一行的单元格数
+func (c *adocConverter) table(sep byte, content []string) {
+	columns := 0
+	if cols := c.named["cols"]; cols != "" {
+		if m := reAdocColsCount.FindStringSubmatch(cols); m != nil {
+			columns, _ = strconv.Atoi(m[1])
+		} else {
+			columns = len(strings.Split(cols, ","))
+		}
+	}
+	// 嵌套表格 !=== 以 ! 分隔单元格
+	cellSep := string(sep)
+
+	var cells []string
+	for _, line := range content {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if sep == ',' || sep == ':' {
+			parts := strings.Split(line, cellSep)
+			if columns == 0 {
+				columns = len(parts)
+			}
+			cells = append(cells, parts...)
+			continue
+		}
+		parts := strings.Split(strings.ReplaceAll(line, `\`+cellSep, "\uE002"), cellSep)
+		if len(parts) == 1 {
+			// 没有分隔符的行续接上一个单元格
+			if len(cells) > 0 {
+				cells[len(cells)-1] += " " + line
+			}
+			continue
+		}
+		var lineCells []string
+		for k, part := range parts[1:] {
+			part = strings.ReplaceAll(part, "\uE002", cellSep)
+			// 单元格末尾可能是下一个单元格的格式说明，如 2+| 、a|
+			if k+2 < len(parts) {
+				part = reAdocCellSpec.ReplaceAllString(part, "")
+			}
+			lineCells = append(lineCells, part)
+		}
+		if columns == 0 {
+			columns = len(lineCells)
+		}
+		cells = append(cells, lineCells...)
+	}
+	if columns == 0 {
+		return
+	}
+
+	var rows [][]string
+	for start := 0; start < len(cells); start += columns {
+		row := cells[start:min(start+columns, len(cells))]
+		for k := range row {
+			row[k] = c.inline(strings.TrimSpace(row[k]))
+		}
+		rows = append(rows, row)
+	}
+	c.b.table(rows)
+}
+
+// listItem 处理列表项，续行并入列表项
+func (c *adocConverter) listItem(lines []string, i int) int {
+	m := reAdocListItem.FindStringSubmatch(lines[i])
+	marker := "1."
+	if m[1][0] == '*' || m[1][0] == '-' {
+		marker = "-"
+	}
+	text := []string{m[2]}
+	for i++; i < len(lines) && c.continues(lines[i]); i++ {
+		text = append(text, strings.TrimSpace(lines[i]))
+	}
+	c.b.listItem(marker, c.inline(strings.Join(text, "\n")))
+	c.reset()
+	return i
+}
+
+// descItem 处理描述列表 "术语:: 定义"，定义在下一行时术语单独作为段落
+func (c *adocConverter) descItem(lines []string, i int) int {
+	m := reAdocDescItem.FindStringSubmatch(lines[i])
+	term := strings.TrimSpace(m[1])
+	if m[3] == "" {
+		c.b.paragraph(c.inline(term))
+		return i + 1
+	}
+	text := []string{m[3]}
+	for i++; i < len(lines) && c.continues(lines[i]); i++ {
+		text = append(text, strings.TrimSpace(lines[i]))
+	}
+	c.b.listItem("-", c.inline(term+": "+strings.Join(text, "\n")))
+	c.reset()
+	return i
+}
+
+// continues 判断一行是否是列表项、段落的续行
+func (c *adocConverter) continues(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	return !isBlank(line) && line != "+" && !reAdocDelimiter.MatchString(line) && !reAdocListItem.MatchString(line) &&
+		!reAdocBlockAttr.MatchString(line) && !isAdocComment(line) && !reAdocAttrEntry.MatchString(line) &&
+		!reAdocSection.MatchString(line) &&
+		!(reAdocDescItem.MatchString(line) && !strings.Contains(line, "://"))
+}
+
+// paragraph 处理段落，[source] 样式的段落按代码输出，提示类段落加标题
+func (c *adocConverter) paragraph(lines []string, i int) int {
+	start := i
+	for i++; i < len(lines) && c.continues(lines[i]); i++ {
+	}
+	c.emitTitle()
+	switch {
+	case c.style == "source" || c.style == "listing" || c.style == "literal":
+		c.b.code(c.sourceLang(), strings.Join(lines[start:i], "\n"))
+	default:
+		text := make([]string, 0, i-start)
+		for _, line := range lines[start:i] {
+			// 行尾的 " +" 是强制换行
+			text = append(text, strings.TrimSuffix(strings.TrimRight(line, " \t"), " +"))
+		}
+		joined := strings.Join(text, "\n")
+		if title, ok := adocAdmonitions[c.style]; ok {
+			joined = title + ": " + joined
+		} else if m := reAdocAdmon.FindStringSubmatch(joined); m != nil {
+			joined = adocAdmonitions[m[1]] + ": " + joined[len(m[0]):]
+		}
+		c.b.paragraph(c.inline(joined))
+	}
+	c.reset()
+	return i
+}
+
+// image 输出图片，第一个位置属性是替代文字
+func (c *adocConverter) image(target, attrs string) string {
+	alt := strings.Trim(strings.TrimSpace(splitAttrList(attrs)[0]), `"`)
+	if strings.Contains(alt, "=") {
+		alt = ""
+	}
+	if dir := c.attrs["imagesdir"]; dir != "" && !strings.Contains(target, "://") && !strings.HasPrefix(target, "/") {
+		target = strings.TrimSuffix(dir, "/") + "/" + target
+	}
+	return "![" + alt + "](" + target + ")"
+}
+
+// inline 把行内语法转换为Markdown：`代码`、链接宏、交叉引用、图片、属性引用等，
+// 粗体、斜体和高亮只保留文字，其余文字中的Markdown标记字符会被转义
+func (c *adocConverter) inline(text string) string {
+	p := &protector{}
+	text = reAdocPass.ReplaceAllStringFunc(text, func(s string) string {
+		m := reAdocPass.FindStringSubmatch(s)
+		return p.protect(m[1] + m[2])
+	})
+	text = reAdocLiteral.ReplaceAllStringFunc(text, func(s string) string {
+		m := reAdocLiteral.FindStringSubmatch(s)
+		return p.protect(inlineCode(m[1] + m[2] + m[3]))
+	})
+	text = c.substitute(text)
+	text = reAdocInlineImg.ReplaceAllStringFunc(text, func(s string) string {
+		m := reAdocInlineImg.FindStringSubmatch(s)
+		return p.protect(c.image(m[1], m[2]))
+	})
+	text = reAdocURLMacro.ReplaceAllStringFunc(text, func(s string) string {
+		m := reAdocURLMacro.FindStringSubmatch(s)
+		target := strings.TrimPrefix(m[1], "link:")
+		label := strings.TrimSuffix(strings.TrimSpace(splitAttrList(m[2])[0]), "^")
+		if strings.Contains(label, "=") {
+			label = ""
+		}
+		return p.protect(mdLink(strings.Trim(label, `"`), target))
+	})
+	text = reAdocXref.ReplaceAllStringFunc(text, func(s string) string {
+		m := reAdocXref.FindStringSubmatch(s)
+		switch {
+		case m[2] != "":
+			return m[2]
+		case m[4] != "":
+			return m[4]
+		case m[3] != "":
+			return m[3]
+		}
+		return m[1]
+	})
+	text = reAdocUIMacro.ReplaceAllStringFunc(text, func(s string) string {
+		m := reAdocUIMacro.FindStringSubmatch(s)
+		if m[2] == "" {
+			return m[1]
+		}
+		items := []string{m[2]}
+		for _, item := range strings.Split(m[3], ">") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return strings.Join(items, " > ")
+	})
+	text = reAdocFootnote.ReplaceAllString(text, " ($1)")
+	text = reAdocInlineID.ReplaceAllString(text, "")
+	for _, re := range []*regexp.Regexp{reAdocMark, reAdocStrongU, reAdocEmphasisU, reAdocStrong, reAdocEmphasis} {
+		text = stripMarkup(re, text)
+	}
+	return p.restore(escapeMarkdown(text))
+}
+
+// substitute 替换属性引用，未定义的属性原样保留
+func (c *adocConverter) substitute(text string) string {
+	return reAdocAttrRef.ReplaceAllStringFunc(text, func(s string) string {
+		name := s[1 : len(s)-1]
+		if value, ok := c.attrs[name]; ok {
+			return value
+		}
+		if value, ok := adocBuiltinAttrs[name]; ok {
+			return value
+		}
+		return s
+	})
+}
+
+// plain 元数据中使用的纯文本
+func (c *adocConverter) plain(text string) string {
+	return strings.Join(strings.Fields(c.substitute(text)), " ")
+}
+
+// isAdocComment 判断是否为单行注释，//// 是注释块的分隔线
+func isAdocComment(line string) bool {
+	return strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "////")
+}
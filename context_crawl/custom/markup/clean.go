@@ -0,0 +1,35 @@
+package markup
+
+import (
+	"strings"
+
+	"context_crawl/custom/md"
+	"context_crawl/types"
+)
+
+// MarkupCleaner 先把reStructuredText、AsciiDoc、Org-mode转换为Markdown，再按Markdown清洗，实现types.Cleaner接口
+// 标题、代码块（含语言）、表格、链接和元数据的输出与Markdown文档一致
+type MarkupCleaner struct {
+	convert  func(string) string // 转换为Markdown
+	markdown types.Cleaner
+}
+
+// NewMarkupCleaner 创建一个新的MarkupCleaner实例
+func NewMarkupCleaner(convert func(string) string) *MarkupCleaner {
+	return &MarkupCleaner{
+		convert:  convert,
+		markdown: md.NewMarkdownCleaner(),
+	}
+}
+
+// Clean 清洗标记语言文本，实现types.Cleaner接口
+func (mc *MarkupCleaner) Clean(input types.Type) (types.Type, error) {
+	text := strings.ReplaceAll(strings.ReplaceAll(input.Text, "\r\n", "\n"), "\r", "\n")
+	text = strings.TrimPrefix(text, "\uFEFF")
+	// 下载到的是HTML页面时交给Markdown清洗器按网页处理
+	if !md.IsHTMLDocument(text) {
+		text = mc.convert(text)
+	}
+	input.Text = text
+	return mc.markdown.Clean(input)
+}
@@ -0,0 +1,256 @@
+package markup
+
+import (
+	"reflect"
+	"testing"
+
+	"context_crawl/types"
+)
+
+// clean 用指定的转换器清洗文档
+func clean(t *testing.T, convert func(string) string, text string) types.Type {
+	t.Helper()
+	result, err := NewMarkupCleaner(convert).Clean(types.Type{Url: "https://example.com/docs/guide", Text: text})
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	return result
+}
+
+func TestCleanPlainText(t *testing.T) {
+	formats := []struct {
+		name    string
+		convert func(string) string
+	}{
+		{"rst", rstToMarkdown},
+		{"adoc", adocToMarkdown},
+		{"org", orgToMarkdown},
+	}
+	// 这些文字在三种标记语言中都不是标记，清洗后应保持原样
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"python varargs", "Call f(*args, **kwargs) to forward arguments."},
+		{"identifiers", "Set snake_case_name and my_var before use."},
+		{"generics and brackets", "Returns List<int> or [optional] values."},
+		{"glob and multiply", "Match *.txt files, then compute 2 * 3 * 4."},
+		{"leading hash", "#1 priority is correctness."},
+		{"leading quote mark", "> is the redirect operator."},
+	}
+	for _, format := range formats {
+		for _, tt := range tests {
+			t.Run(format.name+"/"+tt.name, func(t *testing.T) {
+				if got := clean(t, format.convert, tt.text).Text; got != tt.text {
+					t.Errorf("Clean() = %q, want %q", got, tt.text)
+				}
+			})
+		}
+	}
+}
+
+func TestCleanRST(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		code map[string]string
+		lang map[string]string
+	}{
+		{
+			name: "emphasis and strong",
+			text: "Use *emphasis* and **strong** text.",
+			want: "Use emphasis and strong text.",
+		},
+		{
+			name: "backslash escapes",
+			text: `Literal \*stars\*, C:\\temp and a\ b joined.`,
+			want: `Literal *stars*, C:\temp and ab joined.`,
+		},
+		{
+			name: "numbered heading",
+			text: "1. Introduction\n===============\n\nBody.",
+			want: "# 1. Introduction\nBody.",
+		},
+		{
+			name: "literals keep their content",
+			text: "Call ``f(*args, **kwargs)`` or :func:`os.path.join`.",
+			want: "Call @CODE_0@ or @CODE_1@.",
+			code: map[string]string{"@CODE_0@": "f(*args, **kwargs)", "@CODE_1@": "os.path.join"},
+		},
+		{
+			name: "headings code and links",
+			text: "Title\n=====\n\nSection\n-------\n\nSee `Python <https://python.org>`_.\n\n.. code-block:: python\n\n   def f(*a, **kw):\n       pass\n",
+			want: "# Title\n## Section\nSee [Python](https://python.org).\n@CODE_0@",
+			code: map[string]string{"@CODE_0@": "def f(*a, **kw):\n    pass"},
+			lang: map[string]string{"@CODE_0@": "python"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkClean(t, clean(t, rstToMarkdown, tt.text), tt.want, tt.code, tt.lang, nil)
+		})
+	}
+}
+
+func TestCleanAsciiDoc(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		want     string
+		code     map[string]string
+		lang     map[string]string
+		metadata map[string]string
+	}{
+		{
+			name: "constrained and unconstrained formatting",
+			text: "Use *bold*, _italic_, **un**constrained, __it__alic and #marked# text.",
+			want: "Use bold, italic, unconstrained, italic and marked text.",
+		},
+		{
+			name: "intraword markers are text",
+			text: `Compute a*b*c with my_var_name in C:\temp.`,
+			want: `Compute a*b*c with my_var_name in C:\temp.`,
+		},
+		{
+			name:     "header source block and link",
+			text:     "= Guide\n:author: Ann\n\n== Usage\n\n[source,go]\n----\nx := f(*p)\n----\n\nlink:https://x.org[X site] and `code`.",
+			want:     "# Guide\n## Usage\n@CODE_0@\n[X site](https://x.org) and @CODE_1@.",
+			code:     map[string]string{"@CODE_0@": "x := f(*p)", "@CODE_1@": "code"},
+			lang:     map[string]string{"@CODE_0@": "go"},
+			metadata: map[string]string{"title": "Guide", "author": "Ann"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkClean(t, clean(t, adocToMarkdown, tt.text), tt.want, tt.code, tt.lang, tt.metadata)
+		})
+	}
+}
+
+func TestCleanOrg(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		want     string
+		code     map[string]string
+		lang     map[string]string
+		metadata map[string]string
+	}{
+		{
+			name: "emphasis markers",
+			text: "Use *bold* /italic/ _under_ +strike+ text.",
+			want: "Use bold italic under strike text.",
+		},
+		{
+			name: "backslash and intraword markers",
+			text: `Open C:\temp\new with a*b and my_var.`,
+			want: `Open C:\temp\new with a*b and my_var.`,
+		},
+		{
+			name: "verbatim and code",
+			text: "Run =make *all*= or ~go test ./...~.",
+			want: "Run @CODE_0@ or @CODE_1@.",
+			code: map[string]string{"@CODE_0@": "make *all*", "@CODE_1@": "go test ./..."},
+		},
+		{
+			name:     "title heading source block and link",
+			text:     "#+TITLE: Notes\n\n* Setup\n\n#+BEGIN_SRC python\nprint(*args)\n#+END_SRC\n\n[[https://x.org][X site]]",
+			want:     "# Notes\n## Setup\n@CODE_0@\n[X site](https://x.org)",
+			code:     map[string]string{"@CODE_0@": "print(*args)"},
+			lang:     map[string]string{"@CODE_0@": "python"},
+			metadata: map[string]string{"title": "Notes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkClean(t, clean(t, orgToMarkdown, tt.text), tt.want, tt.code, tt.lang, tt.metadata)
+		})
+	}
+}
+
+func checkClean(t *testing.T, got types.Type, want string, code, lang, metadata map[string]string) {
+	t.Helper()
+	if got.Text != want {
+		t.Errorf("Clean() text = %q, want %q", got.Text, want)
+	}
+	if (len(got.CodeMap) > 0 || code != nil) && !reflect.DeepEqual(got.CodeMap, code) {
+		t.Errorf("Clean() code = %q, want %q", got.CodeMap, code)
+	}
+	if (len(got.CodeLang) > 0 || lang != nil) && !reflect.DeepEqual(got.CodeLang, lang) {
+		t.Errorf("Clean() lang = %q, want %q", got.CodeLang, lang)
+	}
+	if (len(got.Metadata) > 0 || metadata != nil) && !reflect.DeepEqual(got.Metadata, metadata) {
+		t.Errorf("Clean() metadata = %q, want %q", got.Metadata, metadata)
+	}
+}
+
+func TestCleanHTMLDocument(t *testing.T) {
+	// 下载到的是HTML页面时不做转换，按网页清洗
+	got := clean(t, rstToMarkdown, "<!DOCTYPE html><html><body><p>Rendered *page*</p></body></html>")
+	if got.Text != "Rendered *page*" {
+		t.Errorf("Clean() = %q", got.Text)
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"f(*args, **kwargs)", `f(\*args, \*\*kwargs)`},
+		{"a_b `c` [d] <e>", "a\\_b \\`c\\` \\[d] \\<e>"},
+		{`C:\dir`, `C:\\dir`},
+		{"# not a heading", `\# not a heading`},
+		{"  12. not a list", `  12\. not a list`},
+		{"> not a quote", `\> not a quote`},
+		{"version 1. text", "version 1. text"},
+	}
+	for _, tt := range tests {
+		if got := escapeMarkdown(tt.text); got != tt.want {
+			t.Errorf("escapeMarkdown(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRawGitHubURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/python/cpython/blob/main/Doc/index.rst", "https://raw.githubusercontent.com/python/cpython/main/Doc/index.rst"},
+		{"https://github.com/o/r/blob/v1/README.adoc?plain=1#usage", "https://raw.githubusercontent.com/o/r/v1/README.adoc"},
+		{"https://example.com/notes.org", "https://example.com/notes.org"},
+	}
+	for _, tt := range tests {
+		if got := rawGitHubURL(tt.url); got != tt.want {
+			t.Errorf("rawGitHubURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pipeline *MarkupPipeline
+		url      string
+		want     bool
+	}{
+		{NewOrgPipeline(), "https://example.com/notes.org", true},
+		{NewOrgPipeline(), "https://example.com/NOTES.ORG?raw=1#top", true},
+		{NewOrgPipeline(), "/home/user/notes.org", true},
+		// 只有主机名的.org网站交给通用网页pipeline
+		{NewOrgPipeline(), "https://golang.org", false},
+		{NewOrgPipeline(), "https://wikipedia.org/", false},
+		{NewOrgPipeline(), "https://www.gnu.org?lang=en", false},
+		{NewOrgPipeline(), "https://golang.org/doc/", false},
+		{NewRSTPipeline(), "https://github.com/python/cpython/blob/main/Doc/index.rst", true},
+		{NewRSTPipeline(), "https://docs.rest", false},
+		{NewAsciiDocPipeline(), "https://example.com/guide.adoc", true},
+		{NewAsciiDocPipeline(), "https://example.com/pkg-1.0.tar.gz.asc", false},
+	}
+	for _, tt := range tests {
+		if got := tt.pipeline.Match(tt.url); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package markup
+
+import (
+	"fmt"
+
+	"context_crawl/custom/md"
+	"context_crawl/types"
+)
+
+// MarkupCrawler 负责获取标记语言源文件，下载和读取本地文件复用MarkdownCrawler
+type MarkupCrawler struct {
+	files      *md.MarkdownCrawler
+	extensions []string
+}
+
+// NewMarkupCrawler 创建一个新的MarkupCrawler实例，只抓取指定扩展名的文件
+func NewMarkupCrawler(extensions []string) *MarkupCrawler {
+	return &MarkupCrawler{
+		files:      md.NewMarkdownCrawler(),
+		extensions: extensions,
+	}
+}
+
+// Crawl 爬取单个文件，实现types.Crawler接口
+// GitHub网页上的文件地址转换为原始文件地址下载，返回结果中保留原地址
+func (mc *MarkupCrawler) Crawl(input types.Type) (types.Type, error) {
+	if !hasExtension(input.Url, mc.extensions) {
+		return types.Type{}, fmt.Errorf("not a markup file: %s", input.Url)
+	}
+	result, err := mc.files.CrawlMarkdownFile(rawGitHubURL(input.Url))
+	if err != nil {
+		return types.Type{}, err
+	}
+	result.Url = input.Url
+	result.Options = input.Options
+	return result, nil
+}
@@ -0,0 +1,321 @@
+package markup
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	reOrgHeading   = regexp.MustCompile(`^(\*+)\s+(.*)$`)
+	reOrgTodo      = regexp.MustCompile(`^(?:TODO|DONE|NEXT|STARTED|WAITING|HOLD|SOMEDAY|CANCELLED|CANCELED)\s+`)
+	reOrgPriority  = regexp.MustCompile(`^\[#[A-Z0-9]\]\s*`)
+	reOrgTags      = regexp.MustCompile(`\s+:(?:[\w@#%]+:)+\s*$`)
+	reOrgKeyword   = regexp.MustCompile(`^#\+(\w+):\s*(.*)$`)
+	reOrgBegin     = regexp.MustCompile(`(?i)^#\+begin_(\w+)(?:\s+(.*))?$`)
+	reOrgDrawer    = regexp.MustCompile(`^:[\w-]+:$`)
+	reOrgPlanning  = regexp.MustCompile(`^(?:SCHEDULED|DEADLINE|CLOSED):`)
+	reOrgListItem  = regexp.MustCompile(`^(\s*)([-+*]|\d+[.)])\s+(.*)$`)
+	reOrgDescItem  = regexp.MustCompile(`(?s)^(.*?)\s+::(?:\s+(.*))?$`)
+	reOrgFixed     = regexp.MustCompile(`^\s*:(?:\s|$)`)
+	reOrgTableRule = regexp.MustCompile(`^\|?[-+]+\|?$|^\+[-+]+\+$`)
+	reOrgFootnote  = regexp.MustCompile(`^\[fn:([\w-]+)\]\s*(.*)$`)
+	reOrgRule      = regexp.MustCompile(`^-{5,}$`)
+
+	reOrgVerbatim = regexp.MustCompile(`(^|[\s({'"-])=([^\s=](?:[^=]*?[^\s])?)=($|[\s.,;:!?')}"\]-])`)
+	reOrgCode     = regexp.MustCompile(`(^|[\s({'"-])~([^\s~](?:[^~]*?[^\s])?)~($|[\s.,;:!?')}"\]-])`)
+	reOrgLink     = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+	reOrgFootRef  = regexp.MustCompile(`\[fn:([\w-]*):([^\]]*)\]|\[fn:([\w-]+)\]`)
+	reOrgItalic   = regexp.MustCompile(`(^|[\s({'"-])/([^\s/](?:[^/]*?[^\s/])?)/($|[\s.,;:!?')}"\]-])`)
+	reOrgStrike   = regexp.MustCompile(`(^|[\s({'"-])\+([^\s+](?:[^+]*?[^\s+])?)\+($|[\s.,;:!?')}"\]-])`)
+	reOrgBold     = regexp.MustCompile(`(^|[\s({'"-])\*([^\s*](?:[^*]*?[^\s*])?)\*($|[\s.,;:!?')}"\]-])`)
+	reOrgUnder    = regexp.MustCompile(`(^|[\s({'"-])_([^\s_](?:[^_]*?[^\s_])?)_($|[\s.,;:!?')}"\]-])`)
+	reOrgMacro    = regexp.MustCompile(`\{\{\{[^}]*\}\}\}`)
+)
+
+// orgMetaKeywords 放入元数据的文档关键字，值为元数据中的键名
+var orgMetaKeywords = map[string]string{
+	"title": "title", "subtitle": "subtitle", "author": "author", "date": "date", "email": "email",
+	"description": "description", "keywords": "keywords", "language": "lang", "filetags": "tags",
+}
+
+// orgImageExts 链接目标为图片时按图片输出
+var orgImageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true,
+}
+
+// orgConverter 把Org-mode转换为Markdown，#+TITLE 作为一级标题时各级标题顺延一级
+type orgConverter struct {
+	b       *builder
+	offset  int    // 标题层级的偏移
+	caption string // #+CAPTION 作用于下一个区块
+	started bool   // 已出现标题，之后的 #+TITLE 不再作为文档标题
+}
+
+// orgToMarkdown 把Org-mode文档转换为Markdown
+func orgToMarkdown(text string) string {
+	c := &orgConverter{b: newBuilder()}
+	c.convert(strings.Split(text, "\n"))
+	return c.b.String()
+}
+
+// convert 转换一组行，区块内容递归处理
+func (c *orgConverter) convert(lines []string) {
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			i++
+		case reOrgHeading.MatchString(line):
+			c.heading(line)
+			i++
+		case reOrgBegin.MatchString(trimmed):
+			i = c.block(lines, i)
+		case reOrgKeyword.MatchString(trimmed):
+			c.keyword(trimmed)
+			i++
+		case trimmed == "#" || strings.HasPrefix(trimmed, "# "):
+			// 注释
+			i++
+		case reOrgDrawer.MatchString(trimmed) && !strings.EqualFold(trimmed, ":END:"):
+			i = c.drawer(lines, i)
+		case reOrgPlanning.MatchString(trimmed), reOrgRule.MatchString(trimmed):
+			i++
+		case reOrgFixed.MatchString(line):
+			end := i
+			var code []string
+			for ; end < len(lines) && reOrgFixed.MatchString(lines[end]); end++ {
+				code = append(code, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[end]), ":"), " "))
+			}
+			c.emitCaption()
+			c.b.code("", strings.Join(code, "\n"))
+			i = end
+		case strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "+-"):
+			i = c.table(lines, i)
+		case reOrgListItem.MatchString(line):
+			i = c.listItem(lines, i)
+		default:
+			i = c.paragraph(lines, i)
+		}
+	}
+}
+
+// heading 输出标题，去掉TODO关键字、优先级和标签
+func (c *orgConverter) heading(line string) {
+	m := reOrgHeading.FindStringSubmatch(line)
+	title := reOrgTodo.ReplaceAllString(m[2], "")
+	title = reOrgPriority.ReplaceAllString(title, "")
+	title = reOrgTags.ReplaceAllString(title, "")
+	c.b.heading(len(m[1])+c.offset, c.inline(title))
+	c.started = true
+}
+
+// keyword 处理 #+KEY: value，文档信息放入元数据，#+CAPTION 作用于下一个区块
+func (c *orgConverter) keyword(line string) {
+	m := reOrgKeyword.FindStringSubmatch(line)
+	key, value := strings.ToLower(m[1]), strings.TrimSpace(m[2])
+	switch key {
+	case "caption":
+		c.caption = value
+		return
+	case "title":
+		if !c.started && c.offset == 0 && value != "" {
+			c.b.heading(1, c.inline(value))
+			c.offset = 1
+		}
+	}
+	if name, ok := orgMetaKeywords[key]; ok && value != "" {
+		if key == "filetags" {
+			value = strings.Join(strings.FieldsFunc(value, func(r rune) bool { return r == ':' || r == ' ' }), ", ")
+		}
+		if prev := c.b.meta[name]; prev != "" {
+			value = prev + " " + value
+		}
+		c.b.meta[name] = value
+	}
+}
+
+// emitCaption 区块标题作为段落输出
+func (c *orgConverter) emitCaption() {
+	if c.caption != "" {
+		c.b.paragraph(c.inline(c.caption))
+		c.caption = ""
+	}
+}
+
+// block 处理 #+BEGIN_X … #+END_X 区块
+func (c *orgConverter) block(lines []string, i int) int {
+	m := reOrgBegin.FindStringSubmatch(strings.TrimSpace(lines[i]))
+	kind, args := strings.ToLower(m[1]), strings.Fields(m[2])
+	end := i + 1
+	for end < len(lines) && !strings.EqualFold(strings.TrimSpace(lines[end]), "#+end_"+kind) {
+		end++
+	}
+	content := dedent(lines[i+1 : min(end, len(lines))])
+	next := min(end+1, len(lines))
+
+	switch kind {
+	case "comment", "export":
+		c.caption = ""
+		return next
+	case "src", "example":
+		// 代码中以 * 或 #+ 开头的行用逗号转义
+		for k, line := range content {
+			if strings.HasPrefix(line, ",*") || strings.HasPrefix(line, ",#+") {
+				content[k] = line[1:]
+			}
+		}
+		lang := ""
+		if kind == "src" && len(args) > 0 {
+			lang = args[0]
+		}
+		c.emitCaption()
+		c.b.code(lang, strings.Join(content, "\n"))
+	default:
+		// quote、verse、center及自定义区块：内容照常处理
+		c.emitCaption()
+		c.convert(content)
+	}
+	return next
+}
+
+// drawer 跳过 :PROPERTIES: 等抽屉
+func (c *orgConverter) drawer(lines []string, i int) int {
+	for end := i + 1; end < len(lines); end++ {
+		if strings.EqualFold(strings.TrimSpace(lines[end]), ":END:") {
+			return end + 1
+		}
+		if reOrgHeading.MatchString(lines[end]) {
+			break
+		}
+	}
+	// 没有 :END: 时不是抽屉，按段落处理
+	return c.paragraph(lines, i)
+}
+
+// table 处理表格，分隔行忽略
+func (c *orgConverter) table(lines []string, i int) int {
+	var rows [][]string
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, "|") && !strings.HasPrefix(trimmed, "+-") {
+			break
+		}
+		if reOrgTableRule.MatchString(trimmed) {
+			continue
+		}
+		cells := strings.Split(strings.Trim(trimmed, "|"), "|")
+		for k := range cells {
+			cells[k] = c.inline(strings.TrimSpace(cells[k]))
+		}
+		rows = append(rows, cells)
+	}
+	c.emitCaption()
+	c.b.table(rows)
+	return i
+}
+
+// listItem 处理列表项，缩进大于列表符号的续行并入列表项，描述列表 "术语 :: 定义" 转为 "术语: 定义"
+func (c *orgConverter) listItem(lines []string, i int) int {
+	m := reOrgListItem.FindStringSubmatch(lines[i])
+	marker := "1."
+	if len(m[2]) == 1 && strings.Contains("-+*", m[2]) {
+		marker = "-"
+	}
+	indent := indentOf(lines[i])
+	text := []string{m[3]}
+	for i++; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) || indentOf(line) <= indent || reOrgListItem.MatchString(line) ||
+			reOrgBegin.MatchString(strings.TrimSpace(line)) || reOrgFixed.MatchString(line) {
+			break
+		}
+		text = append(text, strings.TrimSpace(line))
+	}
+	item := strings.Join(text, "\n")
+	if d := reOrgDescItem.FindStringSubmatch(item); d != nil && marker == "-" {
+		item = d[1] + ": " + d[2]
+	}
+	c.b.listItem(marker, c.inline(item))
+	return i
+}
+
+// paragraph 处理段落，行尾的 \\ 是强制换行
+func (c *orgConverter) paragraph(lines []string, i int) int {
+	var text []string
+	for start := i; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+		if i > start && (trimmed == "" || reOrgHeading.MatchString(line) || strings.HasPrefix(trimmed, "#+") ||
+			strings.HasPrefix(trimmed, "|") || reOrgListItem.MatchString(line) || reOrgFixed.MatchString(line)) {
+			break
+		}
+		text = append(text, strings.TrimSuffix(trimmed, `\\`))
+	}
+	joined := strings.Join(text, "\n")
+	if m := reOrgFootnote.FindStringSubmatch(joined); m != nil {
+		joined = "[" + m[1] + "] " + m[2]
+	}
+	c.emitCaption()
+	c.b.paragraph(c.inline(joined))
+	return i
+}
+
+// inline 把行内语法转换为Markdown：=代码=、~代码~、[[链接][描述]]和脚注，
+// 粗体、斜体、下划线和删除线只保留文字，其余文字中的Markdown标记字符会被转义
+func (c *orgConverter) inline(text string) string {
+	p := &protector{}
+	code := func(re *regexp.Regexp) {
+		text = re.ReplaceAllStringFunc(text, func(s string) string {
+			m := re.FindStringSubmatch(s)
+			return m[1] + p.protect(inlineCode(m[2])) + m[3]
+		})
+	}
+	code(reOrgVerbatim)
+	code(reOrgCode)
+	text = reOrgLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := reOrgLink.FindStringSubmatch(s)
+		return p.protect(orgLink(m[1], m[2]))
+	})
+	text = reOrgFootRef.ReplaceAllStringFunc(text, func(s string) string {
+		m := reOrgFootRef.FindStringSubmatch(s)
+		if m[3] != "" {
+			return "[" + m[3] + "]"
+		}
+		// 行内脚注 [fn::定义]
+		return " (" + strings.TrimSpace(m[2]) + ")"
+	})
+	text = reOrgMacro.ReplaceAllString(text, "")
+	for _, re := range []*regexp.Regexp{reOrgBold, reOrgItalic, reOrgUnder, reOrgStrike} {
+		text = stripMarkup(re, text)
+	}
+	return p.restore(escapeMarkdown(text))
+}
+
+// orgLink 转换链接：外部地址输出Markdown链接，图片输出图片，文档内链接只保留描述
+func orgLink(target, desc string) string {
+	target = strings.TrimSpace(target)
+	isFile := strings.HasPrefix(target, "file:") || strings.HasPrefix(target, "./") ||
+		strings.HasPrefix(target, "../") || strings.HasPrefix(target, "/")
+	file := strings.TrimPrefix(target, "file:")
+
+	switch {
+	case desc == "" && (isFile || strings.Contains(target, "://")) && orgImageExts[strings.ToLower(path.Ext(file))]:
+		return "![](" + file + ")"
+	case strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:"):
+		if desc == "" {
+			return "<" + target + ">"
+		}
+		return mdLink(desc, target)
+	case isFile:
+		if desc == "" {
+			desc = file
+		}
+		return mdLink(desc, file)
+	case desc != "":
+		return desc
+	}
+	// [[*标题]]、[[#id]] 等文档内链接
+	return strings.TrimLeft(target, "*#")
+}
@@ -0,0 +1,71 @@
+package markup
+
+import (
+	"context_crawl/base/colly"
+	"context_crawl/base/normalize"
+	"context_crawl/types"
+)
+
+var (
+	// RSTExtensions reStructuredText文件扩展名
+	RSTExtensions = []string{".rst", ".rest"}
+	// AsciiDocExtensions AsciiDoc文件扩展名，不含.asc，网上的.asc文件大多是PGP签名
+	AsciiDocExtensions = []string{".adoc", ".asciidoc"}
+	// OrgExtensions Org-mode文件扩展名
+	OrgExtensions = []string{".org"}
+)
+
+// MarkupPipeline 处理reStructuredText、AsciiDoc、Org-mode文档，
+// 匹配对应扩展名的本地文件、远程文件以及GitHub上的文件地址
+type MarkupPipeline struct {
+	Crawler types.Crawler // 爬虫组件
+	Chunker types.Chunker // 分块组件
+	Cleaner types.Cleaner // 清洗组件
+
+	extensions []string
+}
+
+// NewRSTPipeline 创建处理reStructuredText文档的MarkupPipeline
+func NewRSTPipeline() *MarkupPipeline {
+	return newMarkupPipeline(RSTExtensions, rstToMarkdown)
+}
+
+// NewAsciiDocPipeline 创建处理AsciiDoc文档的MarkupPipeline
+func NewAsciiDocPipeline() *MarkupPipeline {
+	return newMarkupPipeline(AsciiDocExtensions, adocToMarkdown)
+}
+
+// NewOrgPipeline 创建处理Org-mode文档的MarkupPipeline
+func NewOrgPipeline() *MarkupPipeline {
+	return newMarkupPipeline(OrgExtensions, orgToMarkdown)
+}
+
+func newMarkupPipeline(extensions []string, convert func(string) string) *MarkupPipeline {
+	return &MarkupPipeline{
+		Crawler: NewMarkupCrawler(extensions),
+		// 转换为Markdown后清洗，分块与Markdown pipeline一致
+		Cleaner:    normalize.NewCleaner(NewMarkupCleaner(convert), normalize.DefaultOptions()),
+		Chunker:    colly.NewSectionChunker(0.2),
+		extensions: extensions,
+	}
+}
+
+// Process 执行pipeline处理流程，实现types.Pipeline接口
+func (p *MarkupPipeline) Process(input types.Type) (types.Type, error) {
+	result, err := p.Crawler.Crawl(input)
+	if err != nil {
+		return types.Type{}, err
+	}
+
+	cleanResult, err := p.Cleaner.Clean(result)
+	if err != nil {
+		return types.Type{}, err
+	}
+
+	return p.Chunker.Chunk(cleanResult)
+}
+
+// Match 匹配方法，检查URL是否是对应扩展名的文件
+func (p *MarkupPipeline) Match(url string) bool {
+	return hasExtension(url, p.extensions)
+}
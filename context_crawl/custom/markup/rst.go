@@ -0,0 +1,620 @@
+package markup
+
+import (
+	"encoding/csv"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	reRSTDirective    = regexp.MustCompile(`^([\w][\w:.+-]*)::(?:\s+(.*))?$`)
+	reRSTSubstitution = regexp.MustCompile(`^\|([^|]+)\|\s+([\w:.+-]+)::(?:\s+(.*))?$`)
+	reRSTTarget       = regexp.MustCompile("^_(`[^`]+`|[^:]+):(?:\\s+(.*))?$")
+	reRSTFootnote     = regexp.MustCompile(`^\[([^\]]+)\]\s+(.*)$`)
+	reRSTField        = regexp.MustCompile(`^:([^:\s][^:]*):(?:\s+(.*))?$`)
+	reRSTOption       = regexp.MustCompile(`^:([\w-]+):(?:\s+(.*))?$`)
+	reRSTListItem     = regexp.MustCompile(`^([-*+•‣⁃]|#\.|\d+\.|\(?[a-zA-Z0-9#]\)|[a-zA-Z]\.)(\s+)(.*)$`)
+	reRSTGridBorder   = regexp.MustCompile(`^\+(?:[-=]+\+)+$`)
+	reRSTSimpleBorder = regexp.MustCompile(`^=+(?: +=+)+ *$`)
+
+	reRSTLiteral   = regexp.MustCompile("``(.+?)``")
+	reRSTRole      = regexp.MustCompile(":([\\w:.+-]+):`([^`]+)`")
+	reRSTLink      = regexp.MustCompile("`([^`<]*?)\\s*<([^`>]+)>`__?")
+	reRSTRefLink   = regexp.MustCompile("`([^`]+)`__?")
+	reRSTFootRef   = regexp.MustCompile(`\s?\[(\d+|#[\w-]*|\*)\]_`)
+	reRSTWordRef   = regexp.MustCompile(`\b([\w.-]*\w)__?(\s|$|[.,;:!?)])`)
+	reRSTSubstRef  = regexp.MustCompile(`\|([^|\s](?:[^|]*[^|\s])?)\|(?:__?)?`)
+	reRSTInterpret = regexp.MustCompile("`([^`]+)`")
+	reRSTTarget2   = regexp.MustCompile(`^(.*?)\s*<([^<>]+)>$`)
+	reRSTEscape    = regexp.MustCompile(`\\(.)`)
+
+	// 强调标记的开始字符串之前是行首、空白或开括号类标点，结束字符串之后是行尾、空白或标点
+	reRSTStrong   = regexp.MustCompile(`(^|[\s'"(\[{<\-/:])\*\*(\S(?:.*?\S)?)\*\*($|[\s'")\]}>\-/:.,;!?])`)
+	reRSTEmphasis = regexp.MustCompile(`(^|[\s'"(\[{<\-/:])\*([^\s*](?:[^*]*?[^\s*])?)\*($|[\s'")\]}>\-/:.,;!?])`)
+)
+
+// rstAdmonitions 提示类指令及其显示的标题
+var rstAdmonitions = map[string]string{
+	"note": "Note", "warning": "Warning", "tip": "Tip", "important": "Important",
+	"caution": "Caution", "attention": "Attention", "danger": "Danger", "error": "Error",
+	"hint": "Hint", "seealso": "See also", "todo": "Todo",
+	"versionadded": "New in version", "versionchanged": "Changed in version", "deprecated": "Deprecated since version",
+}
+
+// rstSkipped 不产生正文的指令
+var rstSkipped = map[string]bool{
+	"toctree": true, "contents": true, "index": true, "raw": true, "include": true, "literalinclude": true,
+	"meta": true, "sectnum": true, "autosummary": true, "currentmodule": true, "module": true,
+	"default-role": true, "role": true, "tabularcolumns": true,
+}
+
+// rstCodeRoles 渲染为行内代码的角色，Sphinx各语言域的交叉引用（:py:func: 等）也按代码处理
+var rstCodeRoles = map[string]bool{
+	"code": true, "literal": true, "samp": true, "file": true, "command": true, "program": true,
+	"envvar": true, "option": true, "func": true, "meth": true, "class": true, "mod": true,
+	"attr": true, "data": true, "exc": true, "obj": true, "const": true, "type": true, "member": true,
+}
+
+// rstConverter 把reStructuredText转换为Markdown，章节标题层级按装饰样式首次出现的顺序确定
+type rstConverter struct {
+	b         *builder
+	styles    []string          // 已出现的标题装饰样式
+	subs      map[string]string // 替换定义 |name|
+	targets   map[string]bool   // 已定义的命名链接目标
+	highlight string            // highlight 指令设置的字面块默认语言
+	started   bool              // 已输出正文，之后的字段列表不再作为文档信息
+}
+
+// rstToMarkdown 把reStructuredText文档转换为Markdown
+func rstToMarkdown(text string) string {
+	c := &rstConverter{
+		b:       newBuilder(),
+		subs:    make(map[string]string),
+		targets: make(map[string]bool),
+	}
+	lines := strings.Split(text, "\n")
+	// 替换定义和链接目标可以出现在引用之后，先收集一遍
+	c.collect(lines)
+	c.convert(lines)
+	return c.b.String()
+}
+
+// collect 收集替换定义和命名链接目标
+func (c *rstConverter) collect(lines []string) {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, ".. ") {
+			continue
+		}
+		rest := strings.TrimSpace(trimmed[3:])
+		if m := reRSTSubstitution.FindStringSubmatch(rest); m != nil {
+			switch m[2] {
+			case "replace", "unicode":
+				c.subs[m[1]] = m[3]
+			default:
+				c.subs[m[1]] = ""
+			}
+		} else if m := reRSTTarget.FindStringSubmatch(rest); m != nil && m[2] != "" {
+			name := strings.Trim(m[1], "`")
+			c.targets[normalizeName(name)] = true
+			c.b.ref(name, strings.TrimSpace(m[2]))
+		}
+	}
+}
+
+// convert 转换一组已经去掉公共缩进的行，缩进的内容块递归处理
+func (c *rstConverter) convert(lines []string) {
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], " \t")
+		switch {
+		case isBlank(line):
+			i++
+		case indentOf(line) > 0:
+			// 引用块、定义内容等缩进块
+			end := blockEnd(lines, i, 1)
+			c.convert(dedent(lines[i:end]))
+			i = end
+		case i+2 < len(lines) && isAdornment(line) && isAdornment(lines[i+2]) && !isBlank(lines[i+1]) &&
+			strings.TrimSpace(lines[i+2])[0] == line[0]:
+			// 上下都有装饰线的标题
+			c.b.heading(c.level(line[0], true), c.inline(lines[i+1]))
+			i += 3
+		case i+1 < len(lines) && isAdornment(lines[i+1]) && !isAdornment(line) &&
+			len(strings.TrimSpace(lines[i+1])) >= min(utf8.RuneCountInString(strings.TrimSpace(line)), 6):
+			c.b.heading(c.level(strings.TrimSpace(lines[i+1])[0], false), c.inline(line))
+			i += 2
+		case isAdornment(line) && len(line) >= 4:
+			// 分隔线
+			i++
+		case line == ".." || strings.HasPrefix(line, ".. "):
+			i = c.explicit(lines, i)
+		case reRSTGridBorder.MatchString(line):
+			i = c.gridTable(lines, i)
+		case reRSTSimpleBorder.MatchString(line):
+			i = c.simpleTable(lines, i)
+		case reRSTField.MatchString(line):
+			i = c.field(lines, i)
+		case reRSTListItem.MatchString(line) && (i+1 >= len(lines) || isBlank(lines[i+1]) || indentOf(lines[i+1]) > 0 || reRSTListItem.MatchString(lines[i+1])):
+			i = c.listItem(lines, i)
+		default:
+			i = c.paragraph(lines, i)
+		}
+	}
+}
+
+// level 返回装饰样式对应的标题层级
+func (c *rstConverter) level(char byte, overline bool) int {
+	style := string(char)
+	if overline {
+		style += style
+	}
+	for i, s := range c.styles {
+		if s == style {
+			return i + 1
+		}
+	}
+	c.styles = append(c.styles, style)
+	return len(c.styles)
+}
+
+// paragraph 处理段落，以 :: 结尾时后面的缩进块是字面块，
+// 单行段落后紧跟缩进行时是定义列表
+func (c *rstConverter) paragraph(lines []string, i int) int {
+	start := i
+	for i < len(lines) && !isBlank(lines[i]) && indentOf(lines[i]) == 0 {
+		// 下一行是标题的装饰线时，当前行属于标题
+		if i > start && i+1 < len(lines) && isAdornment(lines[i+1]) {
+			break
+		}
+		i++
+	}
+	text := strings.Join(lines[start:i], "\n")
+	c.started = true
+
+	if i < len(lines) && !isBlank(lines[i]) && indentOf(lines[i]) > 0 {
+		// 定义列表：术语 + 缩进的定义
+		end := blockEnd(lines, i, 1)
+		c.b.paragraph(c.inline(text))
+		c.convert(dedent(lines[i:end]))
+		return end
+	}
+
+	if !strings.HasSuffix(text, "::") {
+		c.b.paragraph(c.inline(text))
+		return i
+	}
+
+	// 字面块
+	c.b.paragraph(c.inline(literalIntro(text)))
+	j := i
+	for j < len(lines) && isBlank(lines[j]) {
+		j++
+	}
+	if j < len(lines) && indentOf(lines[j]) > 0 {
+		end := blockEnd(lines, j, 1)
+		c.b.code(c.highlight, strings.Join(dedent(lines[j:end]), "\n"))
+		return end
+	}
+	return i
+}
+
+// literalIntro 去掉引出字面块的 ::，"文字::" 保留一个冒号，"文字 ::" 和单独的 "::" 不保留
+func literalIntro(text string) string {
+	trimmed := strings.TrimSpace(strings.TrimSuffix(text, "::"))
+	if trimmed == "" || strings.HasSuffix(text, " ::") {
+		return trimmed
+	}
+	return trimmed + ":"
+}
+
+// listItem 处理列表项，续行并入列表项，之后的缩进内容递归处理
+func (c *rstConverter) listItem(lines []string, i int) int {
+	m := reRSTListItem.FindStringSubmatch(lines[i])
+	marker := "1."
+	if utf8.RuneCountInString(m[1]) == 1 && strings.ContainsAny(m[1], "-*+•‣⁃") {
+		marker = "-"
+	}
+	c.started = true
+
+	end := blockEnd(lines, i+1, 1)
+	body := dedent(append([]string{strings.Repeat(" ", len(m[1])+len(m[2])) + m[3]}, lines[i+1:end]...))
+	// 第一段作为列表项文字
+	j := 0
+	for j < len(body) && !isBlank(body[j]) && (j == 0 || !reRSTListItem.MatchString(body[j])) {
+		j++
+	}
+	text := strings.Join(body[:j], "\n")
+	if strings.HasSuffix(text, "::") {
+		// 列表项以 :: 结尾时，后面的缩进块是字面块
+		text = literalIntro(text)
+		body = append([]string{"::", ""}, body[j:]...)
+		j = 0
+	}
+	c.b.listItem(marker, c.inline(text))
+	if j < len(body) {
+		c.convert(body[j:])
+	}
+	return end
+}
+
+// field 处理字段列表，正文之前的字段作为文档信息放入元数据
+func (c *rstConverter) field(lines []string, i int) int {
+	m := reRSTField.FindStringSubmatch(lines[i])
+	end := blockEnd(lines, i+1, 1)
+	value := strings.Join(append([]string{m[2]}, dedent(lines[i+1:end])...), "\n")
+	if !c.started {
+		if value = strings.Join(strings.Fields(c.inline(value)), " "); value != "" {
+			c.b.meta[strings.ToLower(strings.Join(strings.Fields(m[1]), "_"))] = value
+		}
+		return end
+	}
+	c.b.listItem("-", c.inline(m[1]+": "+value))
+	return end
+}
+
+// explicit 处理以 .. 开头的显式标记：指令、替换定义、链接目标、脚注和注释
+func (c *rstConverter) explicit(lines []string, i int) int {
+	first := strings.TrimSpace(strings.TrimPrefix(lines[i], ".."))
+	end := blockEnd(lines, i+1, 1)
+	body := dedent(lines[i+1 : end])
+
+	switch {
+	case reRSTSubstitution.MatchString(first), reRSTTarget.MatchString(first):
+		// 已在collect中处理
+	case reRSTDirective.MatchString(first):
+		m := reRSTDirective.FindStringSubmatch(first)
+		c.directive(strings.ToLower(m[1]), strings.TrimSpace(m[2]), body)
+	case reRSTFootnote.MatchString(first):
+		m := reRSTFootnote.FindStringSubmatch(first)
+		c.b.paragraph(c.inline("[" + strings.TrimPrefix(m[1], "#") + "] " + m[2] + "\n" + strings.Join(body, "\n")))
+	}
+	// 其余为注释
+	return end
+}
+
+// directive 处理指令，body为指令的参数续行、选项和内容
+func (c *rstConverter) directive(name, arg string, body []string) {
+	// 参数可以续写在下一行，之后是 :option: 选项，空行后是内容
+	j := 0
+	for j < len(body) && !isBlank(body[j]) && !reRSTOption.MatchString(body[j]) {
+		arg = strings.TrimSpace(arg + " " + strings.TrimSpace(body[j]))
+		j++
+	}
+	options := make(map[string]string)
+	for j < len(body) && reRSTOption.MatchString(body[j]) {
+		m := reRSTOption.FindStringSubmatch(body[j])
+		options[m[1]] = strings.TrimSpace(m[2])
+		j++
+	}
+	content := body[j:]
+	for len(content) > 0 && isBlank(content[0]) {
+		content = content[1:]
+	}
+
+	domain, short := "", name
+	if k := strings.LastIndex(name, ":"); k >= 0 {
+		domain, short = name[:k], name[k+1:]
+	}
+
+	switch {
+	case short == "code-block" || short == "code" || short == "sourcecode":
+		c.b.code(arg, strings.Join(content, "\n"))
+	case short == "highlight":
+		c.highlight = arg
+	case short == "math":
+		c.b.code("math", strings.TrimSpace(arg+"\n"+strings.Join(content, "\n")))
+	case short == "image" || short == "figure":
+		c.b.paragraph("![" + options["alt"] + "](" + arg + ")")
+		c.convert(content)
+	case short == "list-table" || short == "csv-table":
+		// 参数是表格标题
+		c.b.paragraph(c.inline(arg))
+		if short == "list-table" {
+			c.b.table(listTableRows(content, c.inline))
+		} else {
+			c.b.table(csvTableRows(options["header"], content, c.inline))
+		}
+	case short == "admonition":
+		c.b.paragraph(c.inline(arg) + ":")
+		c.convert(content)
+	case rstAdmonitions[short] != "":
+		// 版本类指令的参数是版本号，其余提示类指令的参数是正文的第一段
+		title := rstAdmonitions[short]
+		if strings.Contains(title, "version") {
+			c.b.paragraph(c.inline(title+" "+arg) + ":")
+		} else {
+			c.b.paragraph(title + ": " + c.inline(arg))
+		}
+		c.convert(content)
+	case rstSkipped[short]:
+	case domain != "" || isSignatureDirective(short):
+		// API文档：签名作为行内代码，说明递归处理
+		if arg != "" {
+			c.b.paragraph(inlineCode(arg))
+		}
+		c.convert(content)
+	default:
+		// topic、sidebar、rubric、container等：标题作为段落，内容照常处理
+		if arg != "" {
+			c.b.paragraph(c.inline(arg))
+		}
+		c.convert(content)
+	}
+	c.started = true
+}
+
+// isSignatureDirective 判断是否为描述API签名的Sphinx指令（省略了语言域的写法）
+func isSignatureDirective(name string) bool {
+	switch name {
+	case "function", "class", "method", "attribute", "data", "exception", "decorator",
+		"classmethod", "staticmethod", "property", "envvar", "option", "describe", "object":
+		return true
+	}
+	return false
+}
+
+// gridTable 处理网格表格，每两条边框之间的内容合并为一行
+func (c *rstConverter) gridTable(lines []string, i int) int {
+	var rows [][]string
+	var current []string
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if reRSTGridBorder.MatchString(line) {
+			if len(current) > 0 {
+				rows = append(rows, current)
+				current = nil
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "|") {
+			break
+		}
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		for len(current) < len(cells) {
+			current = append(current, "")
+		}
+		for j, cell := range cells {
+			current[j] = strings.TrimSpace(current[j] + " " + strings.TrimSpace(cell))
+		}
+	}
+	for _, row := range rows {
+		for j := range row {
+			row[j] = c.inline(row[j])
+		}
+	}
+	c.b.table(rows)
+	c.started = true
+	return i
+}
+
+// simpleTable 处理简单表格，列的范围由 = 边框确定，第一列为空的行是上一行的续行
+func (c *rstConverter) simpleTable(lines []string, i int) int {
+	border := lines[i]
+	var starts []int
+	for j := 0; j < len(border); j++ {
+		if border[j] == '=' && (j == 0 || border[j-1] == ' ') {
+			starts = append(starts, j)
+		}
+	}
+
+	var rows [][]string
+	for i++; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " ")
+		if reRSTSimpleBorder.MatchString(line) {
+			// 最后一条边框之后是空行或文档结尾
+			if i+1 >= len(lines) || isBlank(lines[i+1]) {
+				i++
+				break
+			}
+			continue
+		}
+		if isBlank(line) || strings.Trim(line, "- ") == "" {
+			continue
+		}
+		cells := make([]string, len(starts))
+		for k, start := range starts {
+			if start >= len(line) {
+				break
+			}
+			stop := len(line)
+			if k+1 < len(starts) && starts[k+1] < len(line) {
+				stop = starts[k+1]
+			}
+			cells[k] = strings.TrimSpace(line[start:stop])
+		}
+		if cells[0] == "" && len(rows) > 0 {
+			prev := rows[len(rows)-1]
+			for k, cell := range cells {
+				prev[k] = strings.TrimSpace(prev[k] + " " + cell)
+			}
+			continue
+		}
+		rows = append(rows, cells)
+	}
+	for _, row := range rows {
+		for j := range row {
+			row[j] = c.inline(row[j])
+		}
+	}
+	c.b.table(rows)
+	c.started = true
+	return i
+}
+
+// listTableRows 解析 list-table 指令：* - 开始新行，- 开始新单元格
+func listTableRows(content []string, inline func(string) string) [][]string {
+	var rows [][]string
+	for _, line := range content {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "* - ") || trimmed == "* -":
+			rows = append(rows, []string{strings.TrimSpace(strings.TrimPrefix(trimmed, "* -"))})
+		case len(rows) > 0 && (strings.HasPrefix(trimmed, "- ") || trimmed == "-"):
+			row := &rows[len(rows)-1]
+			*row = append(*row, strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+		case len(rows) > 0 && trimmed != "":
+			row := rows[len(rows)-1]
+			row[len(row)-1] = strings.TrimSpace(row[len(row)-1] + " " + trimmed)
+		}
+	}
+	for _, row := range rows {
+		for j := range row {
+			row[j] = inline(row[j])
+		}
+	}
+	return rows
+}
+
+// csvTableRows 解析 csv-table 指令，:header: 选项作为表头
+func csvTableRows(header string, content []string, inline func(string) string) [][]string {
+	text := strings.Join(content, "\n")
+	if header != "" {
+		text = header + "\n" + text
+	}
+	r := csv.NewReader(strings.NewReader(text))
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil
+	}
+	for _, row := range rows {
+		for j := range row {
+			row[j] = inline(row[j])
+		}
+	}
+	return rows
+}
+
+// inline 把行内语法转换为Markdown：“代码“、角色、超链接、替换引用和脚注引用，
+// 强调只保留文字，其余文字中的Markdown标记字符会被转义
+func (c *rstConverter) inline(text string) string {
+	p := &protector{}
+	text = reRSTLiteral.ReplaceAllStringFunc(text, func(s string) string {
+		return p.protect(inlineCode(reRSTLiteral.FindStringSubmatch(s)[1]))
+	})
+	text = reRSTRole.ReplaceAllStringFunc(text, func(s string) string {
+		m := reRSTRole.FindStringSubmatch(s)
+		return p.protect(rstRole(m[1], m[2]))
+	})
+	// 反斜杠转义的字符按原样输出，转义的空白直接去掉
+	text = reRSTEscape.ReplaceAllStringFunc(text, func(s string) string {
+		if strings.TrimSpace(s[1:]) == "" {
+			return ""
+		}
+		return p.protect(escapeMarkdown(s[1:]))
+	})
+	text = reRSTLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := reRSTLink.FindStringSubmatch(s)
+		target := strings.Join(strings.Fields(m[2]), "")
+		if strings.HasSuffix(target, "_") {
+			// `文字 <name_>`_ 指向命名目标
+			return p.protect(c.refLink(m[1], strings.TrimSuffix(target, "_")))
+		}
+		return p.protect(mdLink(m[1], target))
+	})
+	text = reRSTRefLink.ReplaceAllStringFunc(text, func(s string) string {
+		name := reRSTRefLink.FindStringSubmatch(s)[1]
+		return p.protect(c.refLink(name, name))
+	})
+	text = reRSTFootRef.ReplaceAllStringFunc(text, func(s string) string {
+		label := reRSTFootRef.FindStringSubmatch(s)[1]
+		if label[0] == '#' || label == "*" {
+			return ""
+		}
+		return "[" + label + "]"
+	})
+	text = reRSTSubstRef.ReplaceAllStringFunc(text, func(s string) string {
+		name := reRSTSubstRef.FindStringSubmatch(s)[1]
+		if value, ok := c.subs[name]; ok {
+			return value
+		}
+		return s
+	})
+	text = reRSTInterpret.ReplaceAllString(text, "$1")
+	text = reRSTWordRef.ReplaceAllStringFunc(text, func(s string) string {
+		m := reRSTWordRef.FindStringSubmatch(s)
+		if !c.targets[normalizeName(m[1])] {
+			return s
+		}
+		return p.protect(c.refLink(m[1], m[1])) + m[2]
+	})
+	text = stripMarkup(reRSTStrong, text)
+	text = stripMarkup(reRSTEmphasis, text)
+	return p.restore(escapeMarkdown(text))
+}
+
+// refLink 引用命名链接目标，目标未定义（如指向章节标题）时只保留文字
+func (c *rstConverter) refLink(text, name string) string {
+	if !c.targets[normalizeName(name)] {
+		return text
+	}
+	if normalizeName(text) == normalizeName(name) {
+		return "[" + text + "]"
+	}
+	return "[" + text + "][" + name + "]"
+}
+
+// rstRole 转换角色：代码类角色输出行内代码，数学公式输出 $...$，交叉引用只保留显示文字
+func rstRole(role, text string) string {
+	short := role
+	if k := strings.LastIndex(role, ":"); k >= 0 {
+		short = role[k+1:]
+	}
+	// :ref:`文字 <label>` 显示文字；:func:`~pkg.mod.name` 只显示最后一段
+	if m := reRSTTarget2.FindStringSubmatch(text); m != nil && m[1] != "" {
+		text = m[1]
+	} else if m != nil {
+		text = m[2]
+	}
+	if strings.HasPrefix(text, "~") {
+		text = text[strings.LastIndex(text, ".")+1:]
+	}
+	text = strings.TrimPrefix(text, "!")
+
+	switch {
+	case short == "math":
+		return "$" + text + "$"
+	case rstCodeRoles[short] || strings.Contains(role, ":"):
+		return inlineCode(text)
+	case short == "menuselection":
+		return strings.ReplaceAll(text, "-->", "→")
+	}
+	return text
+}
+
+// isAdornment 判断是否为标题装饰线或分隔线：由同一个标点符号组成，至少2个字符
+func isAdornment(line string) bool {
+	line = strings.TrimRight(line, " \t")
+	if len(line) < 2 {
+		return false
+	}
+	c := rune(line[0])
+	if c > unicode.MaxASCII || !unicode.IsPunct(c) && !unicode.IsSymbol(c) {
+		return false
+	}
+	return strings.Trim(line, string(c)) == ""
+}
+
+// blockEnd 返回从start开始、缩进不小于minIndent的块的结束位置，不含末尾的空行
+func blockEnd(lines []string, start, minIndent int) int {
+	end := start
+	for i := start; i < len(lines); i++ {
+		if isBlank(lines[i]) {
+			continue
+		}
+		if indentOf(lines[i]) < minIndent {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// normalizeName 规范化链接目标名：忽略大小写，连续空白视为一个空格
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,298 @@
+package markup
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// reBlobURL GitHub网页上的文件地址 github.com/<owner>/<repo>/blob/<ref>/<path>
+	reBlobURL = regexp.MustCompile(`^https?://(?:www\.)?github\.com/([^/]+)/([^/]+)/blob/(.+)$`)
+
+	// reMarkdownInline 纯文本中会被Markdown当作强调、代码、链接或HTML标签的字符，以及反斜杠本身
+	reMarkdownInline = regexp.MustCompile("[\\\\*_`\\[<]")
+	// reMarkdownBlock 位于块开头时会被Markdown当作标题、有序列表或引用的标记
+	reMarkdownBlock = regexp.MustCompile(`^\s*(?:#|\d{1,9}[.)]|>)`)
+)
+
+// hasExtension 判断URL的路径部分（忽略主机名、查询参数和锚点）是否以指定扩展名之一结尾，
+// 只有主机名的地址（如https://golang.org）不算匹配
+func hasExtension(rawURL string, extensions []string) bool {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+		if u.Opaque != "" {
+			// C:\docs\a.org这类Windows本地路径会被解析为scheme加opaque
+			path = u.Opaque
+		}
+	}
+	path = strings.ToLower(path)
+	for _, ext := range extensions {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawGitHubURL 把GitHub网页上的文件地址转换为raw.githubusercontent.com上的原始文件地址，其余地址原样返回
+func rawGitHubURL(rawURL string) string {
+	m := reBlobURL.FindStringSubmatch(rawURL)
+	if m == nil {
+		return rawURL
+	}
+	path := m[3]
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", m[1], m[2], path)
+}
+
+// builder 逐块输出Markdown，各标记语言的转换器共用，块与块之间以空行分隔
+type builder struct {
+	meta  map[string]string // 输出为YAML front matter
+	refs  []string          // 链接引用定义，输出在文末
+	lines []string
+}
+
+func newBuilder() *builder {
+	return &builder{meta: make(map[string]string)}
+}
+
+// heading 输出标题，层级超过6时按6处理
+func (b *builder) heading(level int, text string) {
+	if text = strings.TrimSpace(text); text == "" {
+		return
+	}
+	if level < 1 {
+		level = 1
+	}
+	if level > 6 {
+		level = 6
+	}
+	b.block(strings.Repeat("#", level) + " " + text)
+}
+
+// paragraph 输出段落，多行文字合并为一行
+func (b *builder) paragraph(text string) {
+	if text = strings.Join(strings.Fields(text), " "); text != "" {
+		b.block(text)
+	}
+}
+
+// listItem 输出列表项，marker为 "-" 或 "1."
+func (b *builder) listItem(marker, text string) {
+	if text = strings.Join(strings.Fields(text), " "); text != "" {
+		b.block(marker + " " + text)
+	}
+}
+
+// code 输出围栏代码块，围栏长度大于代码中最长的反引号串
+func (b *builder) code(lang, code string) {
+	code = strings.Trim(code, "\n")
+	if strings.TrimSpace(code) == "" {
+		return
+	}
+	// 语言只取第一个词，如 "python3 :linenos:" 中的 python3
+	if fields := strings.Fields(lang); len(fields) > 0 {
+		lang = strings.ToLower(fields[0])
+	}
+	fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+	b.block(fence + lang + "\n" + code + "\n" + fence)
+}
+
+// table 输出表格，第一行作为表头，单元格中的 | 需要转义
+func (b *builder) table(rows [][]string) {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	if width == 0 {
+		return
+	}
+	var lines []string
+	for i, row := range rows {
+		cells := make([]string, width)
+		for j := range cells {
+			if j < len(row) {
+				cells[j] = strings.ReplaceAll(strings.Join(strings.Fields(row[j]), " "), "|", `\|`)
+			}
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", width))
+		}
+	}
+	b.block(strings.Join(lines, "\n"))
+}
+
+// ref 登记一个链接引用定义
+func (b *builder) ref(label, target string) {
+	if label = strings.TrimSpace(label); label != "" && target != "" {
+		b.refs = append(b.refs, "["+label+"]: <"+target+">")
+	}
+}
+
+func (b *builder) block(text string) {
+	b.lines = append(b.lines, text, "")
+}
+
+// String 返回完整的Markdown文本，元数据放在开头的front matter中
+func (b *builder) String() string {
+	var out strings.Builder
+	if len(b.meta) > 0 {
+		if data, err := yaml.Marshal(b.meta); err == nil {
+			out.WriteString("---\n")
+			out.Write(data)
+			out.WriteString("---\n")
+		}
+	}
+	out.WriteString(strings.Join(b.lines, "\n"))
+	if len(b.refs) > 0 {
+		out.WriteString("\n" + strings.Join(b.refs, "\n") + "\n")
+	}
+	return out.String()
+}
+
+// inlineCode 输出行内代码，反引号数量多于代码中最长的反引号串
+func inlineCode(code string) string {
+	code = strings.Join(strings.Fields(code), " ")
+	if code == "" {
+		return ""
+	}
+	ticks := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return ticks + code + ticks
+}
+
+// mdLink 输出Markdown链接，地址中的空格和括号会破坏语法，用尖括号包围
+func mdLink(text, target string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		text = target
+	}
+	if strings.ContainsAny(target, " ()") {
+		target = "<" + target + ">"
+	}
+	return "[" + text + "](" + target + ")"
+}
+
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// indentOf 行首空白的宽度，制表符按8列对齐
+func indentOf(line string) int {
+	n := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 8 - n%8
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// isBlank 判断是否为空行
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// dedent 去掉一组行共同的最小缩进，制表符先展开
+func dedent(lines []string) []string {
+	common := -1
+	for _, line := range lines {
+		if isBlank(line) {
+			continue
+		}
+		if n := indentOf(line); common < 0 || n < common {
+			common = n
+		}
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		line = expandTabs(line)
+		if len(line) >= common && common > 0 {
+			line = line[common:]
+		} else if isBlank(line) {
+			line = ""
+		}
+		out[i] = line
+	}
+	return out
+}
+
+// expandTabs 展开行首的制表符
+func expandTabs(line string) string {
+	n := indentOf(line)
+	rest := strings.TrimLeft(line, " \t")
+	return strings.Repeat(" ", n) + rest
+}
+
+// escapeMarkdown 转义纯文本中的Markdown标记字符，经Markdown清洗后还原为原来的字符，
+// 如RST的 f(*args, **kwargs) 不会被当作强调；转换器生成的Markdown片段受protector保护，不会被转义
+func escapeMarkdown(text string) string {
+	text = reMarkdownInline.ReplaceAllString(text, `\$0`)
+	if loc := reMarkdownBlock.FindStringIndex(text); loc != nil {
+		text = text[:loc[1]-1] + `\` + text[loc[1]-1:]
+	}
+	return text
+}
+
+// stripMarkup 去掉成对的强调标记，只保留文字，re的三个分组依次为前导字符、文字和后继字符；
+// 相邻的两处标记共用中间的分隔字符，需要重复替换到不再变化
+func stripMarkup(re *regexp.Regexp, text string) string {
+	for {
+		stripped := re.ReplaceAllString(text, "$1$2$3")
+		if stripped == text {
+			return text
+		}
+		text = stripped
+	}
+}
+
+// protector 转换行内语法时保护已经生成的Markdown片段，避免被后续规则再次改写
+type protector struct {
+	tokens []string
+}
+
+var reProtected = regexp.MustCompile("\uE000(\\d+)\uE001")
+
+func (p *protector) protect(s string) string {
+	p.tokens = append(p.tokens, s)
+	return "\uE000" + strconv.Itoa(len(p.tokens)-1) + "\uE001"
+}
+
+func (p *protector) restore(text string) string {
+	for strings.Contains(text, "\uE000") {
+		restored := reProtected.ReplaceAllStringFunc(text, func(token string) string {
+			i, _ := strconv.Atoi(reProtected.FindStringSubmatch(token)[1])
+			return p.tokens[i]
+		})
+		if restored == text {
+			break
+		}
+		text = restored
+	}
+	return text
+}
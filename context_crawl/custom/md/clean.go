@@ -0,0 +1,55 @@
+package md
+
+import (
+	"strings"
+
+	"context_crawl/base/colly"
+	"context_crawl/types"
+)
+
+// MarkdownCleaner 按Markdown语法清洗文档，实现types.Cleaner接口
+// 围栏和缩进代码块连同语言放入CodeMap/CodeLang，标题转为 # 标记行供SectionChunker切分章节，
+// YAML front matter转为元数据，链接保留为 [文字](地址)
+type MarkdownCleaner struct {
+	html types.Cleaner // 下载到的是HTML页面（如GitHub的blob页面）时使用
+}
+
+// NewMarkdownCleaner 创建一个新的MarkdownCleaner实例
+func NewMarkdownCleaner() *MarkdownCleaner {
+	return &MarkdownCleaner{
+		html: colly.NewBasicCleaner(),
+	}
+}
+
+// Clean 清洗Markdown文本，实现types.Cleaner接口
+func (mc *MarkdownCleaner) Clean(input types.Type) (types.Type, error) {
+	text := strings.ReplaceAll(strings.ReplaceAll(input.Text, "\r\n", "\n"), "\r", "\n")
+	text = strings.TrimPrefix(text, "\uFEFF")
+	if IsHTMLDocument(text) {
+		return mc.html.Clean(input)
+	}
+
+	metadata, body := splitFrontMatter(text)
+	blocks, refs := parseBlocks(body)
+
+	r := newRenderer(input.Url, refs, input.Options)
+	return types.Type{
+		Url:      input.Url,
+		Text:     r.render(blocks),
+		CodeMap:  r.codeMap,
+		CodeLang: r.codeLang,
+		Options:  input.Options,
+		Outlinks: r.outlinks,
+		Images:   r.images,
+		Metadata: metadata,
+	}, nil
+}
+
+// IsHTMLDocument 判断内容是否为完整的HTML页面而不是Markdown，如下载到的GitHub blob页面
+func IsHTMLDocument(text string) bool {
+	head := strings.ToLower(strings.TrimSpace(text))
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html")
+}
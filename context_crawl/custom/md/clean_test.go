@@ -0,0 +1,185 @@
+package md
+
+import (
+	"reflect"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		want     string
+		code     map[string]string
+		lang     map[string]string
+		metadata map[string]string
+	}{
+		{
+			name:     "front matter",
+			text:     "---\ntitle: Guide\ndate: 2024-01-02\ntags: [a, b]\nauthor:\n  name: Ann\n---\n# Intro\n\nSome **bold** and _em_ text.\n",
+			want:     "# Intro\nSome bold and em text.",
+			metadata: map[string]string{"title": "Guide", "date": "2024-01-02", "tags": "a, b", "author.name": "Ann"},
+		},
+		{
+			name: "broken front matter parsed as markdown",
+			text: "---\ntitle: [unclosed\n---\nbody",
+			want: "## title: [unclosed\nbody",
+		},
+		{
+			name: "setext headings",
+			text: "Intro\n=====\n\nSub\n---\n\ntext",
+			want: "# Intro\n## Sub\ntext",
+		},
+		{
+			name: "fenced and indented code",
+			text: "```go\nfmt.Println(1)\n```\n\n    indented\n    code\n\nafter",
+			want: "@CODE_0@\n@CODE_1@\nafter",
+			code: map[string]string{"@CODE_0@": "fmt.Println(1)", "@CODE_1@": "indented\ncode"},
+			lang: map[string]string{"@CODE_0@": "go"},
+		},
+		{
+			name: "tilde fence inside a list",
+			text: "- step:\n\n  ~~~sh\n  make\n  ~~~\n- done",
+			want: "- step:\n@CODE_0@\n- done",
+			code: map[string]string{"@CODE_0@": "make"},
+			lang: map[string]string{"@CODE_0@": "sh"},
+		},
+		{
+			name: "code spans and escapes",
+			text: "Use `a*b` and \\*literal\\*",
+			want: "Use @CODE_0@ and *literal*",
+			code: map[string]string{"@CODE_0@": "a*b"},
+		},
+		{
+			name: "lists and tables",
+			text: "- one\n- two\n  cont\n1. first\n\n| a | b |\n|---|---|\n| 1 | 2 |",
+			want: "- one\n- two cont\n1. first\na | b\n1 | 2",
+		},
+		{
+			name: "quotes and comments",
+			text: "> quote\n> more\n\n<!-- hidden\n-->\nvisible <!-- inline -->",
+			want: "quote more\nvisible",
+		},
+		{
+			name: "html page",
+			text: "<!DOCTYPE html><html><body><p>hi</p></body></html>",
+			want: "hi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMarkdownCleaner().Clean(types.Type{Url: "https://example.com/docs/readme.md", Text: tt.text})
+			if err != nil {
+				t.Fatalf("Clean() error = %v", err)
+			}
+			if got.Text != tt.want {
+				t.Errorf("Clean() text = %q, want %q", got.Text, tt.want)
+			}
+			if len(got.CodeMap) > 0 || tt.code != nil {
+				if !reflect.DeepEqual(got.CodeMap, tt.code) {
+					t.Errorf("Clean() code = %q, want %q", got.CodeMap, tt.code)
+				}
+			}
+			if len(got.CodeLang) > 0 || tt.lang != nil {
+				if !reflect.DeepEqual(got.CodeLang, tt.lang) {
+					t.Errorf("Clean() lang = %q, want %q", got.CodeLang, tt.lang)
+				}
+			}
+			if len(got.Metadata) > 0 || tt.metadata != nil {
+				if !reflect.DeepEqual(got.Metadata, tt.metadata) {
+					t.Errorf("Clean() metadata = %q, want %q", got.Metadata, tt.metadata)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanLinks(t *testing.T) {
+	const text = "# See [heading link](a.md)\n\nSee [docs](guide.md) and [ref][r] and <https://x.org>.\n\n" +
+		"![logo](https://img.shields.io/badge.svg) ![chart](img/c.png)\n\n[r]: https://r.org \"T\""
+	tests := []struct {
+		name     string
+		options  types.Options
+		want     string
+		outlinks []types.Outlink
+		images   []string
+	}{
+		{
+			name: "links kept as markdown",
+			want: "# See heading link\nSee [docs](https://example.com/docs/guide.md) and [ref](https://r.org) and https://x.org.",
+		},
+		{
+			name:    "preserve links",
+			options: types.Options{PreserveLinks: true},
+			want:    "# See heading link\nSee docs[2] and ref[3] and https://x.org[1].",
+			outlinks: []types.Outlink{
+				{Index: 1, Url: "https://x.org", Text: "https://x.org"},
+				{Index: 2, Url: "https://example.com/docs/guide.md", Text: "docs", Internal: true},
+				{Index: 3, Url: "https://r.org", Text: "ref"},
+			},
+		},
+		{
+			name:    "content images only",
+			options: types.Options{ExtractImages: true},
+			want:    "# See heading link\nSee [docs](https://example.com/docs/guide.md) and [ref](https://r.org) and https://x.org.",
+			images:  []string{"https://example.com/docs/img/c.png"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMarkdownCleaner().Clean(types.Type{Url: "https://example.com/docs/readme.md", Text: text, Options: tt.options})
+			if err != nil {
+				t.Fatalf("Clean() error = %v", err)
+			}
+			if got.Text != tt.want {
+				t.Errorf("Clean() text = %q, want %q", got.Text, tt.want)
+			}
+			if !reflect.DeepEqual(got.Outlinks, tt.outlinks) {
+				t.Errorf("Clean() outlinks = %+v, want %+v", got.Outlinks, tt.outlinks)
+			}
+			var images []string
+			for _, image := range got.Images {
+				images = append(images, image.Src)
+			}
+			if !reflect.DeepEqual(images, tt.images) {
+				t.Errorf("Clean() images = %q, want %q", images, tt.images)
+			}
+		})
+	}
+}
+
+func TestIsHTMLDocument(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"<!DOCTYPE html>\n<html>", true},
+		{"  <HTML lang=\"en\">", true},
+		{"# Title\n\n<div>inline html</div>", false},
+		{"<details><summary>x</summary></details>", false},
+	}
+	for _, tt := range tests {
+		if got := IsHTMLDocument(tt.text); got != tt.want {
+			t.Errorf("IsHTMLDocument(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestIsMarkdownFile(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/README.md", true},
+		{"docs/guide.markdown", true},
+		{"notes.MDX", false},
+		{"https://example.com/page.html", false},
+	}
+	for _, tt := range tests {
+		if got := IsMarkdownFile(tt.url); got != tt.want {
+			t.Errorf("IsMarkdownFile(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -27,7 +27,9 @@ func NewMarkdownCrawler() *MarkdownCrawler {
 // Crawl 爬取单个页面，实现types.Crawler接口
 func (mc *MarkdownCrawler) Crawl(input types.Type) (types.Type, error) {
 	if IsMarkdownFile(input.Url) {
-		return mc.CrawlMarkdownFile(input.Url)
+		result, err := mc.CrawlMarkdownFile(input.Url)
+		result.Options = input.Options
+		return result, err
 	}
 	return types.Type{}, fmt.Errorf("not a markdown file: %s", input.Url)
 }
@@ -95,11 +97,9 @@ func (mc *MarkdownCrawler) downloadMarkdownFile(url string) (types.Type, error)
 
 	os.Remove(tempFile.Name())
 
-	text := fmt.Sprintf("<div>%s</div>", string(content))
-
 	return types.Type{
 		Url:  url,
-		Text: text,
+		Text: string(content),
 	}, nil
 }
 
@@ -110,10 +110,8 @@ func (mc *MarkdownCrawler) readLocalMarkdownFile(filePath string) (types.Type, e
 		return types.Type{}, fmt.Errorf("读取本地文件失败: %v", err)
 	}
 
-	text := fmt.Sprintf("<div>%s</div>", string(content))
-
 	return types.Type{
 		Url:  filePath,
-		Text: text,
+		Text: string(content),
 	}, nil
 }
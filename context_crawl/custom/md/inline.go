@@ -0,0 +1,367 @@
+package md
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"context_crawl/types"
+)
+
+var (
+	reEscape     = regexp.MustCompile("\\\\([!-/:-@\\[-`{-~])")
+	reAutolink   = regexp.MustCompile(`<((?:https?|ftp)://[^\s<>]+|mailto:[^\s<>]+)>`)
+	reHTMLImg    = regexp.MustCompile(`(?is)<img\b[^>]*>`)
+	reHTMLAnchor = regexp.MustCompile(`(?is)<a\b([^>]*)>(.*?)</a>`)
+	reAttrSrc    = regexp.MustCompile(`(?is)\bsrc\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	reAttrAlt    = regexp.MustCompile(`(?is)\balt\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	reAttrHref   = regexp.MustCompile(`(?is)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+	// 行内链接和图片的地址允许一层括号，如维基百科的链接
+	linkTarget    = `\(\s*<?([^\s()<>]*(?:\([^\s()]*\)[^\s()<>]*)*)>?(?:\s+(?:"[^"]*"|'[^']*'|\([^)]*\)))?\s*\)`
+	reInlineImage = regexp.MustCompile(`!\[([^\[\]]*)\]` + linkTarget)
+	reRefImage    = regexp.MustCompile(`!\[([^\[\]]*)\]\[([^\[\]]*)\]`)
+	reInlineLink  = regexp.MustCompile(`\[([^\[\]]*)\]` + linkTarget)
+	reRefLink     = regexp.MustCompile(`\[([^\[\]]+)\]\[([^\[\]]*)\]`)
+	reShortcut    = regexp.MustCompile(`\[([^\[\]]+)\]`)
+
+	// 只去掉常见的HTML标签，正文中的 List<T>、<T> 等保持原样；块级标签替换为空格，行内标签直接去掉
+	reHTMLBlock = regexp.MustCompile(`(?i)</?(?:blockquote|br|center|dd|details|div|dl|dt|figcaption|figure|h[1-6]|hr|li|ol|p|picture|section|source|summary|table|tbody|td|tfoot|th|thead|tr|ul|video)\b[^>]*>`)
+	reHTMLTag   = regexp.MustCompile(`(?i)</?(?:a|abbr|b|big|cite|code|del|em|font|i|img|input|ins|kbd|mark|pre|q|s|samp|small|span|strike|strong|sub|sup|tt|u|var)\b[^>]*>`)
+
+	reStrong = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	reStrike = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	reEmStar = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*`)
+	reEmLine = regexp.MustCompile(`(^|[^\w])_(\S(?:[^_]*?\S)?)_([^\w]|$)`)
+
+	reToken = regexp.MustCompile("\uE000(\\d+)\uE001")
+)
+
+// decorativeHints 地址中出现这些词的图片视为徽章、图标等装饰性图片
+var decorativeHints = []string{"shields.io", "badge", "icon", "logo", "avatar", "emoji", "spacer", "pixel"}
+
+// renderer 把块级元素渲染为SectionChunker使用的结构化文本：标题为 # 标记行，
+// 代码块为独占一行的占位符，其余每个块一行；行内的代码、链接和图片在这里处理
+type renderer struct {
+	base    *url.URL // 文档地址，用于解析相对链接，本地文件为nil
+	refs    map[string]linkRef
+	options types.Options
+
+	codeMap   map[string]string
+	codeLang  map[string]string
+	outlinks  []types.Outlink
+	linkIndex map[string]int
+	images    []types.Image
+	heading   string // 最近的标题，记录到图片上
+
+	tokens []string // 受保护的片段，渲染结束时还原，避免被强调标记等规则改写
+}
+
+func newRenderer(docURL string, refs map[string]linkRef, options types.Options) *renderer {
+	r := &renderer{
+		refs:      refs,
+		options:   options,
+		codeMap:   make(map[string]string),
+		codeLang:  make(map[string]string),
+		linkIndex: make(map[string]int),
+	}
+	if u, err := url.Parse(docURL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		r.base = u
+	}
+	return r
+}
+
+// render 渲染全部块级元素
+func (r *renderer) render(blocks []block) string {
+	var lines []string
+	for _, b := range blocks {
+		switch b.kind {
+		case blockHeading:
+			text := r.inline(b.text, true)
+			if text == "" {
+				continue
+			}
+			r.heading = text
+			lines = append(lines, strings.Repeat("#", b.level)+" "+text)
+		case blockCode:
+			if strings.TrimSpace(b.text) == "" {
+				continue
+			}
+			lines = append(lines, r.addCode(b.text, b.lang))
+		case blockTableRow:
+			cells := splitRow(b.text)
+			for i, cell := range cells {
+				cells[i] = r.inline(cell, false)
+			}
+			if row := strings.Trim(strings.Join(cells, " | "), " |"); row != "" {
+				lines = append(lines, row)
+			}
+		default:
+			if text := r.inline(b.text, false); text != "" && text != "-" {
+				lines = append(lines, text)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inline 处理一段行内内容；heading为true时链接只保留文字，不进入outlinks
+func (r *renderer) inline(text string, heading bool) string {
+	r.tokens = r.tokens[:0]
+
+	text = reEscape.ReplaceAllStringFunc(text, func(m string) string {
+		return r.protect(m[1:])
+	})
+	text = r.codeSpans(text)
+	text = reHTMLComment.ReplaceAllString(text, "")
+
+	text = reAutolink.ReplaceAllStringFunc(text, func(m string) string {
+		target := reAutolink.FindStringSubmatch(m)[1]
+		return r.link(strings.TrimPrefix(target, "mailto:"), target, heading)
+	})
+	text = reHTMLImg.ReplaceAllStringFunc(text, func(tag string) string {
+		return r.image(attr(reAttrAlt, tag), attr(reAttrSrc, tag))
+	})
+	text = reHTMLAnchor.ReplaceAllStringFunc(text, func(tag string) string {
+		m := reHTMLAnchor.FindStringSubmatch(tag)
+		inner := reHTMLTag.ReplaceAllString(reHTMLBlock.ReplaceAllString(m[2], " "), "")
+		return r.link(inner, attr(reAttrHref, m[1]), heading)
+	})
+
+	text = reInlineImage.ReplaceAllStringFunc(text, func(s string) string {
+		m := reInlineImage.FindStringSubmatch(s)
+		return r.image(m[1], m[2])
+	})
+	text = reRefImage.ReplaceAllStringFunc(text, func(s string) string {
+		m := reRefImage.FindStringSubmatch(s)
+		ref, ok := r.lookup(m[2], m[1])
+		if !ok {
+			return s
+		}
+		return r.image(m[1], ref.url)
+	})
+
+	text = reInlineLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := reInlineLink.FindStringSubmatch(s)
+		return r.link(m[1], m[2], heading)
+	})
+	text = reRefLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := reRefLink.FindStringSubmatch(s)
+		ref, ok := r.lookup(m[2], m[1])
+		if !ok {
+			return s
+		}
+		return r.link(m[1], ref.url, heading)
+	})
+	text = reShortcut.ReplaceAllStringFunc(text, func(s string) string {
+		m := reShortcut.FindStringSubmatch(s)
+		ref, ok := r.lookup("", m[1])
+		if !ok {
+			return s
+		}
+		return r.link(m[1], ref.url, heading)
+	})
+
+	text = reHTMLBlock.ReplaceAllString(text, " ")
+	text = reHTMLTag.ReplaceAllString(text, "")
+	text = reStrong.ReplaceAllString(text, "$2")
+	text = reStrike.ReplaceAllString(text, "$1")
+	text = reEmStar.ReplaceAllString(text, "$1")
+	text = reEmLine.ReplaceAllString(text, "$1$2$3")
+	text = html.UnescapeString(text)
+
+	return strings.Join(strings.Fields(r.restore(text)), " ")
+}
+
+// codeSpans 把行内代码替换为代码占位符：开始和结束的反引号串长度相同，中间可以含有较短的反引号串
+func (r *renderer) codeSpans(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		if text[i] != '`' {
+			out.WriteByte(text[i])
+			i++
+			continue
+		}
+		n := 0
+		for i+n < len(text) && text[i+n] == '`' {
+			n++
+		}
+		end := closingBackticks(text, i+n, n)
+		if end < 0 {
+			out.WriteString(text[i : i+n])
+			i += n
+			continue
+		}
+		code := text[i+n : end]
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+			code = code[1 : len(code)-1]
+		}
+		out.WriteString(r.protect(r.addCode(code, "")))
+		i = end + n
+	}
+	return out.String()
+}
+
+// closingBackticks 从from开始查找长度恰好为n的反引号串，返回其起始下标
+func closingBackticks(text string, from, n int) int {
+	for i := from; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		run := 0
+		for i+run < len(text) && text[i+run] == '`' {
+			run++
+		}
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// addCode 登记一段代码，返回其占位符
+func (r *renderer) addCode(code, lang string) string {
+	placeholder := fmt.Sprintf("@CODE_%d@", len(r.codeMap))
+	r.codeMap[placeholder] = code
+	if lang != "" {
+		r.codeLang[placeholder] = lang
+	}
+	return placeholder
+}
+
+// link 渲染一个链接：默认保留为 [文字](绝对地址)；开启PreserveLinks时与网页一致，
+// 输出「文字[n]」并收集outlinks；页内锚点和标题中的链接只保留文字
+func (r *renderer) link(text, href string, heading bool) string {
+	text = strings.TrimSpace(text)
+	href = strings.TrimSpace(html.UnescapeString(r.restore(href)))
+	if heading || href == "" || strings.HasPrefix(href, "#") {
+		return text
+	}
+
+	// 徽章等图片链接被过滤后没有文字，整个链接一起去掉
+	if text == "" {
+		return ""
+	}
+
+	target := r.resolve(href)
+	if r.options.PreserveLinks {
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return text
+		}
+		index, seen := r.linkIndex[target]
+		if !seen {
+			index = len(r.outlinks) + 1
+			r.linkIndex[target] = index
+			r.outlinks = append(r.outlinks, types.Outlink{
+				Index:    index,
+				Url:      target,
+				Text:     r.restore(text),
+				Internal: r.base != nil && strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") == strings.TrimPrefix(strings.ToLower(r.base.Hostname()), "www."),
+			})
+		}
+		return text + r.protect("["+strconv.Itoa(index)+"]")
+	}
+
+	// 自动链接 <https://...> 保留原样
+	if text == href || "mailto:"+text == href {
+		return r.protect(href)
+	}
+	return r.protect("[") + text + r.protect("]("+target+")")
+}
+
+// image 处理一张图片：开启ExtractImages时收集到images，开启ImagePlaceholders时在正文中保留 ![alt](src)
+func (r *renderer) image(alt, src string) string {
+	src = strings.TrimSpace(html.UnescapeString(r.restore(src)))
+	if src == "" || strings.HasPrefix(src, "data:") {
+		return ""
+	}
+	target := r.resolve(src)
+	lower := strings.ToLower(target)
+	for _, hint := range decorativeHints {
+		if strings.Contains(lower, hint) {
+			return ""
+		}
+	}
+
+	alt = strings.TrimSpace(html.UnescapeString(r.restore(alt)))
+	if r.options.ExtractImages || r.options.ImagePlaceholders {
+		r.images = append(r.images, types.Image{Src: target, Alt: alt, Heading: r.heading})
+	}
+	if r.options.ImagePlaceholders {
+		return r.protect("![" + alt + "](" + target + ")")
+	}
+	return ""
+}
+
+// lookup 查找链接引用定义，label为空时使用链接文字（[text][] 与 [text] 两种写法）
+func (r *renderer) lookup(label, text string) (linkRef, bool) {
+	if label == "" {
+		label = text
+	}
+	ref, ok := r.refs[normalizeLabel(r.restore(label))]
+	return ref, ok
+}
+
+// resolve 基于文档地址把相对地址解析为绝对地址，本地文件保持原样
+func (r *renderer) resolve(href string) string {
+	if r.base == nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return r.base.ResolveReference(ref).String()
+}
+
+// protect 登记受保护的片段，返回其标记
+func (r *renderer) protect(s string) string {
+	r.tokens = append(r.tokens, s)
+	return fmt.Sprintf("\uE000%d\uE001", len(r.tokens)-1)
+}
+
+// restore 还原受保护的片段，片段中可能嵌套更早登记的片段
+func (r *renderer) restore(text string) string {
+	for strings.Contains(text, "\uE000") {
+		restored := reToken.ReplaceAllStringFunc(text, func(token string) string {
+			i, _ := strconv.Atoi(reToken.FindStringSubmatch(token)[1])
+			return r.tokens[i]
+		})
+		if restored == text {
+			break
+		}
+		text = restored
+	}
+	return text
+}
+
+// splitRow 拆分表格行的单元格，\| 不作为分隔符
+func splitRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(strings.ReplaceAll(row, `\|`, "\uE002"), "|")
+	for i, cell := range cells {
+		cells[i] = strings.ReplaceAll(cell, "\uE002", "|")
+	}
+	return cells
+}
+
+// attr 从标签中取出属性值
+func attr(re *regexp.Regexp, tag string) string {
+	m := re.FindStringSubmatch(tag)
+	if m == nil {
+		return ""
+	}
+	for _, v := range m[1:] {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,369 @@
+package md
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 块级元素的种类
+const (
+	blockParagraph = iota
+	blockHeading
+	blockListItem
+	blockTableRow
+	blockCode
+)
+
+// block 解析得到的一个块级元素，除代码块外Text为未经行内处理的原文
+type block struct {
+	kind  int
+	level int    // 标题层级
+	text  string // 段落、标题、列表项（含 "- " 或 "1. " 标记）、表格行的原文
+	lang  string // 代码块的语言
+}
+
+// linkRef 链接引用定义 [label]: url "title"
+type linkRef struct {
+	url   string
+	title string
+}
+
+var (
+	reFence       = regexp.MustCompile("^( *)(`{3,}|~{3,})\\s*([^`\\s]*)")
+	reATXHeading  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	reSetextLine  = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	reThematic    = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	reListMarker  = regexp.MustCompile(`^( *)([-*+]|\d{1,9}[.)])(?:[ \t]+(.*))?$`)
+	reQuoteMarker = regexp.MustCompile(`^ {0,3}> ?`)
+	reRefDef      = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:[ \t]*<?([^\s>]+)>?(?:[ \t]+(?:"([^"]*)"|'([^']*)'|\(([^)]*)\)))?[ \t]*$`)
+	reTableDelim  = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	reHTMLComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+// parser 逐行解析Markdown的块结构：围栏和缩进代码块、ATX与Setext标题、列表、引用、表格、
+// 链接引用定义和段落，行内内容留到渲染阶段处理
+type parser struct {
+	lines  []string
+	blocks []block
+	refs   map[string]linkRef
+
+	para     []string // 正在累积的段落或列表项
+	paraKind int
+	inList   bool // 位于列表中，缩进的行是列表项的延续而不是代码块
+	blank    bool // 上一行是空行
+}
+
+// parseBlocks 解析Markdown正文（不含front matter），返回块级元素和链接引用定义
+func parseBlocks(text string) ([]block, map[string]linkRef) {
+	p := &parser{
+		lines: strings.Split(text, "\n"),
+		refs:  make(map[string]linkRef),
+	}
+	p.parse()
+	return p.blocks, p.refs
+}
+
+func (p *parser) parse() {
+	for i := 0; i < len(p.lines); i++ {
+		line, quoted := stripQuote(expandTabs(p.lines[i]))
+
+		if strings.TrimSpace(line) == "" {
+			p.flush()
+			p.blank = true
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		// 围栏代码块，列表中的代码块可以有任意缩进
+		if m := reFence.FindStringSubmatch(line); m != nil && (indent <= 3 || p.inList) && !(m[2][0] == '`' && strings.Contains(line[len(m[0]):], "`")) {
+			p.flush()
+			i = p.fencedCode(i, m, quoted)
+			p.blank = false
+			continue
+		}
+
+		// 缩进代码块：不在列表和段落中、缩进至少4个空格
+		if indent >= 4 && !p.inList && len(p.para) == 0 {
+			i = p.indentedCode(i)
+			p.blank = false
+			continue
+		}
+
+		// 跨行的HTML注释整体跳过，单行注释在行内渲染时去掉
+		if strings.HasPrefix(strings.TrimSpace(line), "<!--") && !strings.Contains(line, "-->") {
+			p.flush()
+			for i < len(p.lines)-1 && !strings.Contains(p.lines[i], "-->") {
+				i++
+			}
+			continue
+		}
+
+		if m := reATXHeading.FindStringSubmatch(line); m != nil {
+			p.flush()
+			p.inList = false
+			p.add(block{kind: blockHeading, level: len(m[1]), text: m[2]})
+			p.blank = false
+			continue
+		}
+
+		// Setext标题：段落下一行是 === 或 ---
+		if m := reSetextLine.FindStringSubmatch(line); m != nil && len(p.para) > 0 && p.paraKind == blockParagraph && !p.blank {
+			level := 1
+			if m[1][0] == '-' {
+				level = 2
+			}
+			p.add(block{kind: blockHeading, level: level, text: strings.Join(p.para, " ")})
+			p.para = nil
+			p.inList = false
+			continue
+		}
+
+		if reThematic.MatchString(line) {
+			p.flush()
+			p.inList = false
+			p.blank = false
+			continue
+		}
+
+		if m := reRefDef.FindStringSubmatch(line); m != nil && len(p.para) == 0 {
+			label := normalizeLabel(m[1])
+			if _, exists := p.refs[label]; !exists {
+				p.refs[label] = linkRef{url: m[2], title: m[3] + m[4] + m[5]}
+			}
+			p.blank = false
+			continue
+		}
+
+		if m := reListMarker.FindStringSubmatch(line); m != nil && (indent <= 3 || p.inList) && !(len(p.para) > 0 && p.paraKind == blockParagraph && m[3] == "") {
+			p.flush()
+			p.inList = true
+			marker := m[2]
+			if marker == "*" || marker == "+" {
+				marker = "-"
+			}
+			p.para = []string{marker + " " + m[3]}
+			p.paraKind = blockListItem
+			p.blank = false
+			continue
+		}
+
+		// 表格：表头行的下一行是分隔行
+		if strings.Contains(line, "|") && len(p.para) == 0 && i+1 < len(p.lines) {
+			if next, _ := stripQuote(expandTabs(p.lines[i+1])); reTableDelim.MatchString(next) && strings.Contains(next, "-") {
+				i = p.table(i)
+				p.blank = false
+				continue
+			}
+		}
+
+		// 列表之后隔空行出现的未缩进文本结束列表
+		if p.inList && p.blank && indent < 2 {
+			p.inList = false
+		}
+		if p.blank && len(p.para) > 0 {
+			p.flush()
+		}
+		if len(p.para) == 0 {
+			p.paraKind = blockParagraph
+		}
+		p.para = append(p.para, strings.TrimSpace(line))
+		p.blank = false
+	}
+	p.flush()
+}
+
+// fencedCode 读取围栏代码块直到同类且不短于开始标记的结束围栏，返回最后一行的下标
+// 未闭合的代码块延续到文末
+func (p *parser) fencedCode(start int, m []string, quoted bool) int {
+	indent, fence, lang := len(m[1]), m[2], m[3]
+	var code []string
+	i := start + 1
+	for ; i < len(p.lines); i++ {
+		line := expandTabs(p.lines[i])
+		if quoted {
+			line, _ = stripQuote(line)
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			break
+		}
+		// 去掉与开始围栏相同的缩进
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+	p.add(block{kind: blockCode, text: strings.Join(code, "\n"), lang: strings.ToLower(lang)})
+	return i
+}
+
+// indentedCode 读取缩进代码块，中间的空行属于代码块，返回最后一行的下标
+func (p *parser) indentedCode(start int) int {
+	var code []string
+	i := start
+	for ; i < len(p.lines); i++ {
+		line := expandTabs(p.lines[i])
+		if strings.TrimSpace(line) == "" {
+			code = append(code, "")
+			continue
+		}
+		if !strings.HasPrefix(line, "    ") {
+			break
+		}
+		code = append(code, line[4:])
+	}
+	for len(code) > 0 && code[len(code)-1] == "" {
+		code = code[:len(code)-1]
+	}
+	p.add(block{kind: blockCode, text: strings.Join(code, "\n")})
+	return i - 1
+}
+
+// table 读取表格，跳过分隔行，每行输出为以 | 分隔的单元格，返回最后一行的下标
+func (p *parser) table(start int) int {
+	header, _ := stripQuote(p.lines[start])
+	p.add(block{kind: blockTableRow, text: header})
+	i := start + 2
+	for ; i < len(p.lines); i++ {
+		line, _ := stripQuote(p.lines[i])
+		if strings.TrimSpace(line) == "" || !strings.Contains(line, "|") {
+			break
+		}
+		p.add(block{kind: blockTableRow, text: line})
+	}
+	return i - 1
+}
+
+// flush 结束正在累积的段落或列表项
+func (p *parser) flush() {
+	if len(p.para) == 0 {
+		return
+	}
+	p.add(block{kind: p.paraKind, text: strings.Join(p.para, " ")})
+	p.para = nil
+}
+
+func (p *parser) add(b block) {
+	p.blocks = append(p.blocks, b)
+}
+
+// stripQuote 去掉行首的引用标记（可以嵌套），引用内的内容按普通块解析
+func stripQuote(line string) (string, bool) {
+	quoted := false
+	for {
+		loc := reQuoteMarker.FindStringIndex(line)
+		if loc == nil {
+			return line, quoted
+		}
+		line = line[loc[1]:]
+		quoted = true
+	}
+}
+
+// expandTabs 将行首的制表符展开为4个空格，便于判断缩进
+func expandTabs(line string) string {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+	if !strings.Contains(line[:i], "\t") {
+		return line
+	}
+	var prefix strings.Builder
+	for _, c := range line[:i] {
+		if c == '\t' {
+			prefix.WriteString(strings.Repeat(" ", 4-prefix.Len()%4))
+		} else {
+			prefix.WriteByte(' ')
+		}
+	}
+	return prefix.String() + line[i:]
+}
+
+// normalizeLabel 链接引用的标签不区分大小写，连续空白视为一个空格
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// splitFrontMatter 分离文件开头的YAML front matter（--- 包围），返回元数据和剩余正文
+// front matter无法解析时保留原文
+func splitFrontMatter(text string) (map[string]string, string) {
+	if !strings.HasPrefix(text, "---\n") {
+		return nil, text
+	}
+	rest := text[len("---\n"):]
+	end := -1
+	for _, closing := range []string{"\n---\n", "\n...\n"} {
+		if i := strings.Index(rest, closing); i >= 0 && (end < 0 || i < end) {
+			end = i
+		}
+	}
+	body := ""
+	switch {
+	case end >= 0:
+		body = rest[end+len("\n---\n"):]
+	case strings.HasSuffix(rest, "\n---") || strings.HasSuffix(rest, "\n..."):
+		end = len(rest) - len("\n---")
+	default:
+		return nil, text
+	}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(rest[:end]), &values); err != nil || len(values) == 0 {
+		return nil, text
+	}
+	metadata := make(map[string]string)
+	flattenYAML("", values, metadata)
+	return metadata, body
+}
+
+// flattenYAML 把front matter展开为字符串键值：嵌套映射的键以 . 连接，标量列表以 ", " 连接
+func flattenYAML(prefix string, value interface{}, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			flattenYAML(name, v[key], out)
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for i, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				flattenYAML(fmt.Sprintf("%s.%d", prefix, i), item, out)
+			default:
+				if item != nil {
+					items = append(items, fmt.Sprint(item))
+				}
+			}
+		}
+		if len(items) > 0 {
+			out[prefix] = strings.Join(items, ", ")
+		}
+	case time.Time:
+		// 只有日期的值（如 date: 2024-01-02）不补时间部分
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Location() == time.UTC {
+			out[prefix] = v.Format("2006-01-02")
+		} else {
+			out[prefix] = v.Format(time.RFC3339)
+		}
+	case nil:
+	default:
+		if s := strings.TrimSpace(fmt.Sprint(v)); s != "" && prefix != "" {
+			out[prefix] = s
+		}
+	}
+}
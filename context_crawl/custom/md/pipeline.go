@@ -2,6 +2,7 @@ package md
 
 import (
 	"context_crawl/base/colly"
+	"context_crawl/base/normalize"
 	"context_crawl/types"
 )
 
@@ -15,9 +16,9 @@ type MarkdownPipeline struct {
 func NewMarkdownPipeline() *MarkdownPipeline {
 	// 自定义markdown抓取逻辑
 	crawler := NewMarkdownCrawler()
-	// 复用 colly 其余组件
-	cleaner := colly.NewBasicCleaner()
-	chunker := colly.NewScoredChunker(0.0) // 设置scoreThreshold为0.0
+	// 按Markdown语法清洗，复用 colly 的分块组件
+	cleaner := normalize.NewCleaner(NewMarkdownCleaner(), normalize.DefaultOptions())
+	chunker := colly.NewSectionChunker(0.2) // 按标题结构分块，文档中列表、表格较多，阈值放低
 
 	return &MarkdownPipeline{
 		Crawler: crawler,
@@ -1,11 +1,23 @@
 package pdf
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	"context_crawl/types"
 )
 
+var (
+	// rePageMarker 单独一行的页码标记，见types.PageMarker
+	rePageMarker = regexp.MustCompile(`^@PAGE_\d+@$`)
+	// reCodeLine 单独一行的代码块占位符
+	reCodeLine = regexp.MustCompile(`^@CODE_\d+@$`)
+)
+
+// codeFence 版面重建输出的代码围栏，围住连续的等宽字体行
+const codeFence = "```"
+
 // PDFCleaner 负责清洗PDF文本
 type PDFCleaner struct {}
 
@@ -16,23 +28,54 @@ func NewPDFCleaner() *PDFCleaner {
 
 // Clean 清洗PDF文本，实现types.Cleaner接口
 func (c *PDFCleaner) Clean(input types.Type) (types.Type, error) {
-	text := input.Text
+	// 等宽字体的代码行放入CodeMap，不参与空白合并和页眉页脚检测
+	text, codeMap := c.extractCode(input.Text)
 
 	// 移除多余的空行
 	text = c.removeExtraEmptyLines(text)
 
-	// 移除多余的空格
+	// 移除多余的空格，段落、标题和页码标记各占一行
 	text = c.removeExtraSpaces(text)
 
-	// 移除PDF特有的标记和噪声
+	// 移除页眉、页脚、页码和水印
 	text = c.removePDFSpecificNoise(text)
 
 	return types.Type{
-		Url:  input.Url,
-		Text: text,
+		Url:      input.Url,
+		Text:     text,
+		Options:  input.Options,
+		CodeMap:  codeMap,
+		Metadata: input.Metadata,
+		Toc:      input.Toc,
 	}, nil
 }
 
+// extractCode 将代码围栏中的行替换为单独一行的占位符，返回替换后的文本和占位符到代码的映射
+func (c *PDFCleaner) extractCode(text string) (string, map[string]string) {
+	codeMap := make(map[string]string)
+	var out, code []string
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == codeFence {
+			if inCode && len(code) > 0 {
+				placeholder := fmt.Sprintf("@CODE_%d@", len(codeMap))
+				codeMap[placeholder] = strings.Join(code, "\n")
+				out = append(out, placeholder)
+			}
+			inCode, code = !inCode, nil
+			continue
+		}
+		if inCode {
+			code = append(code, line)
+			continue
+		}
+		out = append(out, line)
+	}
+	// 未闭合的围栏按普通文本处理
+	out = append(out, code...)
+	return strings.Join(out, "\n"), codeMap
+}
+
 // removeExtraEmptyLines 移除多余的空行
 func (c *PDFCleaner) removeExtraEmptyLines(text string) string {
 	lines := strings.Split(text, "\n")
@@ -48,23 +91,16 @@ func (c *PDFCleaner) removeExtraEmptyLines(text string) string {
 	return strings.Join(cleanedLines, "\n")
 }
 
-// removeExtraSpaces 移除多余的空格
+// removeExtraSpaces 移除多余的空格：每行内的连续空白合并为一个空格，保留行结构
 func (c *PDFCleaner) removeExtraSpaces(text string) string {
-	return strings.Join(strings.Fields(text), " ")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
 }
 
-// removePDFSpecificNoise 移除PDF特有的标记和噪声
+// removePDFSpecificNoise 移除PDF特有的噪声：页眉、页脚、页码和水印，见removeRunningText
 func (c *PDFCleaner) removePDFSpecificNoise(text string) string {
-	// 移除常见的PDF标记
-	noisePatterns := []string{
-		"[PDF文本提取功能需要PDF处理库支持]",
-		"Page ",
-		"PDF",
-	}
-
-	for _, pattern := range noisePatterns {
-		text = strings.ReplaceAll(text, pattern, "")
-	}
-
-	return text
+	return removeRunningText(text)
 }
@@ -7,11 +7,39 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"context_crawl/types"
+	"context_crawl/utils"
 )
 
+// sniffLength 识别文件类型时读取的文件头长度，%PDF- 标记之前允许有少量无关字节
+const sniffLength = utils.PDFSniffLength
+
+// DefaultMaxDownloadSize 默认的PDF下载大小上限
+const DefaultMaxDownloadSize int64 = 50 << 20
+
+// maxDownloadSize 当前的下载大小上限，服务启动时从配置加载
+var maxDownloadSize atomic.Int64
+
+func init() {
+	maxDownloadSize.Store(DefaultMaxDownloadSize)
+}
+
+// SetMaxDownloadSize 设置PDF下载大小上限（字节），不大于0时恢复默认值
+func SetMaxDownloadSize(size int64) {
+	if size <= 0 {
+		size = DefaultMaxDownloadSize
+	}
+	maxDownloadSize.Store(size)
+}
+
+// MaxDownloadSize 返回当前的PDF下载大小上限（字节）
+func MaxDownloadSize() int64 {
+	return maxDownloadSize.Load()
+}
+
 // PDFCrawler 负责爬取PDF文件
 type PDFCrawler struct {
 	TempDir string
@@ -25,33 +53,44 @@ func NewPDFCrawler() *PDFCrawler {
 }
 
 // Crawl 爬取单个PDF文件，实现types.Crawler接口
+// 按文件头识别PDF，链接不必以.pdf结尾（如出版商的全文链接），内容不是PDF时返回 pdf_not_pdf 错误
 func (pc *PDFCrawler) Crawl(input types.Type) (types.Type, error) {
-	if IsPDFFile(input.Url) {
-		return pc.CrawlPDFFile(input.Url)
-	}
-	return types.Type{}, fmt.Errorf("not a PDF file: %s", input.Url)
+	result, err := pc.CrawlPDFFile(input.Url, input.Options)
+	result.Options = input.Options
+	return result, err
 }
 
-// CrawlPDFFile 爬取单个PDF文件
-func (pc *PDFCrawler) CrawlPDFFile(url string) (types.Type, error) {
+// CrawlPDFFile 爬取单个PDF文件，options.Pages为页码范围，为空时提取全部页面；
+// 加密文件依次尝试空密码、options.Password和站点规则中的pdf_password
+func (pc *PDFCrawler) CrawlPDFFile(url string, options types.Options) (types.Type, error) {
+	passwords := []string{options.Password}
+	if rule, ok := utils.MatchSiteRule(url); ok {
+		passwords = append(passwords, rule.PDFPassword)
+	}
+
 	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
-		return pc.downloadPDFFile(url)
-	} else {
-		return pc.readLocalPDFFile(url)
+		return pc.downloadPDFFile(url, options, passwords)
 	}
+	return pc.readLocalPDFFile(url, options.Pages, passwords)
 }
 
 // downloadPDFFile 下载远程PDF文件
-func (pc *PDFCrawler) downloadPDFFile(url string) (types.Type, error) {
+// 按文件头的 %PDF- 标记识别PDF，不依赖Content-Type；超过下载大小上限时立即中止，临时文件总会被删除
+func (pc *PDFCrawler) downloadPDFFile(url string, options types.Options, passwords []string) (types.Type, error) {
 	// 创建临时文件
 	tempFile, err := os.CreateTemp(pc.TempDir, "pdf_*.pdf")
 	if err != nil {
 		return types.Type{}, fmt.Errorf("创建临时文件失败: %v", err)
 	}
-	defer tempFile.Close()
-
-	// 设置请求上下文和超时
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer func() {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+	}()
+
+	// 设置请求上下文和超时，请求的截止时间更早时以截止时间为准
+	parent, cancelParent := options.Context()
+	defer cancelParent()
+	ctx, cancel := context.WithTimeout(parent, 30*time.Second)
 	defer cancel()
 
 	// 创建HTTP请求
@@ -63,57 +102,74 @@ func (pc *PDFCrawler) downloadPDFFile(url string) (types.Type, error) {
 	// 执行请求
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return types.Type{}, fmt.Errorf("HTTP请求失败: %v", err)
+		return types.Type{}, downloadError("HTTP请求失败: %v", err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
-		return types.Type{}, fmt.Errorf("HTTP请求失败，状态码: %d", resp.StatusCode)
+		return types.Type{}, downloadError("HTTP请求失败，状态码: %d", resp.StatusCode)
 	}
 
-	// 检查Content-Type是否为PDF
-	contentType := resp.Header.Get("Content-Type")
-	if contentType != "application/pdf" && !strings.Contains(contentType, "pdf") {
-		return types.Type{}, fmt.Errorf("不是PDF文件，Content-Type: %s", contentType)
+	// 声明的长度已超过上限时不再下载
+	limit := MaxDownloadSize()
+	if resp.ContentLength > limit {
+		return types.Type{}, tooLargeError(limit)
 	}
 
-	// 下载文件内容
-	_, err = io.Copy(tempFile, resp.Body)
-	if err != nil {
-		return types.Type{}, fmt.Errorf("写入临时文件失败: %v", err)
+	// 读取文件头判断是否为PDF
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(resp.Body, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return types.Type{}, downloadError("读取响应失败: %v", err)
+	}
+	head = head[:n]
+	if !IsPDFContent(head) {
+		return types.Type{}, types.NewError(ErrCodeNotPDF, "不是PDF文件，Content-Type: "+resp.Header.Get("Content-Type"), false, nil)
 	}
 
-	// 提取PDF文本
-	text, err := ExtractTextFromPDF(tempFile.Name())
+	// 下载文件内容，多读一个字节用于判断是否超过上限
+	if _, err := tempFile.Write(head); err != nil {
+		return types.Type{}, fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	written, err := io.Copy(tempFile, io.LimitReader(resp.Body, limit-int64(n)+1))
 	if err != nil {
-		return types.Type{}, fmt.Errorf("提取PDF文本失败: %v", err)
+		return types.Type{}, downloadError("下载PDF文件失败: %v", err)
+	}
+	if int64(n)+written > limit {
+		return types.Type{}, tooLargeError(limit)
 	}
 
-	// 删除临时文件
-	os.Remove(tempFile.Name())
+	// 提取PDF文本、元数据和目录
+	doc, err := ExtractPDF(tempFile.Name(), options.Pages, passwords...)
+	if err != nil {
+		return types.Type{}, err
+	}
 
-	return types.Type{
-		Url:  url,
-		Text: text,
-	}, nil
+	doc.Url = url
+	return doc, nil
 }
 
 // readLocalPDFFile 读取本地PDF文件
-func (pc *PDFCrawler) readLocalPDFFile(filePath string) (types.Type, error) {
+func (pc *PDFCrawler) readLocalPDFFile(filePath string, pages string, passwords []string) (types.Type, error) {
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return types.Type{}, fmt.Errorf("文件不存在: %s", filePath)
 	}
 
-	// 提取PDF文本
-	text, err := ExtractTextFromPDF(filePath)
+	// 检查文件头
+	if ok, err := isPDFFileContent(filePath); err != nil {
+		return types.Type{}, fmt.Errorf("读取文件失败: %v", err)
+	} else if !ok {
+		return types.Type{}, types.NewError(ErrCodeNotPDF, "不是PDF文件: "+filePath, false, nil)
+	}
+
+	// 提取PDF文本、元数据和目录
+	doc, err := ExtractPDF(filePath, pages, passwords...)
 	if err != nil {
-		return types.Type{}, fmt.Errorf("提取PDF文本失败: %v", err)
+		return types.Type{}, err
 	}
 
-	return types.Type{
-		Url:  filePath,
-		Text: text,
-	}, nil
+	doc.Url = filePath
+	return doc, nil
 }
@@ -0,0 +1,113 @@
+package pdf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestIsPDFFile(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/paper.pdf", true},
+		{"https://example.com/Paper.PDF", true},
+		{"https://example.com/a.pdf?dl=1", true},
+		{"https://example.com/a.pdf#page=3", true},
+		{"https://arxiv.org/pdf/2301.00001", true},
+		{"/home/user/report.pdf", true},
+		{"https://example.com/download?file=a.pdf", false},
+		{"https://pdf.example.com/", false},
+		{"https://example.com/docs", false},
+	}
+	for _, tt := range tests {
+		if got := IsPDFFile(tt.url); got != tt.want {
+			t.Errorf("IsPDFFile(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadPDFFile(t *testing.T) {
+	valid := pagePDF("")
+	big := make([]byte, 8<<20)
+	copy(big, valid)
+
+	mux := http.NewServeMux()
+	// 没有.pdf扩展名、Content-Type为octet-stream的下载链接
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(valid)
+	})
+	mux.HandleFunc("/page.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("<html><body>Sign in to download</body></html>"))
+	})
+	mux.HandleFunc("/declared", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(big)))
+		w.Write(big)
+	})
+	// 不声明长度，边下载边判断是否超过上限
+	streamed := make(chan int, 1)
+	mux.HandleFunc("/streamed", func(w http.ResponseWriter, r *http.Request) {
+		total := 0
+		defer func() { streamed <- total }()
+		for total < len(big) {
+			n, err := w.Write(big[total : total+32<<10])
+			total += n
+			if err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/missing.pdf", http.NotFound)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	SetMaxDownloadSize(64 << 10)
+	defer SetMaxDownloadSize(0)
+
+	tests := []struct {
+		path     string
+		wantCode string
+	}{
+		// 空白页没有文字，说明已按文件头识别为PDF并完成解析
+		{"/download", ErrCodeNoText},
+		{"/page.pdf", ErrCodeNotPDF},
+		{"/declared", ErrCodeTooLarge},
+		{"/streamed", ErrCodeTooLarge},
+		{"/missing.pdf", ErrCodeDownload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			crawler := &PDFCrawler{TempDir: t.TempDir()}
+			_, err := crawler.Crawl(types.Type{Url: server.URL + tt.path})
+
+			var typed *types.Error
+			if !errors.As(err, &typed) || typed.Code != tt.wantCode {
+				t.Fatalf("Crawl() error = %v, want code %s", err, tt.wantCode)
+			}
+			if tt.wantCode == ErrCodeTooLarge && !errors.Is(err, ErrTooLarge) {
+				t.Errorf("Crawl() error = %v, want errors.Is(err, ErrTooLarge)", err)
+			}
+			entries, err := os.ReadDir(crawler.TempDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("temp files left behind: %v", entries)
+			}
+		})
+	}
+
+	// 超过上限后立即中止下载，服务端不会把整个文件发完
+	if total := <-streamed; total >= len(big) {
+		t.Errorf("streamed download read %d bytes, want it aborted before %d", total, len(big))
+	}
+}
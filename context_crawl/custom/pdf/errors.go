@@ -0,0 +1,40 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+
+	"context_crawl/types"
+)
+
+// PDF处理的错误码，见types.Error
+const (
+	ErrCodeDownload              = "pdf_download_failed"        // 下载失败，其他pipeline可能仍能抓取
+	ErrCodeNotPDF                = "pdf_not_pdf"                // 内容不是PDF，交给其他pipeline处理
+	ErrCodeTooLarge              = "pdf_too_large"              // 超过下载大小上限
+	ErrCodeEncrypted             = "pdf_encrypted"              // 加密文件，没有提供正确的密码
+	ErrCodeUnsupportedEncryption = "pdf_unsupported_encryption" // 加密方式不受支持（如AES-256），提供密码也无法打开
+	ErrCodeMalformed             = "pdf_malformed"              // 文件损坏且无法修复
+	ErrCodeNoText                = "pdf_no_text"                // 没有可提取的文字，如扫描件
+	ErrCodePages                 = "pdf_invalid_pages"          // 页码范围无效
+)
+
+// ErrTooLarge PDF文件超过下载大小上限，可用errors.Is判断
+var ErrTooLarge = errors.New(ErrCodeTooLarge)
+
+func tooLargeError(limit int64) error {
+	return types.NewError(ErrCodeTooLarge, fmt.Sprintf("PDF文件超过下载大小上限 %d 字节", limit), true, ErrTooLarge)
+}
+
+func encryptedError(err error) error {
+	return types.NewError(ErrCodeEncrypted, "PDF文件已加密，需要提供正确的密码", true, err)
+}
+
+func unsupportedEncryptionError(err error) error {
+	return types.NewError(ErrCodeUnsupportedEncryption, fmt.Sprintf("PDF文件的加密方式不受支持: %v", err), true, err)
+}
+
+// downloadError 下载阶段的错误，允许其他pipeline继续尝试
+func downloadError(format string, args ...interface{}) error {
+	return types.NewError(ErrCodeDownload, fmt.Sprintf(format, args...), false, nil)
+}
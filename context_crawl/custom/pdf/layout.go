@@ -0,0 +1,531 @@
+package pdf
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"context_crawl/types"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// 版面重建的阈值，均以字号为单位
+const (
+	lineTolerance   = 0.5  // 基线相差不超过该值的字形属于同一行
+	wordGap         = 0.12 // 字形间距超过该值时补空格
+	fragmentGap     = 1.2  // 同一行内间距超过该值时切成两段（分栏的栏间距）
+	paragraphGap    = 1.4  // 行距超过常规行距的该倍数时分段
+	indentThreshold = 1.0  // 首行缩进超过该值时分段
+)
+
+// reSectionNumber 带编号的章节标题，如 "1 Introduction"、"3.2 Results"
+var reSectionNumber = regexp.MustCompile(`^(\d+(?:\.\d+)*)\.?\s+\S`)
+
+// glyph 页面上的一个字形
+type glyph struct {
+	x, y, w float64
+	size    float64
+	font    string
+	s       string
+}
+
+// textLine 同一基线上连续的一段文字（分栏时左右两栏是不同的textLine）
+type textLine struct {
+	x0, x1, y float64
+	size      float64
+	bold      bool
+	mono      bool // 等宽字体，通常是代码
+	text      string
+}
+
+// paragraph 版面重建后的一个段落
+type paragraph struct {
+	lines []textLine
+	size  float64
+	bold  bool
+	mono  bool // 等宽字体的代码行
+	text  string
+}
+
+// pageLayout 一页的版面重建结果
+type pageLayout struct {
+	paragraphs []paragraph
+	sizes      map[float64]int // 字号 -> 字形数，用于确定正文字号
+}
+
+// readLayout 用字形坐标重建页面的阅读顺序：按基线合并成行，检测分栏，再按行距、缩进和字号切分段落
+// 解析失败或页面没有文字时返回false，调用方应退回到GetPlainText
+func readLayout(page pdf.Page) (layout pageLayout, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+		}
+	}()
+
+	var glyphs []glyph
+	zeroWidth := 0
+	for _, t := range page.Content().Text {
+		if t.S == "" || t.FontSize <= 0 {
+			continue
+		}
+		if t.W <= 0 {
+			zeroWidth++
+			if !visibleZeroWidth(t.S) {
+				continue
+			}
+		}
+		glyphs = append(glyphs, glyph{x: t.X, y: t.Y, w: t.W, size: t.FontSize, font: t.Font, s: t.S})
+	}
+	// 字体缺少字宽表（标准14字体、部分中日韩CID字体）时所有字形挤在同一位置，无法按坐标重建
+	if len(glyphs) == 0 || float64(zeroWidth) > 0.2*float64(len(glyphs)) {
+		return pageLayout{}, false
+	}
+
+	layout.sizes = make(map[float64]int)
+	for _, g := range glyphs {
+		if strings.TrimSpace(g.s) != "" {
+			layout.sizes[math.Round(g.size*2)/2]++
+		}
+	}
+
+	lines := groupLines(glyphs)
+	layout.paragraphs = buildParagraphs(readingOrder(lines))
+	return layout, len(layout.paragraphs) > 0
+}
+
+// plainLayout 无法按字形重建时的退路：用GetPlainText取出整页文本，合并为一个段落
+func plainLayout(page pdf.Page) (pageLayout, bool) {
+	text, err := page.GetPlainText(nil)
+	if err != nil {
+		return pageLayout{}, false
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return pageLayout{}, false
+	}
+	return pageLayout{paragraphs: []paragraph{{text: text}}}, true
+}
+
+// groupLines 将字形按基线合并成行，行内按大的水平间距切成若干段
+func groupLines(glyphs []glyph) []textLine {
+	sort.SliceStable(glyphs, func(i, j int) bool {
+		if math.Abs(glyphs[i].y-glyphs[j].y) > 0.01 {
+			return glyphs[i].y > glyphs[j].y
+		}
+		return glyphs[i].x < glyphs[j].x
+	})
+
+	var lines []textLine
+	var row []glyph
+	flush := func() {
+		if len(row) == 0 {
+			return
+		}
+		sort.SliceStable(row, func(i, j int) bool { return row[i].x < row[j].x })
+		lines = append(lines, splitRow(row)...)
+		row = nil
+	}
+	for _, g := range glyphs {
+		if len(row) > 0 && math.Abs(row[0].y-g.y) > lineTolerance*math.Max(row[0].size, g.size) {
+			flush()
+		}
+		row = append(row, g)
+	}
+	flush()
+	return lines
+}
+
+// splitRow 将同一基线上的字形拼接成文字，间距较大处补空格，栏间距处切开
+func splitRow(row []glyph) []textLine {
+	var lines []textLine
+	var b strings.Builder
+	var current textLine
+	var sizes, bolds, monos, count float64
+	prevEnd := math.Inf(-1)
+	var prev *glyph
+	space := false
+
+	emit := func() {
+		text := strings.TrimSpace(b.String())
+		if text != "" {
+			current.text = text
+			current.size = sizes / count
+			current.bold = bolds > count/2
+			current.mono = monos > count/2
+			lines = append(lines, current)
+		}
+		b.Reset()
+		sizes, bolds, monos, count = 0, 0, 0, 0
+		prevEnd = math.Inf(-1)
+		prev = nil
+		space = false
+	}
+
+	for i := range row {
+		g := &row[i]
+		if strings.TrimSpace(g.s) == "" {
+			space = true
+			continue
+		}
+		// 加粗效果常通过在相近位置重复绘制同一字形实现
+		if prev != nil && prev.s == g.s && math.Abs(prev.x-g.x) < 0.1*g.size {
+			continue
+		}
+		if prev != nil {
+			gap := g.x - prevEnd
+			if gap > fragmentGap*g.size {
+				current.x1 = prevEnd
+				emit()
+			} else if space || gap > wordGap*g.size {
+				b.WriteByte(' ')
+			}
+		}
+		if b.Len() == 0 {
+			current = textLine{x0: g.x, y: g.y}
+		}
+		b.WriteString(g.s)
+		sizes += g.size
+		if isBoldFont(g.font) {
+			bolds++
+		}
+		if isMonoFont(g.font) {
+			monos++
+		}
+		count++
+		prevEnd = g.x + g.w
+		current.x1 = prevEnd
+		prev = g
+		space = false
+	}
+	emit()
+	return lines
+}
+
+// readingOrder 检测双栏版面并按阅读顺序排列各行：
+// 横跨栏间距的行（标题、摘要、通栏图表说明）打断分栏，其前面的内容按先左栏后右栏输出
+func readingOrder(lines []textLine) []textLine {
+	gutter, ok := findGutter(lines)
+	if !ok {
+		return lines
+	}
+
+	var ordered, left, right []textLine
+	flush := func() {
+		ordered = append(ordered, left...)
+		ordered = append(ordered, right...)
+		left, right = nil, nil
+	}
+	for _, line := range lines {
+		switch {
+		case line.x1 <= gutter:
+			left = append(left, line)
+		case line.x0 >= gutter:
+			right = append(right, line)
+		default:
+			flush()
+			ordered = append(ordered, line)
+		}
+	}
+	flush()
+	return ordered
+}
+
+// findGutter 在页面中部寻找几乎没有文字跨过的竖直空白带，作为栏间距
+func findGutter(lines []textLine) (float64, bool) {
+	if len(lines) < 10 {
+		return 0, false
+	}
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	for _, l := range lines {
+		minX = math.Min(minX, l.x0)
+		maxX = math.Max(maxX, l.x1)
+	}
+	width := maxX - minX
+	if width <= 0 {
+		return 0, false
+	}
+
+	best, bestCount := 0.0, len(lines)+1
+	for x := minX + 0.35*width; x <= minX+0.65*width; x++ {
+		count := 0
+		for _, l := range lines {
+			if l.x0 < x && l.x1 > x {
+				count++
+			}
+		}
+		if count < bestCount {
+			best, bestCount = x, count
+		}
+	}
+	if float64(bestCount) > 0.1*float64(len(lines)) {
+		return 0, false
+	}
+
+	// 两侧都要有足够多的行才算双栏
+	leftCount, rightCount := 0, 0
+	for _, l := range lines {
+		if l.x1 <= best {
+			leftCount++
+		} else if l.x0 >= best {
+			rightCount++
+		}
+	}
+	if float64(leftCount) < 0.2*float64(len(lines)) || float64(rightCount) < 0.2*float64(len(lines)) {
+		return 0, false
+	}
+	return best, true
+}
+
+// buildParagraphs 按行距、缩进、字号和栏的切换将行合并为段落，并修复行尾连字符
+func buildParagraphs(lines []textLine) []paragraph {
+	spacing := typicalSpacing(lines)
+
+	var paragraphs []paragraph
+	var current []textLine
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		p := paragraph{lines: current}
+		var sizes float64
+		bold, mono := 0, 0
+		texts := make([]string, 0, len(current))
+		for _, l := range current {
+			sizes += l.size
+			if l.bold {
+				bold++
+			}
+			if l.mono {
+				mono++
+			}
+			texts = append(texts, l.text)
+		}
+		p.size = sizes / float64(len(current))
+		p.bold = bold*2 > len(current)
+		p.mono = mono == len(current)
+		p.text = joinLines(texts)
+		paragraphs = append(paragraphs, p)
+		current = nil
+	}
+
+	for _, line := range lines {
+		if len(current) > 0 {
+			prev := current[len(current)-1]
+			gap := prev.y - line.y
+			newParagraph := gap <= 0 || // 回到上方，说明换栏了
+				(spacing > 0 && gap > paragraphGap*spacing) ||
+				math.Abs(line.size-prev.size) > 0.1*prev.size ||
+				line.bold != prev.bold ||
+				line.mono || prev.mono || // 代码逐行保留
+				startsWithBullet(line.text) ||
+				(line.x0-leftEdge(current) > indentThreshold*line.size && !startsWithBullet(current[0].text)) // 列表项的悬挂缩进不分段
+			if newParagraph {
+				flush()
+			}
+		}
+		current = append(current, line)
+	}
+	flush()
+	return paragraphs
+}
+
+// leftEdge 段落各行的左边界，用于判断下一行是否首行缩进
+func leftEdge(lines []textLine) float64 {
+	left := math.Inf(1)
+	for _, l := range lines {
+		left = math.Min(left, l.x0)
+	}
+	return left
+}
+
+// typicalSpacing 相邻行之间最常见的行距（中位数）
+func typicalSpacing(lines []textLine) float64 {
+	var gaps []float64
+	for i := 1; i < len(lines); i++ {
+		if gap := lines[i-1].y - lines[i].y; gap > 0 && gap < 3*lines[i].size {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+	sort.Float64s(gaps)
+	return gaps[len(gaps)/2]
+}
+
+// joinLines 将段落内的各行拼接起来：行尾连字符后接小写字母时去掉连字符直接拼接，
+// 中文之间不加空格，其余补一个空格
+func joinLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i == 0 {
+			b.WriteString(line)
+			continue
+		}
+		prev := b.String()
+		last, _ := utf8.DecodeLastRuneInString(prev)
+		first, _ := utf8.DecodeRuneInString(line)
+		switch {
+		case last == '-' || last == '\u00ad':
+			trimmed := strings.TrimRight(prev, "-\u00ad")
+			before, _ := utf8.DecodeLastRuneInString(trimmed)
+			if unicode.IsLetter(before) && unicode.IsLower(first) {
+				b.Reset()
+				b.WriteString(trimmed)
+			}
+		case isCJKRune(last) && isCJKRune(first):
+		default:
+			b.WriteByte(' ')
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// renderLayout 将各页的版面输出为文本：每页之前是页码标记，每个段落一行，连续的等宽字体行放在 ``` 代码围栏中。
+// 文档有书签时以书签为章节标题：与书签标题相同的段落输出为对应层级的 # 标题，
+// 页内找不到的书签标题插在该页开头；没有书签时，字号明显大于正文或加粗的带编号短行输出为 # 标题
+func renderLayout(pages []int, layouts []pageLayout, toc []types.TocEntry) string {
+	body := bodySize(layouts)
+	outline := outlineHeadings(toc)
+	var b strings.Builder
+	inCode := false
+	writeLine := func(line string, mono bool) {
+		if mono != inCode {
+			b.WriteString(codeFence + "\n")
+			inCode = mono
+		}
+		b.WriteString(line)
+	}
+	for i, layout := range layouts {
+		b.WriteString(types.PageMarker(pages[i]) + "\n")
+		if outline == nil {
+			for _, p := range layout.paragraphs {
+				if level := headingLevel(p, body); level > 0 && !p.mono {
+					writeLine(headingLine(level, p.text), false)
+					continue
+				}
+				writeLine(p.text+"\n", p.mono)
+			}
+			writeLine("", false) // 代码围栏不跨页，页码标记保持在围栏外
+			continue
+		}
+
+		if i == 0 {
+			for _, entry := range enclosingHeadings(toc, pages[i]) {
+				b.WriteString(headingLine(entry.Level, entry.Title))
+			}
+		}
+		entries := outline[pages[i]]
+		matched := make([]int, len(layout.paragraphs)) // 段落对应的书签下标+1，0表示普通段落
+		found := make([]bool, len(entries))
+		next := 0 // 书签按文档顺序出现，只向后匹配
+		for j, entry := range entries {
+			for k := next; k < len(layout.paragraphs); k++ {
+				if matchesTitle(layout.paragraphs[k].text, entry.Title) {
+					matched[k], found[j], next = j+1, true, k+1
+					break
+				}
+			}
+		}
+		for j, entry := range entries {
+			if !found[j] {
+				b.WriteString(headingLine(entry.Level, entry.Title))
+			}
+		}
+		for k, p := range layout.paragraphs {
+			if matched[k] > 0 {
+				writeLine(headingLine(entries[matched[k]-1].Level, p.text), false)
+				continue
+			}
+			writeLine(p.text+"\n", p.mono)
+		}
+		writeLine("", false)
+	}
+	return b.String()
+}
+
+// bodySize 全文出现最多的字号即正文字号
+func bodySize(layouts []pageLayout) float64 {
+	counts := make(map[float64]int)
+	for _, l := range layouts {
+		for size, n := range l.sizes {
+			counts[size] += n
+		}
+	}
+	body, best := 0.0, 0
+	for size, n := range counts {
+		if n > best || (n == best && size < body) {
+			body, best = size, n
+		}
+	}
+	return body
+}
+
+// headingLevel 判断段落是否为标题，返回标题级别，0表示不是标题
+func headingLevel(p paragraph, body float64) int {
+	text := p.text
+	if body <= 0 || len(p.lines) > 2 || utf8.RuneCountInString(text) > 120 {
+		return 0
+	}
+	// 带编号的标题按编号层级定级：1 -> ##，2.1 -> ###
+	numbered := reSectionNumber.FindStringSubmatch(text)
+	ratio := p.size / body
+	switch {
+	case numbered != nil && (ratio >= 1.1 || p.bold):
+		level := strings.Count(numbered[1], ".") + 2
+		if level > 4 {
+			level = 4
+		}
+		return level
+	case ratio >= 1.6:
+		return 1
+	case ratio >= 1.3:
+		return 2
+	case ratio >= 1.15:
+		return 3
+	case p.bold && len(p.lines) == 1 && utf8.RuneCountInString(text) <= 80 && !endsWithTerminator(text):
+		return 3
+	}
+	return 0
+}
+
+// visibleZeroWidth 判断宽度为0的字形是否仍应保留：连字（fi、ffl等）被拆成多个字符时，
+// 末尾的字符宽度为0但可见；其余宽度为0的字形（如TeX字体中的换行符被解码成的Ω）不可见，直接丢弃
+func visibleZeroWidth(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r < utf8.RuneSelf
+}
+
+func endsWithTerminator(text string) bool {
+	last, _ := utf8.DecodeLastRuneInString(text)
+	return strings.ContainsRune(".。!！?？:：;；,，", last)
+}
+
+func isBoldFont(font string) bool {
+	lower := strings.ToLower(font)
+	return strings.Contains(lower, "bold") || strings.Contains(lower, "black") ||
+		strings.Contains(lower, "heavy") || strings.Contains(lower, "semibold") ||
+		strings.HasSuffix(lower, ".b") || strings.Contains(lower, "cmbx")
+}
+
+func isMonoFont(font string) bool {
+	lower := strings.ToLower(font)
+	return strings.Contains(lower, "mono") || strings.Contains(lower, "courier") ||
+		strings.Contains(lower, "consol") || strings.Contains(lower, "cmtt")
+}
+
+// startsWithBullet 判断行是否以列表符号开头，列表项各自成段
+func startsWithBullet(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return strings.ContainsRune("•◦▪▫‣∙·●○■□–—\ufffd", r)
+}
+
+func isCJKRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
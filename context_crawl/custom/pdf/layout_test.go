@@ -0,0 +1,306 @@
+package pdf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"context_crawl/types"
+)
+
+// word 生成从x开始、基线为y的一串字形，每个字宽为半个字号，空格也占一个字形
+func word(x, y, size float64, font, s string) []glyph {
+	var glyphs []glyph
+	for _, r := range s {
+		glyphs = append(glyphs, glyph{x: x, y: y, w: size / 2, size: size, font: font, s: string(r)})
+		x += size / 2
+	}
+	return glyphs
+}
+
+// line 生成一行版面重建用的textLine
+func line(x0, x1, y, size float64, text string) textLine {
+	return textLine{x0: x0, x1: x1, y: y, size: size, text: text}
+}
+
+func TestGroupLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		glyphs []glyph
+		want   []string
+		bold   []bool
+		mono   []bool
+	}{
+		{
+			name:   "baseline jitter and word gaps",
+			glyphs: append(word(10, 700, 10, "Times", "Hello"), word(40, 700.5, 10, "Times", "world")...),
+			want:   []string{"Hello world"},
+			bold:   []bool{false},
+			mono:   []bool{false},
+		},
+		{
+			name:   "lines ordered top to bottom",
+			glyphs: append(word(10, 680, 10, "Times", "second"), word(10, 700, 10, "Times", "first")...),
+			want:   []string{"first", "second"},
+			bold:   []bool{false, false},
+			mono:   []bool{false, false},
+		},
+		{
+			name:   "column gap splits the row",
+			glyphs: append(word(10, 700, 10, "Times", "left"), word(200, 700, 10, "Times", "right")...),
+			want:   []string{"left", "right"},
+			bold:   []bool{false, false},
+			mono:   []bool{false, false},
+		},
+		{
+			name: "overprinted bold glyphs collapse",
+			glyphs: []glyph{
+				{x: 10, y: 700, w: 5, size: 10, font: "Times-Bold", s: "A"},
+				{x: 10.3, y: 700, w: 5, size: 10, font: "Times-Bold", s: "A"},
+				{x: 15, y: 700, w: 5, size: 10, font: "Times-Bold", s: "B"},
+			},
+			want: []string{"AB"},
+			bold: []bool{true},
+			mono: []bool{false},
+		},
+		{
+			name:   "monospace font",
+			glyphs: word(10, 700, 10, "CourierNew", "x := 1"),
+			want:   []string{"x := 1"},
+			bold:   []bool{false},
+			mono:   []bool{true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := groupLines(tt.glyphs)
+			got := texts(lines)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("groupLines() = %q, want %q", got, tt.want)
+			}
+			for i, l := range lines {
+				if l.bold != tt.bold[i] || l.mono != tt.mono[i] {
+					t.Errorf("line %d bold=%v mono=%v, want bold=%v mono=%v", i, l.bold, l.mono, tt.bold[i], tt.mono[i])
+				}
+			}
+		})
+	}
+}
+
+func texts(lines []textLine) []string {
+	var out []string
+	for _, l := range lines {
+		out = append(out, l.text)
+	}
+	return out
+}
+
+func TestReadingOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []textLine
+		want  []string
+	}{
+		{
+			name: "two columns with a spanning title",
+			lines: func() []textLine {
+				lines := []textLine{line(50, 550, 760, 14, "Title")}
+				for i, y := 0, 740.0; i < 6; i, y = i+1, y-12 {
+					lines = append(lines,
+						line(50, 280, y, 10, "L"+string(rune('1'+i))),
+						line(320, 550, y, 10, "R"+string(rune('1'+i))))
+				}
+				return lines
+			}(),
+			want: []string{"Title", "L1", "L2", "L3", "L4", "L5", "L6", "R1", "R2", "R3", "R4", "R5", "R6"},
+		},
+		{
+			name: "single column unchanged",
+			lines: func() []textLine {
+				var lines []textLine
+				for i, y := 0, 740.0; i < 12; i, y = i+1, y-12 {
+					lines = append(lines, line(50, 550, y, 10, string(rune('a'+i))))
+				}
+				return lines
+			}(),
+			want: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := texts(readingOrder(tt.lines)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readingOrder() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildParagraphs(t *testing.T) {
+	mono := func(l textLine) textLine {
+		l.mono = true
+		return l
+	}
+	tests := []struct {
+		name  string
+		lines []textLine
+		want  []string
+	}{
+		{
+			name: "line spacing separates paragraphs",
+			lines: []textLine{
+				line(50, 500, 700, 10, "First paragraph starts"),
+				line(50, 500, 688, 10, "and ends here."),
+				line(50, 500, 660, 10, "Second paragraph"),
+				line(50, 500, 648, 10, "follows."),
+			},
+			want: []string{"First paragraph starts and ends here.", "Second paragraph follows."},
+		},
+		{
+			name: "first line indent starts a paragraph",
+			lines: []textLine{
+				line(50, 500, 700, 10, "End of one."),
+				line(70, 500, 688, 10, "Indented start"),
+				line(50, 500, 676, 10, "continues."),
+			},
+			want: []string{"End of one.", "Indented start continues."},
+		},
+		{
+			name: "hyphenation and cjk joins",
+			lines: []textLine{
+				line(50, 500, 700, 10, "recon-"),
+				line(50, 500, 688, 10, "struction of 版面"),
+				line(50, 500, 676, 10, "重建"),
+			},
+			want: []string{"reconstruction of 版面重建"},
+		},
+		{
+			name: "monospace lines stay separate",
+			lines: []textLine{
+				line(50, 500, 700, 10, "Example:"),
+				mono(line(50, 500, 688, 10, "if err != nil {")),
+				mono(line(50, 500, 676, 10, "return err")),
+			},
+			want: []string{"Example:", "if err != nil {", "return err"},
+		},
+		{
+			name: "bullets are separate items",
+			lines: []textLine{
+				line(50, 500, 700, 10, "• one"),
+				line(50, 500, 688, 10, "• two"),
+			},
+			want: []string{"• one", "• two"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range buildParagraphs(tt.lines) {
+				got = append(got, p.text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildParagraphs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinLines(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"hyphen-", "ation"}, "hyphenation"},
+		{[]string{"well-", "Known"}, "well-Known"},
+		{[]string{"中文", "段落"}, "中文段落"},
+		{[]string{"mixed", "中文"}, "mixed 中文"},
+		{[]string{"soft\u00ad", "hyphen"}, "softhyphen"},
+	}
+	for _, tt := range tests {
+		if got := joinLines(tt.lines); got != tt.want {
+			t.Errorf("joinLines(%q) = %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestHeadingLevel(t *testing.T) {
+	para := func(text string, size float64, bold bool) paragraph {
+		return paragraph{lines: []textLine{{text: text}}, text: text, size: size, bold: bold}
+	}
+	tests := []struct {
+		name string
+		p    paragraph
+		want int
+	}{
+		{"numbered section", para("2 Related Work", 12, true), 2},
+		{"numbered subsection", para("3.1 Setup", 11, false), 3},
+		{"large title", para("A Study of Things", 18, false), 1},
+		{"bold short line", para("Abstract", 10, true), 3},
+		{"bold sentence", para("Note that this holds.", 10, true), 0},
+		{"body text", para("Plain body text", 10, false), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := headingLevel(tt.p, 10); got != tt.want {
+				t.Errorf("headingLevel() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderLayout(t *testing.T) {
+	body := paragraph{lines: []textLine{{}}, size: 10}
+	p := func(text string, size float64, mono bool) paragraph {
+		q := body
+		q.text, q.size, q.mono = text, size, mono
+		return q
+	}
+	layouts := []pageLayout{
+		{
+			paragraphs: []paragraph{
+				p("Introduction", 16, false),
+				p("Run the command:", 10, false),
+				p("go build ./...", 10, true),
+				p("go test ./...", 10, true),
+			},
+			sizes: map[float64]int{10: 100, 16: 5},
+		},
+		{
+			paragraphs: []paragraph{p("fmt.Println(x)", 10, true), p("Done.", 10, false)},
+			sizes:      map[float64]int{10: 50},
+		},
+	}
+
+	tests := []struct {
+		name string
+		toc  []types.TocEntry
+		want string
+	}{
+		{
+			name: "headings from font size",
+			want: "@PAGE_1@\n# Introduction\nRun the command:\n```\ngo build ./...\ngo test ./...\n```\n" +
+				"@PAGE_2@\n```\nfmt.Println(x)\n```\nDone.\n",
+		},
+		{
+			name: "headings from outline",
+			toc:  []types.TocEntry{{Level: 1, Title: "Introduction", Page: 1}, {Level: 2, Title: "Usage", Page: 2}},
+			want: "@PAGE_1@\n# Introduction\nRun the command:\n```\ngo build ./...\ngo test ./...\n```\n" +
+				"@PAGE_2@\n## Usage\n```\nfmt.Println(x)\n```\nDone.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderLayout([]int{1, 2}, layouts, tt.toc)
+			if got != tt.want {
+				t.Errorf("renderLayout() =\n%s\nwant\n%s", got, tt.want)
+			}
+			// 清洗后代码围栏变为代码块，页码标记保留
+			cleaned, err := NewPDFCleaner().Clean(types.Type{Text: got})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cleaned.CodeMap) != 2 || strings.Count(cleaned.Text, "@PAGE_") != 2 {
+				t.Errorf("Clean() text = %q, code = %q", cleaned.Text, cleaned.CodeMap)
+			}
+		})
+	}
+}
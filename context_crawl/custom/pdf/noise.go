@@ -0,0 +1,227 @@
+package pdf
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 页眉页脚检测参数
+const (
+	edgeLines     = 3   // 每页顶部和底部各检查的行数
+	minRepeats    = 3   // 同一位置的同一行（或递增的页码）至少在这么多页出现才视为页眉页脚
+	watermarkRate = 0.5 // 任意位置的短行在超过该比例的页面出现时视为水印
+	maxNoiseRunes = 100 // 页眉页脚和水印都是短行
+)
+
+var (
+	// rePageNumber 单独成行的页码："3"、"- 3 -"、"Page 3"、"Page 3 of 10"、"3 / 10"、"第3页"、"第 3 页 共 10 页"、罗马数字
+	rePageNumber = regexp.MustCompile(`(?i)^(?:` +
+		`[-–—]?\s*\d{1,4}\s*[-–—]?` +
+		`|(?:page|p\.|pg\.?)\s*\d{1,4}(?:\s*(?:of|/)\s*\d{1,4})?` +
+		`|\d{1,4}\s*(?:of|/)\s*\d{1,4}` +
+		`|第\s*\d{1,4}\s*页(?:\s*[，,/]?\s*共\s*\d{1,4}\s*页)?` +
+		`|x{0,3}(?:ix|iv|vi{0,3}|i{1,3})|x{1,3}` + // 前言部分的罗马数字页码，只到xxxix，避免误伤 mix、dim 等单词
+		`)$`)
+	reDigits = regexp.MustCompile(`\d+`)
+	// reWatermark 水印和下载声明的常见措辞，只有命中的短行才会因为在大多数页面出现而被移除
+	reWatermark = regexp.MustCompile(`(?i)confidential|draft|preprint|watermark|proprietary|` +
+		`for review|internal use|not for (?:distribution|citation|redistribution)|do not (?:copy|distribute|cite)|` +
+		`downloaded (?:from|by|on)|licensed to|personal use|copyright|all rights reserved|©|` +
+		`https?://|www\.|` +
+		`机密|秘密|内部资料|内部使用|草稿|样稿|仅供|版权所有|下载自|未经(?:许可|授权)|禁止(?:转载|传播)`)
+	// reCodeLike 代码行的特征：常见关键字开头，或包含赋值、比较等运算符
+	reCodeLike = regexp.MustCompile(`^(?:return|if|else|for|while|switch|case|func|def|var|let|const|import|from|class|try|catch|except)\b|` +
+		`:=|!=|==|&&|\|\||=>|->`)
+)
+
+// pageLines 一页的内容行（不含页码标记）及其在全文中的行号
+type pageLines struct {
+	lines []string
+	index []int
+}
+
+// pageNumber 页面边缘的一个页码行：在全文中的行号及其数值
+type pageNumber struct {
+	line  int
+	value int
+}
+
+// removeRunningText 移除页眉、页脚、页码和水印，正文不受影响：
+// 1. 每页顶部、底部几行中的页码行：同一位置至少在minRepeats页出现，且数值逐页递增
+// 2. 在多页的同一位置（顶部、底部几行）重复出现的行（比较时忽略其中的数字，以覆盖 "Chapter 2 ... 15" 这类带页码的页眉）
+// 3. 符合水印措辞、且在大多数页面都出现的短行，如 "CONFIDENTIAL"、"Draft"、"Downloaded from ..."
+// 标题行和代码占位符不会被移除，像正文句子或代码的行不按页眉页脚处理
+func removeRunningText(text string) string {
+	all := strings.Split(text, "\n")
+	pages := splitPages(all)
+	if len(pages) == 0 {
+		return text
+	}
+
+	remove := make(map[int]bool)
+	edgeCounts := make(map[string]int)
+	lineCounts := make(map[string]int)
+	numbers := make(map[string][]pageNumber)
+	for _, page := range pages {
+		seen := make(map[string]bool)
+		for i, line := range page.lines {
+			if edge, ok := edgeKey(page, i, line); ok {
+				edgeCounts[edge]++
+			}
+			if slot, ok := edgeSlot(page, i); ok && rePageNumber.MatchString(line) {
+				// 阿拉伯数字和罗马数字分开计数，前言的 i、ii 之后正文通常从1重新开始
+				value, roman := pageNumberValue(line)
+				if roman {
+					slot += ":roman"
+				}
+				numbers[slot] = append(numbers[slot], pageNumber{line: page.index[i], value: value})
+			}
+			if !reWatermark.MatchString(line) {
+				continue
+			}
+			if key := repeatKey(line); key != "" && !seen[key] {
+				seen[key] = true
+				lineCounts[key]++
+			}
+		}
+	}
+
+	for _, run := range numbers {
+		if len(run) >= minRepeats && increasing(run) {
+			for _, number := range run {
+				remove[number.line] = true
+			}
+		}
+	}
+
+	watermarkPages := int(watermarkRate*float64(len(pages))) + 1
+	if watermarkPages < minRepeats {
+		watermarkPages = minRepeats
+	}
+	for _, page := range pages {
+		for i, line := range page.lines {
+			// 页码行只按上面的递增规则处理，不因数字被忽略后"重复"而移除
+			if isHeadingLine(line) || reCodeLine.MatchString(line) || rePageNumber.MatchString(line) {
+				continue
+			}
+			if key := repeatKey(line); key != "" && lineCounts[key] >= watermarkPages {
+				remove[page.index[i]] = true
+				continue
+			}
+			if edge, ok := edgeKey(page, i, line); ok && edgeCounts[edge] >= minRepeats && !looksLikeBody(line) {
+				remove[page.index[i]] = true
+			}
+		}
+	}
+
+	if len(remove) == 0 {
+		return text
+	}
+	kept := make([]string, 0, len(all)-len(remove))
+	for i, line := range all {
+		if !remove[i] {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// splitPages 按页码标记将行分组；没有页码标记时返回nil
+func splitPages(lines []string) []pageLines {
+	var pages []pageLines
+	for i, line := range lines {
+		if rePageMarker.MatchString(line) {
+			pages = append(pages, pageLines{})
+			continue
+		}
+		if len(pages) == 0 || line == "" {
+			continue
+		}
+		current := &pages[len(pages)-1]
+		current.lines = append(current.lines, line)
+		current.index = append(current.index, i)
+	}
+	return pages
+}
+
+// edgeKey 返回位于页面顶部或底部的行的位置键，其余行返回false
+func edgeKey(page pageLines, i int, line string) (string, bool) {
+	key := repeatKey(line)
+	if key == "" {
+		return "", false
+	}
+	slot, ok := edgeSlot(page, i)
+	if !ok {
+		return "", false
+	}
+	return slot + ":" + key, true
+}
+
+// edgeSlot 返回第i行在页面顶部或底部的位置，如 "top:0"、"bottom:1"，其余行返回false
+func edgeSlot(page pageLines, i int) (string, bool) {
+	if i < edgeLines {
+		return "top:" + string(rune('0'+i)), true
+	}
+	if fromEnd := len(page.lines) - 1 - i; fromEnd < edgeLines {
+		return "bottom:" + string(rune('0'+fromEnd)), true
+	}
+	return "", false
+}
+
+// pageNumberValue 页码行的数值：取第一个阿拉伯数字（"Page 3 of 10" 为3）；
+// 没有阿拉伯数字时按罗马数字解析，此时roman为true
+func pageNumberValue(line string) (value int, roman bool) {
+	if digits := reDigits.FindString(line); digits != "" {
+		value, _ = strconv.Atoi(digits)
+		return value, false
+	}
+	values := map[rune]int{'i': 1, 'v': 5, 'x': 10}
+	runes := []rune(strings.ToLower(strings.TrimSpace(line)))
+	for i, r := range runes {
+		if i+1 < len(runes) && values[r] < values[runes[i+1]] {
+			value -= values[r]
+		} else {
+			value += values[r]
+		}
+	}
+	return value, true
+}
+
+// increasing 同一位置的页码是否随页面顺序严格递增
+func increasing(run []pageNumber) bool {
+	for i := 1; i < len(run); i++ {
+		if run[i].value <= run[i-1].value {
+			return false
+		}
+	}
+	return true
+}
+
+// repeatKey 比较重复行用的键：忽略大小写、空白和数字；过长的行不可能是页眉页脚，返回空串
+func repeatKey(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || utf8.RuneCountInString(line) > maxNoiseRunes {
+		return ""
+	}
+	key := reDigits.ReplaceAllString(strings.ToLower(line), "0")
+	return strings.Join(strings.Fields(key), " ")
+}
+
+func isHeadingLine(line string) bool {
+	return strings.HasPrefix(line, "#")
+}
+
+// looksLikeBody 即使出现在页面边缘也不按页眉页脚处理的行：以句末标点结尾的正文句子、代码行，
+// 以及不含字母数字的行（如单独的 "}"）
+func looksLikeBody(line string) bool {
+	line = strings.TrimSpace(line)
+	if endsWithTerminator(line) || reCodeLike.MatchString(line) {
+		return true
+	}
+	return !strings.ContainsFunc(line, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,203 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"context_crawl/types"
+)
+
+// pagesText 按页拼出清洗器的输入，每页之前是页码标记
+func pagesText(pages ...[]string) string {
+	var b strings.Builder
+	for i, lines := range pages {
+		fmt.Fprintf(&b, "@PAGE_%d@\n", i+1)
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// repeatPages 生成n页，每页由page(i)给出，i从1开始
+func repeatPages(n int, page func(i int) []string) string {
+	pages := make([][]string, n)
+	for i := range pages {
+		pages[i] = page(i + 1)
+	}
+	return pagesText(pages...)
+}
+
+func TestRemoveRunningText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		removed []string // 应被移除的行
+		kept    []string // 应保留的行
+	}{
+		{
+			name: "running header, footer and page numbers",
+			text: repeatPages(4, func(i int) []string {
+				return []string{
+					fmt.Sprintf("Journal of Systems, Vol. 12 (%d)", 2020+i),
+					fmt.Sprintf("Body paragraph number %d explains the method.", i),
+					"Another sentence that belongs to the body.",
+					fmt.Sprintf("- %d -", i),
+				}
+			}),
+			removed: []string{"Journal of Systems", "- 1 -", "- 4 -"},
+			kept:    []string{"Body paragraph number 3 explains the method.", "Another sentence that belongs to the body."},
+		},
+		{
+			name: "watermark anywhere on the page",
+			text: repeatPages(4, func(i int) []string {
+				return []string{
+					fmt.Sprintf("Section text %d opens the page.", i),
+					"CONFIDENTIAL",
+					"More body text follows here.",
+					"Even more body text follows.",
+					"Closing body text of the page.",
+					"Downloaded from https://example.org on May 3, 2021.",
+				}
+			}),
+			removed: []string{"CONFIDENTIAL", "Downloaded from"},
+			kept:    []string{"More body text follows here."},
+		},
+		{
+			name: "repeated code and short body lines stay",
+			text: repeatPages(5, func(i int) []string {
+				return []string{
+					"if err != nil {",
+					"return nil, err",
+					"}",
+					fmt.Sprintf("Lemma %d holds for every graph.", i),
+					"Proof.",
+					"PDF/A and Page Rank stay.",
+					"}",
+				}
+			}),
+			kept: []string{"if err != nil {", "return nil, err", "}", "Proof.", "PDF/A and Page Rank stay."},
+		},
+		{
+			name: "headings and code placeholders stay",
+			text: repeatPages(4, func(i int) []string {
+				return []string{"# Appendix", fmt.Sprintf("@CODE_%d@", i), "Body text of the appendix page."}
+			}),
+			kept: []string{"# Appendix", "@CODE_1@", "@CODE_4@"},
+		},
+		{
+			name: "roman front matter then arabic page numbers",
+			text: pagesText(
+				[]string{"Preface page text.", "ii"},
+				[]string{"More preface text.", "iii"},
+				[]string{"Last preface text.", "iv"},
+				[]string{"Chapter body text.", "- 1 -"},
+				[]string{"More chapter text.", "- 2 -"},
+				[]string{"Last chapter text.", "- 3 -"},
+			),
+			removed: []string{"iii", "iv", "- 1 -", "- 3 -"},
+			kept:    []string{"Preface page text.", "Last chapter text."},
+		},
+		{
+			name: "numbers on too few pages stay",
+			text: pagesText(
+				[]string{"412", "Revenue grew in every region this year."},
+				[]string{"Costs stayed flat for the quarter.", "Details follow below."},
+				[]string{"Headcount rose slightly.", "More details follow."},
+				[]string{"530", "Margins improved again this year."},
+			),
+			kept: []string{"412", "530"},
+		},
+		{
+			name: "numbers that do not increase stay",
+			text: repeatPages(4, func(i int) []string {
+				return []string{
+					fmt.Sprintf("Table %d lists the measured latency.", i),
+					"Average latency in milliseconds:",
+					[]string{"", "230", "97", "230", "115"}[i],
+				}
+			}),
+			kept: []string{"230", "97", "115"},
+		},
+		{
+			name:    "too few pages",
+			text:    pagesText([]string{"Running title", "Body."}, []string{"Running title", "More body."}),
+			removed: nil,
+			kept:    []string{"Running title"},
+		},
+		{
+			name: "no page markers",
+			text: "Running title\nBody.\n",
+			kept: []string{"Running title", "Body."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := removeRunningText(tt.text)
+			lines := strings.Split(got, "\n")
+			for _, bad := range tt.removed {
+				for _, line := range lines {
+					if strings.Contains(line, bad) {
+						t.Errorf("line %q should have been removed", line)
+					}
+				}
+			}
+			for _, want := range tt.kept {
+				if strings.Count(got, want) != strings.Count(tt.text, want) {
+					t.Errorf("removeRunningText() dropped %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestPDFCleanerExtractsCode(t *testing.T) {
+	text := pagesText(
+		[]string{"Usage:", "```", "if err != nil {", "    return nil, err", "}", "```", "Done."},
+		[]string{"```", "unterminated(   x )"},
+	)
+	got, err := NewPDFCleaner().Clean(types.Type{Text: text})
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if len(got.CodeMap) != 1 {
+		t.Fatalf("Clean() CodeMap = %v, want 1 entry", got.CodeMap)
+	}
+	if code := got.CodeMap["@CODE_0@"]; code != "if err != nil {\n    return nil, err\n}" {
+		t.Errorf("code = %q", code)
+	}
+	if !strings.Contains(got.Text, "Usage:\n@CODE_0@\nDone.") {
+		t.Errorf("text = %q", got.Text)
+	}
+	// 未闭合的围栏按普通文本处理，空白照常合并
+	if !strings.Contains(got.Text, "unterminated( x )") || strings.Contains(got.Text, "```") {
+		t.Errorf("text = %q", got.Text)
+	}
+}
+
+func TestRePageNumber(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"3", true},
+		{"- 12 -", true},
+		{"Page 3 of 10", true},
+		{"3 / 10", true},
+		{"第 3 页 共 10 页", true},
+		{"i", true},
+		{"xiv", true},
+		{"xxx", true},
+		{"", false},
+		{"mix", false},
+		{"dim", false},
+		{"Chapter 3", false},
+	}
+	for _, tt := range tests {
+		if got := rePageNumber.MatchString(tt.line); got != tt.want {
+			t.Errorf("rePageNumber.MatchString(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"context_crawl/types"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// 书签树的遍历上限，防止损坏的文件中Next/Kids形成环
+const (
+	maxTocEntries = 2000
+	maxTreeDepth  = 32
+)
+
+// infoKeys 信息字典中读取的字段及其在metadata中的键名
+var infoKeys = []struct{ key, name string }{
+	{"Title", "title"},
+	{"Author", "author"},
+	{"Subject", "subject"},
+	{"Keywords", "keywords"},
+	{"Creator", "creator"},
+	{"Producer", "producer"},
+	{"CreationDate", "created"},
+	{"ModDate", "modified"},
+}
+
+// readMetadata 读取文档信息字典，日期转换为RFC 3339格式；另外记录总页数
+func readMetadata(r *pdf.Reader) (metadata map[string]string) {
+	defer func() {
+		if recover() != nil {
+			metadata = nil
+		}
+	}()
+
+	metadata = map[string]string{
+		"pages": strconv.Itoa(r.NumPage()),
+	}
+	info := r.Trailer().Key("Info")
+	for _, k := range infoKeys {
+		value := strings.Join(strings.Fields(info.Key(k.key).Text()), " ")
+		if value == "" {
+			continue
+		}
+		if strings.HasSuffix(k.key, "Date") {
+			value = parsePDFDate(value)
+		}
+		metadata[k.name] = value
+	}
+	return metadata
+}
+
+// parsePDFDate 解析PDF日期 D:YYYYMMDDHHmmSSOHH'mm'，只有日期部分时返回 2006-01-02，无法解析时原样返回
+func parsePDFDate(value string) string {
+	s := strings.TrimPrefix(value, "D:")
+	digits := 0
+	for digits < len(s) && digits < 14 && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	layouts := map[int]string{4: "2006", 6: "200601", 8: "20060102", 10: "2006010215", 12: "200601021504", 14: "20060102150405"}
+	layout, ok := layouts[digits]
+	if !ok {
+		return value
+	}
+
+	location := time.UTC
+	if zone := strings.TrimRight(s[digits:], "'"); len(zone) >= 3 && (zone[0] == '+' || zone[0] == '-') {
+		parts := strings.SplitN(zone[1:], "'", 2)
+		hours, _ := strconv.Atoi(parts[0])
+		minutes := 0
+		if len(parts) == 2 {
+			minutes, _ = strconv.Atoi(parts[1])
+		}
+		offset := hours*3600 + minutes*60
+		if zone[0] == '-' {
+			offset = -offset
+		}
+		location = time.FixedZone("", offset)
+	}
+
+	t, err := time.ParseInLocation(layout, s[:digits], location)
+	if err != nil {
+		return value
+	}
+	if digits <= 8 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// readOutline 读取书签（大纲）树，按文档顺序展开为目录，并解析每一项指向的页码
+func readOutline(r *pdf.Reader) (toc []types.TocEntry) {
+	defer func() {
+		if recover() != nil {
+			toc = nil
+		}
+	}()
+
+	root := r.Trailer().Key("Root")
+	pages := make(map[string]int)
+	numberPages(root.Key("Pages"), pages, make(map[string]bool), 0)
+
+	// 书签项以字典文本（含其Next、Parent等引用）去重，遇到已访问过的项说明Next或First形成了环；
+	// 没有标题的项也计入上限，避免全是空标题的环无法结束
+	visited := make(map[string]bool)
+	var walk func(item pdf.Value, level int)
+	walk = func(item pdf.Value, level int) {
+		if level > maxTreeDepth {
+			return
+		}
+		for child := item.Key("First"); child.Kind() == pdf.Dict && len(visited) < maxTocEntries; child = child.Key("Next") {
+			key := child.String()
+			if visited[key] {
+				return
+			}
+			visited[key] = true
+			if title := strings.Join(strings.Fields(child.Key("Title").Text()), " "); title != "" {
+				toc = append(toc, types.TocEntry{
+					Level: level,
+					Title: title,
+					Page:  pages[destinationPage(root, child)],
+				})
+			}
+			walk(child, level+1)
+		}
+	}
+	walk(root.Key("Outlines"), 1)
+	return toc
+}
+
+// numberPages 遍历页面树，以页面字典的文本形式为键记录页码（书签的目标页只能这样与页码对应）
+// seen 记录访问过的节点，Kids中引用祖先节点形成环时不再重复展开
+func numberPages(node pdf.Value, pages map[string]int, seen map[string]bool, depth int) {
+	if depth > maxTreeDepth {
+		return
+	}
+	key := node.String()
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+	switch node.Key("Type").Name() {
+	case "Pages":
+		kids := node.Key("Kids")
+		for i := 0; i < kids.Len(); i++ {
+			numberPages(kids.Index(i), pages, seen, depth+1)
+		}
+	case "Page":
+		pages[key] = len(pages) + 1
+	}
+}
+
+// destinationPage 返回书签目标页的页面字典文本，目标可以是 /Dest 或 GoTo 动作，也可以是命名目标
+func destinationPage(root, item pdf.Value) string {
+	dest := item.Key("Dest")
+	if dest.IsNull() {
+		if action := item.Key("A"); action.Key("S").Name() == "GoTo" {
+			dest = action.Key("D")
+		}
+	}
+
+	// 命名目标：先查 /Dests 字典，再查 /Names /Dests 名称树
+	var name string
+	switch dest.Kind() {
+	case pdf.Name:
+		name = dest.Name()
+	case pdf.String:
+		name = dest.RawString()
+	}
+	if name != "" {
+		if named := root.Key("Dests").Key(name); !named.IsNull() {
+			dest = named
+		} else {
+			dest = lookupNameTree(root.Key("Names").Key("Dests"), name, 0)
+		}
+	}
+	if dest.Kind() == pdf.Dict {
+		dest = dest.Key("D")
+	}
+
+	if dest.Kind() != pdf.Array || dest.Len() == 0 {
+		return ""
+	}
+	page := dest.Index(0)
+	if page.Kind() != pdf.Dict {
+		return ""
+	}
+	return page.String()
+}
+
+// lookupNameTree 在名称树中查找key
+func lookupNameTree(node pdf.Value, key string, depth int) pdf.Value {
+	if node.IsNull() || depth > maxTreeDepth {
+		return pdf.Value{}
+	}
+	names := node.Key("Names")
+	for i := 0; i+1 < names.Len(); i += 2 {
+		if names.Index(i).RawString() == key {
+			return names.Index(i + 1)
+		}
+	}
+	kids := node.Key("Kids")
+	for i := 0; i < kids.Len(); i++ {
+		kid := kids.Index(i)
+		if limits := kid.Key("Limits"); limits.Len() == 2 &&
+			(key < limits.Index(0).RawString() || key > limits.Index(1).RawString()) {
+			continue
+		}
+		if found := lookupNameTree(kid, key, depth+1); !found.IsNull() {
+			return found
+		}
+	}
+	return pdf.Value{}
+}
+
+// outlineHeadings 书签按页分组，用于在分块时作为章节标题；书签都无法定位到页面时返回nil
+func outlineHeadings(toc []types.TocEntry) map[int][]types.TocEntry {
+	var byPage map[int][]types.TocEntry
+	for _, entry := range toc {
+		if entry.Page == 0 {
+			continue
+		}
+		if byPage == nil {
+			byPage = make(map[int][]types.TocEntry)
+		}
+		byPage[entry.Page] = append(byPage[entry.Page], entry)
+	}
+	return byPage
+}
+
+// enclosingHeadings 返回在firstPage之前开始、仍覆盖firstPage的各级书签，
+// 只提取部分页面时用它们补全第一页所在章节的标题路径
+func enclosingHeadings(toc []types.TocEntry, firstPage int) []types.TocEntry {
+	var stack []types.TocEntry
+	for _, entry := range toc {
+		if entry.Page == 0 || entry.Page >= firstPage {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= entry.Level {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, entry)
+	}
+	return stack
+}
+
+// matchesTitle 判断段落是否就是书签标题所在的行：忽略大小写、空白和标点，
+// 允许段落带有书签中没有的章节编号，如 "2.1 ASN.1 syntax" 对应书签 "ASN.1 syntax"
+func matchesTitle(text, title string) bool {
+	t, p := titleKey(title), titleKey(text)
+	if t == "" || p == "" {
+		return false
+	}
+	return p == t || (strings.HasSuffix(p, t) && len(p)-len(t) <= 12)
+}
+
+// titleKey 只保留字母和数字并转为小写
+func titleKey(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// headingLine 输出一行 # 标题，层级最多为6
+func headingLine(level int, text string) string {
+	if level > 6 {
+		level = 6
+	}
+	if level < 1 {
+		level = 1
+	}
+	return strings.Repeat("#", level) + " " + text + "\n"
+}
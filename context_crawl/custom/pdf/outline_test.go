@@ -0,0 +1,130 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"context_crawl/types"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// buildPDF 用给定的对象（按编号从1开始）拼出一个带正确xref表的PDF，trailer为额外的trailer字段
+func buildPDF(objects []string, trailer string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+	return b.Bytes()
+}
+
+// outlinePDF 两页的文档，书签从对象5开始
+func outlinePDF(items ...string) []byte {
+	return outlinePDFWithKids("[3 0 R 8 0 R]", items...)
+}
+
+// outlinePDFWithKids 同outlinePDF，页面树根节点的Kids由调用方给出
+func outlinePDFWithKids(kids string, items ...string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /Outlines 4 0 R >>",
+		"<< /Type /Pages /Kids " + kids + " /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+		"<< /Type /Outlines /First 5 0 R >>",
+	}
+	objects = append(objects, items...)
+	for len(objects) < 7 {
+		objects = append(objects, "<< >>")
+	}
+	objects = append(objects, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] >>")
+	return buildPDF(objects, "")
+}
+
+func TestReadOutline(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []types.TocEntry
+	}{
+		{
+			name: "nested outline",
+			data: outlinePDF(
+				"<< /Title (Intro) /Parent 4 0 R /Next 6 0 R /Dest [3 0 R /Fit] >>",
+				"<< /Title (Method) /Parent 4 0 R /Prev 5 0 R /First 7 0 R /Dest [8 0 R /Fit] >>",
+				"<< /Title (Setup) /Parent 6 0 R /Dest [8 0 R /XYZ 0 700 0] >>",
+			),
+			want: []types.TocEntry{{Level: 1, Title: "Intro", Page: 1}, {Level: 1, Title: "Method", Page: 2}, {Level: 2, Title: "Setup", Page: 2}},
+		},
+		{
+			name: "next points back to an earlier item",
+			data: outlinePDF(
+				"<< /Title (Intro) /Parent 4 0 R /Next 6 0 R /Dest [3 0 R /Fit] >>",
+				"<< /Title (Loop) /Parent 4 0 R /Prev 5 0 R /Next 5 0 R /Dest [8 0 R /Fit] >>",
+			),
+			want: []types.TocEntry{{Level: 1, Title: "Intro", Page: 1}, {Level: 1, Title: "Loop", Page: 2}},
+		},
+		{
+			name: "page tree kids point back to the parent",
+			data: outlinePDFWithKids("[2 0 R 3 0 R 2 0 R 8 0 R]", "<< /Title (Intro) /Parent 4 0 R /Dest [8 0 R /Fit] >>"),
+			want: []types.TocEntry{{Level: 1, Title: "Intro", Page: 2}},
+		},
+		{
+			name: "untitled item whose next is itself",
+			data: outlinePDF("<< /Parent 4 0 R /Next 5 0 R >>"),
+			want: nil,
+		},
+		{
+			name: "first points back to the root",
+			data: outlinePDF("<< /Title (Intro) /Parent 4 0 R /First 4 0 R /Dest [3 0 R /Fit] >>"),
+			want: []types.TocEntry{{Level: 1, Title: "Intro", Page: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := pdf.NewReader(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			done := make(chan []types.TocEntry, 1)
+			go func() { done <- readOutline(r) }()
+			select {
+			case got := <-done:
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("readOutline() = %+v, want %+v", got, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("readOutline() did not return, the outline cycle was not detected")
+			}
+		})
+	}
+}
+
+func TestParsePDFDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"D:20230115", "2023-01-15"},
+		{"D:20230115083000Z", "2023-01-15T08:30:00Z"},
+		{"D:20230115083000+08'00'", "2023-01-15T08:30:00+08:00"},
+		{"D:20230115083000-05'30", "2023-01-15T08:30:00-05:30"},
+		{"yesterday", "yesterday"},
+	}
+	for _, tt := range tests {
+		if got := parsePDFDate(tt.value); got != tt.want {
+			t.Errorf("parsePDFDate(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
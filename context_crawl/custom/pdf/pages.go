@@ -0,0 +1,56 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParsePageRange 解析页码范围，返回升序且去重的页码
+// 支持 "3"、"1-5"、"12-"（到最后一页）、"-3"（前三页），多段用逗号分隔；超出总页数的部分被忽略
+func ParsePageRange(spec string, numPages int) ([]int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = "1-"
+	}
+
+	selected := make([]bool, numPages+1)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to := 1, numPages
+		bounds := strings.SplitN(part, "-", 2)
+		var err error
+		if bounds[0] = strings.TrimSpace(bounds[0]); bounds[0] != "" {
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("页码范围无效: %s", part)
+			}
+		}
+		if len(bounds) == 1 {
+			to = from
+		} else if bounds[1] = strings.TrimSpace(bounds[1]); bounds[1] != "" {
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("页码范围无效: %s", part)
+			}
+		}
+		if from < 1 || to < from {
+			return nil, fmt.Errorf("页码范围无效: %s", part)
+		}
+		for page := from; page <= to && page <= numPages; page++ {
+			selected[page] = true
+		}
+	}
+
+	var pages []int
+	for page := 1; page <= numPages; page++ {
+		if selected[page] {
+			pages = append(pages, page)
+		}
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("页码范围 %s 超出文档页数 %d", spec, numPages)
+	}
+	return pages, nil
+}
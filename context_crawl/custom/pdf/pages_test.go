@@ -0,0 +1,36 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePageRange(t *testing.T) {
+	tests := []struct {
+		spec    string
+		pages   int
+		want    []int
+		wantErr bool
+	}{
+		{spec: "", pages: 3, want: []int{1, 2, 3}},
+		{spec: "2", pages: 3, want: []int{2}},
+		{spec: "1-2, 5", pages: 6, want: []int{1, 2, 5}},
+		{spec: "4-", pages: 5, want: []int{4, 5}},
+		{spec: "-2", pages: 5, want: []int{1, 2}},
+		{spec: "3-1", pages: 5, wantErr: true},
+		{spec: "a-b", pages: 5, wantErr: true},
+		{spec: "9", pages: 5, wantErr: true},
+		{spec: "2-3,3,2", pages: 5, want: []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParsePageRange(tt.spec, tt.pages)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePageRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePageRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
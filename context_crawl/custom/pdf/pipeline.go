@@ -2,6 +2,7 @@ package pdf
 
 import (
 	"context_crawl/base/colly"
+	"context_crawl/base/normalize"
 	"context_crawl/types"
 )
 
@@ -15,9 +16,9 @@ type PDFPipeline struct {
 // NewPDFPipeline 创建一个新的PDFPipeline实例
 func NewPDFPipeline() *PDFPipeline {
 	crawler := NewPDFCrawler()
-	cleaner := NewPDFCleaner()
-	// 复用colly的ScoredChunker
-	chunker := colly.NewScoredChunker(0.0)
+	cleaner := normalize.NewCleaner(NewPDFCleaner(), normalize.DefaultOptions())
+	// 复用colly的SectionChunker，版面重建输出的标题和段落可以按章节分块
+	chunker := colly.NewSectionChunker(0.2) // PDF没有链接和导航，主要过滤页码、乱码等碎片
 	return &PDFPipeline{
 		Crawler: crawler,
 		Cleaner: cleaner,
@@ -26,35 +27,22 @@ func NewPDFPipeline() *PDFPipeline {
 }
 
 // Process 执行PDF pipeline处理流程，实现types.Pipeline接口
+// 各阶段的错误原样返回（多为*types.Error），由service层决定是否尝试其他pipeline
 func (p *PDFPipeline) Process(input types.Type) (types.Type, error) {
 	// 1. 爬取PDF文件
 	pdfResult, err := p.Crawler.Crawl(input)
 	if err != nil {
-		return types.Type{
-			Url:  input.Url,
-			Text: "PDF文件爬取失败: " + err.Error(),
-		}, nil
+		return types.Type{}, err
 	}
 
 	// 2. 清洗PDF文本
 	cleanResult, err := p.Cleaner.Clean(pdfResult)
 	if err != nil {
-		return types.Type{
-			Url:  input.Url,
-			Text: "PDF文本清洗失败: " + err.Error(),
-		}, nil
+		return types.Type{}, err
 	}
 
-	// 3. 分块处理（直接使用colly的ScoredChunker）
-	chunkResult, err := p.Chunker.Chunk(cleanResult)
-	if err != nil {
-		return types.Type{
-			Url:  input.Url,
-			Text: "PDF文本分块失败: " + err.Error(),
-		}, nil
-	}
-
-	return chunkResult, nil
+	// 3. 分块处理（直接使用colly的SectionChunker）
+	return p.Chunker.Chunk(cleanResult)
 }
 
 // Match 匹配方法，实现types.Pipeline接口
@@ -0,0 +1,122 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var (
+	// reObjectStart 间接对象的开头 "12 0 obj"，只认行首的，减少流数据中的误匹配
+	reObjectStart = regexp.MustCompile(`(?:\A|[\r\n])(\d+)[ \t\r\n]+(\d+)[ \t\r\n]+obj\b`)
+	reRootRef     = regexp.MustCompile(`/Root[ \t\r\n]*(\d+[ \t\r\n]+\d+[ \t\r\n]+R)`)
+	reInfoRef     = regexp.MustCompile(`/Info[ \t\r\n]*(\d+[ \t\r\n]+\d+[ \t\r\n]+R)`)
+	reEncryptRef  = regexp.MustCompile(`/Encrypt[ \t\r\n]*(\d+[ \t\r\n]+\d+[ \t\r\n]+R)`)
+	reTrailerID   = regexp.MustCompile(`/ID[ \t\r\n]*(\[[^\]]*\])`)
+	reCatalog     = regexp.MustCompile(`/Type[ \t\r\n]*/Catalog\b`)
+)
+
+// repairPDF 修复常见的文件结构损坏，返回修复后的文件内容，无法修复时返回nil：
+// 去掉 %PDF- 之前的无关字节、改写库不认识的文件头版本号，
+// 扫描全部 "n g obj" 重建交叉引用表和trailer，并补上 startxref 与 %%EOF
+// 压缩在对象流中的对象无法通过扫描找到，这类文件只有在交叉引用流完好时才能读取
+func repairPDF(data []byte) []byte {
+	start := bytes.Index(data, []byte("%PDF-"))
+	if start < 0 || len(data)-start < 16 {
+		return nil
+	}
+	out := make([]byte, len(data)-start, len(data)-start+4096)
+	copy(out, data[start:])
+
+	// 文件头固定为 %PDF-1.x 加换行，版本号只影响库的校验
+	if out[5] != '1' || out[6] != '.' || out[7] < '0' || out[7] > '7' {
+		copy(out[5:8], "1.7")
+	}
+	if out[8] != '\r' && out[8] != '\n' {
+		out[8] = '\n'
+	}
+
+	// 扫描对象，增量更新中后出现的定义覆盖先前的
+	offsets := make(map[int]int64)
+	generations := make(map[int]int)
+	maxID := 0
+	catalog := ""
+	for _, m := range reObjectStart.FindAllSubmatchIndex(out, -1) {
+		id, err1 := strconv.Atoi(string(out[m[2]:m[3]]))
+		gen, err2 := strconv.Atoi(string(out[m[4]:m[5]]))
+		if err1 != nil || err2 != nil || id <= 0 || id > 10_000_000 {
+			continue
+		}
+		offsets[id] = int64(m[2])
+		generations[id] = gen
+		if id > maxID {
+			maxID = id
+		}
+		if catalog == "" && reCatalog.Match(objectHead(out, m[1])) {
+			catalog = fmt.Sprintf("%d %d R", id, gen)
+		}
+	}
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	// trailer中的引用取文件中最后一次出现的，找不到根对象时使用扫描到的目录对象
+	root := lastRef(out, reRootRef)
+	if root == "" {
+		root = catalog
+	}
+	if root == "" {
+		return nil
+	}
+
+	var b bytes.Buffer
+	b.Write(out)
+	b.WriteString("\n")
+	xrefOffset := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", maxID+1)
+	for id := 1; id <= maxID; id++ {
+		if off, ok := offsets[id]; ok {
+			fmt.Fprintf(&b, "%010d %05d n \n", off, generations[id])
+		} else {
+			b.WriteString("0000000000 65535 f \n")
+		}
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %s", maxID+1, root)
+	if info := lastRef(out, reInfoRef); info != "" {
+		fmt.Fprintf(&b, " /Info %s", info)
+	}
+	if encrypt := lastRef(out, reEncryptRef); encrypt != "" {
+		fmt.Fprintf(&b, " /Encrypt %s", encrypt)
+	}
+	if id := lastRef(out, reTrailerID); id != "" {
+		fmt.Fprintf(&b, " /ID %s", id)
+	}
+	fmt.Fprintf(&b, " >>\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return b.Bytes()
+}
+
+// objectHead 对象开头的一小段内容，用于判断对象类型
+func objectHead(data []byte, from int) []byte {
+	end := from + 512
+	if end > len(data) {
+		end = len(data)
+	}
+	head := data[from:end]
+	if i := bytes.Index(head, []byte("endobj")); i >= 0 {
+		head = head[:i]
+	}
+	if i := bytes.Index(head, []byte("stream")); i >= 0 {
+		head = head[:i]
+	}
+	return head
+}
+
+// lastRef 返回正则第一个分组在文件中最后一次出现的内容
+func lastRef(data []byte, re *regexp.Regexp) string {
+	matches := re.FindAllSubmatch(data, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return string(matches[len(matches)-1][1])
+}
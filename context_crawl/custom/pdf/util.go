@@ -1,26 +1,66 @@
 package pdf
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
 	"strings"
 	"time"
 
+	"context_crawl/types"
+	"context_crawl/utils"
+
 	"github.com/ledongthuc/pdf"
 )
 
 // IsPDFFile 判断是否是PDF文件
-func IsPDFFile(url string) bool {
-	// 检查URL是否以.pdf结尾
-	if strings.HasSuffix(strings.ToLower(url), ".pdf") {
+// 只看URL的路径部分，a.pdf?dl=1这类带查询参数的地址同样匹配；
+// 扩展名不是.pdf的链接由colly pipeline按响应内容识别后交给PDF pipeline
+func IsPDFFile(rawURL string) bool {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+		if u.Opaque != "" {
+			// C:\docs\a.pdf这类Windows本地路径会被解析为scheme加opaque
+			path = u.Opaque
+		}
+	}
+	// 检查路径是否以.pdf结尾
+	if strings.HasSuffix(strings.ToLower(path), ".pdf") {
 		return true
 	}
 	// 检查URL是否包含arxiv.org/pdf
-	if strings.Contains(url, "arxiv.org/pdf") {
+	if strings.Contains(rawURL, "arxiv.org/pdf") {
 		return true
 	}
 	return false
 }
 
+// IsPDFContent 根据文件头判断内容是否为PDF：文件开头附近出现 %PDF- 标记
+func IsPDFContent(head []byte) bool {
+	return utils.IsPDFContent(head)
+}
+
+// isPDFFileContent 读取本地文件的文件头判断是否为PDF
+func isPDFFileContent(filePath string) (bool, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	return IsPDFContent(head[:n]), nil
+}
+
 // GetPDFFileName 获取PDF文件的名称
 func GetPDFFileName(url string) string {
 	if strings.Contains(url, "/") {
@@ -35,40 +75,152 @@ func GetPDFFileName(url string) string {
 	return fmt.Sprintf("pdf_%d.pdf", timestamp)
 }
 
-// ExtractTextFromPDF 从PDF文件中提取文本
-// 使用github.com/ledongthuc/pdf库实现PDF文本提取
-func ExtractTextFromPDF(filePath string) (string, error) {
-	// 打开PDF文件
-	f, r, err := pdf.Open(filePath)
+// ExtractTextFromPDF 从PDF文件中提取文本，见ExtractPDF
+func ExtractTextFromPDF(filePath string, pages string) (string, error) {
+	doc, err := ExtractPDF(filePath, pages)
+	return doc.Text, err
+}
+
+// ExtractPDF 从PDF文件中提取文本、元数据（信息字典）和目录（书签）
+// 使用github.com/ledongthuc/pdf库读取每页的字形坐标，按版面重建阅读顺序（分栏、段落、标题，见layout.go），
+// 无法重建的页面退回到GetPlainText；每一页之前插入一行页码标记（types.PageMarker），
+// pages为页码范围（如 "1-5,8,12-"），为空时提取全部页面，只有选中的页面会被解析，目录始终覆盖全文
+// 加密文件依次尝试空密码和passwords；失败时返回*types.Error，错误码见errors.go
+func ExtractPDF(filePath string, pages string, passwords ...string) (doc types.Type, err error) {
+	// 库在遇到损坏的对象时会panic
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = types.Type{}, types.NewError(ErrCodeMalformed, fmt.Sprintf("PDF文件解析失败: %v", r), true, nil)
+		}
+	}()
+
+	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Sprintf("[PDF文件打开失败]\n错误: %v\n文件路径: %s", err, filePath), nil
+		return types.Type{}, fmt.Errorf("读取PDF文件失败: %v", err)
+	}
+	r, err := openPDF(data, passwords)
+	if err != nil {
+		return types.Type{}, err
 	}
-	defer f.Close()
 
-	var textBuilder strings.Builder
+	selected, err := ParsePageRange(pages, r.NumPage())
+	if err != nil {
+		return types.Type{}, types.NewError(ErrCodePages, err.Error(), true, err)
+	}
 
-	// 遍历所有页面提取文本
-	for pageNum := 1; pageNum <= r.NumPage(); pageNum++ {
+	// 遍历选中的页面重建版面，正文字号要在全部页面读完后才能确定
+	var pageNums []int
+	var layouts []pageLayout
+	for _, pageNum := range selected {
 		p := r.Page(pageNum)
 		if p.V.IsNull() {
 			continue
 		}
 
-		// 提取页面文本
-		text, err := p.GetPlainText(nil)
-		if err != nil {
+		layout, ok := readLayout(p)
+		if !ok {
+			layout, ok = plainLayout(p)
+		}
+		if !ok {
 			continue
 		}
+		pageNums = append(pageNums, pageNum)
+		layouts = append(layouts, layout)
+	}
+
+	// 没有提取到文本，通常是扫描件
+	if len(layouts) == 0 {
+		return types.Type{}, types.NewError(ErrCodeNoText, "PDF中没有可提取的文字，可能是扫描件", true, nil)
+	}
+
+	toc := readOutline(r)
+	return types.Type{
+		Text:     renderLayout(pageNums, layouts, toc),
+		Metadata: readMetadata(r),
+		Toc:      toc,
+	}, nil
+}
+
+// reUnsupportedEncryption 库对不支持的加密方式返回的错误
+var reUnsupportedEncryption = regexp.MustCompile(`^(?:unsupported PDF: encryption|malformed PDF: \d+-bit encryption key)`)
+
+// openPDF 打开PDF：加密文件依次尝试空密码和passwords；
+// 文件结构损坏（文件头、交叉引用表、%%EOF）时先修复再打开，见repairPDF；
+// 密码错误或加密方式不受支持时文件本身是完好的，直接返回错误，不做修复
+func openPDF(data []byte, passwords []string) (*pdf.Reader, error) {
+	r, err := newReader(data, passwords)
+	if err == nil && countPages(r) > 0 {
+		return r, nil
+	}
+	if encErr := encryptionError(err); encErr != nil {
+		return nil, encErr
+	}
+
+	if repaired := repairPDF(data); repaired != nil {
+		fixed, fixErr := newReader(repaired, passwords)
+		if fixErr == nil && countPages(fixed) > 0 {
+			return fixed, nil
+		}
+		if encErr := encryptionError(fixErr); encErr != nil {
+			return nil, encErr
+		}
+	}
+	if err == nil {
+		err = errors.New("没有可读取的页面")
+	}
+	return nil, types.NewError(ErrCodeMalformed, fmt.Sprintf("PDF文件损坏且无法修复: %v", err), true, err)
+}
 
-		// 添加页面文本到结果
-		textBuilder.WriteString(text)
-		textBuilder.WriteString("\n")
+// encryptionError 将库返回的加密相关错误转换为*types.Error，其他错误返回nil
+// 库只支持RC4和AES-128（V<=4、R<=4）的Standard安全处理器，其余加密返回 "unsupported PDF: encryption ..."；
+// AES-256（V=5）的字典通常写有 /Length 256，库会先报 "malformed PDF: 256-bit encryption key"，同样是不支持
+func encryptionError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pdf.ErrInvalidPassword):
+		return encryptedError(err)
+	case reUnsupportedEncryption.MatchString(err.Error()):
+		return unsupportedEncryptionError(err)
 	}
+	return nil
+}
 
-	// 如果没有提取到文本，返回提示信息
-	if textBuilder.Len() == 0 {
-		return fmt.Sprintf("[PDF文本提取为空]\n文件路径: %s", filePath), nil
+// newReader 用给定的密码列表打开PDF，空密码由库自动尝试
+func newReader(data []byte, passwords []string) (r *pdf.Reader, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			r, err = nil, fmt.Errorf("%v", p)
+		}
+	}()
+
+	var candidates []string
+	for _, pw := range passwords {
+		if pw != "" {
+			candidates = append(candidates, pw)
+		}
 	}
+	next := func() string {
+		if len(candidates) == 0 {
+			return ""
+		}
+		pw := candidates[0]
+		candidates = candidates[1:]
+		return pw
+	}
+	return pdf.NewReaderEncrypted(bytes.NewReader(data), int64(len(data)), next)
+}
 
-	return textBuilder.String(), nil
+// countPages 返回页数，页面树损坏时返回0
+func countPages(r *pdf.Reader) (n int) {
+	defer func() {
+		if recover() != nil {
+			n = 0
+		}
+	}()
+	n = r.NumPage()
+	if n > 0 && r.Page(1).V.IsNull() {
+		return 0
+	}
+	return n
 }
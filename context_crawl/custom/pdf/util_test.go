@@ -0,0 +1,167 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rc4"
+	"errors"
+	"fmt"
+	"testing"
+
+	"context_crawl/types"
+)
+
+// testFileID trailer中的文件标识，加密密钥的计算要用到
+const testFileID = "0123456789abcdef"
+
+// pagePDF 一页的最小文档，trailer为额外的trailer字段，extra为附加的对象（编号从4开始）
+func pagePDF(trailer string, extra ...string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+	}
+	return buildPDF(append(objects, extra...), trailer)
+}
+
+// rc4PDF 用户密码为password的RC4加密文档（V=2，R=3，128位），按PDF 32000-1 7.6.3的算法计算U
+func rc4PDF(password string) []byte {
+	owner := bytes.Repeat([]byte{0x42}, 32) // 只打开文档时O的内容不影响校验，但参与密钥计算
+	const permissions = -4
+
+	pw := append([]byte(password), passwordPadding...)[:32]
+	h := md5.New()
+	h.Write(pw)
+	h.Write(owner)
+	perms := int32(permissions)
+	p := uint32(perms)
+	h.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+	h.Write([]byte(testFileID))
+	key := h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		sum := md5.Sum(key[:16])
+		key = sum[:]
+	}
+
+	h.Reset()
+	h.Write(passwordPadding)
+	h.Write([]byte(testFileID))
+	u := h.Sum(nil)
+	for i := 0; i <= 19; i++ {
+		k := make([]byte, len(key))
+		for j := range key {
+			k[j] = key[j] ^ byte(i)
+		}
+		c, _ := rc4.NewCipher(k)
+		c.XORKeyStream(u, u)
+	}
+	u = append(u, make([]byte, 16)...)
+
+	encrypt := fmt.Sprintf("<< /Filter /Standard /V 2 /R 3 /Length 128 /P %d /O <%x> /U <%x> >>", permissions, owner, u)
+	return pagePDF(fmt.Sprintf("/Encrypt 4 0 R /ID [<%x> <%x>]", testFileID, testFileID), encrypt)
+}
+
+// passwordPadding PDF规范中用于补齐密码的32字节
+var passwordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// encryptedPDF 带给定加密字典的文档，用于库不支持的加密方式
+func encryptedPDF(encrypt string) []byte {
+	return pagePDF(fmt.Sprintf("/Encrypt 4 0 R /ID [<%x> <%x>]", testFileID, testFileID), encrypt)
+}
+
+func TestOpenPDF(t *testing.T) {
+	aes256 := "<< /Filter /Standard /V 5 /R 6 /Length 256 /P -4 /O <%s> /U <%s> /OE <%s> /UE <%s> >>"
+	hex48 := fmt.Sprintf("%096x", 0)
+	hex32 := fmt.Sprintf("%064x", 0)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		passwords []string
+		wantCode  string // 为空时应成功打开
+	}{
+		{name: "plain", data: pagePDF("")},
+		{name: "empty user password", data: rc4PDF("")},
+		{name: "correct password", data: rc4PDF("s3cret"), passwords: []string{"s3cret"}},
+		{name: "correct password after a wrong one", data: rc4PDF("s3cret"), passwords: []string{"nope", "", "s3cret"}},
+		{name: "no password", data: rc4PDF("s3cret"), wantCode: ErrCodeEncrypted},
+		{name: "wrong password", data: rc4PDF("s3cret"), passwords: []string{"nope"}, wantCode: ErrCodeEncrypted},
+		{
+			name:      "aes-256 with key length",
+			data:      encryptedPDF(fmt.Sprintf(aes256, hex48, hex48, hex32, hex32)),
+			passwords: []string{"s3cret"},
+			wantCode:  ErrCodeUnsupportedEncryption,
+		},
+		{
+			name:     "aes-256 without key length",
+			data:     encryptedPDF(fmt.Sprintf("<< /Filter /Standard /V 5 /R 6 /P -4 /O <%s> /U <%s> >>", hex48, hex48)),
+			wantCode: ErrCodeUnsupportedEncryption,
+		},
+		{
+			name:     "public key security handler",
+			data:     encryptedPDF("<< /Filter /Adobe.PubSec /SubFilter /adbe.pkcs7.s5 /V 4 >>"),
+			wantCode: ErrCodeUnsupportedEncryption,
+		},
+		{name: "not a pdf", data: []byte("<html>not a pdf</html>"), wantCode: ErrCodeMalformed},
+		{name: "broken xref is repaired", data: bytes.Replace(pagePDF(""), []byte("xref"), []byte("xxxx"), 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := openPDF(tt.data, tt.passwords)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("openPDF() error = %v", err)
+				}
+				if n := countPages(r); n != 1 {
+					t.Errorf("openPDF() pages = %d, want 1", n)
+				}
+				return
+			}
+			var typed *types.Error
+			if !errors.As(err, &typed) || typed.Code != tt.wantCode {
+				t.Fatalf("openPDF() error = %v, want code %s", err, tt.wantCode)
+			}
+			if !typed.NoFallback {
+				t.Errorf("openPDF() error %s should not fall back to other pipelines", typed.Code)
+			}
+		})
+	}
+}
+
+func TestRepairPDF(t *testing.T) {
+	valid := pagePDF("")
+	tests := []struct {
+		name string
+		data []byte
+		ok   bool
+	}{
+		{name: "junk before header", data: append([]byte("HTTP/1.1 200 OK\r\n\r\n"), valid...), ok: true},
+		{name: "unknown header version", data: bytes.Replace(valid, []byte("%PDF-1.7"), []byte("%PDF-2.0"), 1), ok: true},
+		{name: "truncated before xref", data: valid[:bytes.Index(valid, []byte("xref"))], ok: true},
+		{name: "missing eof", data: bytes.TrimSuffix(valid, []byte("%%EOF\n")), ok: true},
+		{name: "no header", data: []byte("1 0 obj << /Type /Catalog >> endobj"), ok: false},
+		{name: "no objects", data: []byte("%PDF-1.7\nnothing here at all\n"), ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repaired := repairPDF(tt.data)
+			if (repaired != nil) != tt.ok {
+				t.Fatalf("repairPDF() returned %d bytes, want ok=%v", len(repaired), tt.ok)
+			}
+			if !tt.ok {
+				return
+			}
+			r, err := newReader(repaired, nil)
+			if err != nil {
+				t.Fatalf("repaired file does not open: %v", err)
+			}
+			if n := countPages(r); n != 1 {
+				t.Errorf("repaired file pages = %d, want 1", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// ================== 分块翻页handler ===================
+package handler
+
+import (
+	"context_crawl/handler/models"
+	"context_crawl/service"
+	"context_crawl/types"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NextChunks 根据游标返回同一文档的下一页分块，不会重新抓取
+func NextChunks(request models.NextRequest) (models.Response, error) {
+	page, err := service.NextChunks(request.Cursor, request.PageSize)
+	if err != nil {
+		return models.Response{}, err
+	}
+	return models.Response{
+		Code: 0,
+		Msg:  "success",
+		Data: map[string]interface{}{
+			"url":          page.Url,
+			"text":         types.FormatChunksFrom(page.Chunks, page.Offset),
+			"offset":       page.Offset,
+			"total_chunks": page.Total,
+			"next_cursor":  page.NextCursor,
+		},
+	}, nil
+}
+
+// HandleNextChunks 处理分块翻页的HTTP请求
+func HandleNextChunks(c *gin.Context) {
+	var request models.NextRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Cursor == "" {
+		c.JSON(400, models.Response{Code: -1, Msg: "Invalid request body", Data: nil})
+		return
+	}
+
+	response, err := NextChunks(request)
+	if err != nil {
+		// 游标格式错误是调用方的问题，游标过期或文档被淘汰需要重新抓取
+		status := 404
+		if errors.Is(err, service.ErrCursorInvalid) {
+			status = 400
+		}
+		c.JSON(status, models.Response{Code: -1, Msg: err.Error(), Data: nil})
+		return
+	}
+	c.JSON(200, response)
+}
@@ -0,0 +1,61 @@
+// ================== 结构化抽取handler ===================
+package handler
+
+import (
+	"context_crawl/base/scrape"
+	"context_crawl/handler/models"
+	"context_crawl/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExtractURLs 按字段定义抽取多个URL的结构化数据
+func ExtractURLs(request models.ExtractRequest) models.Response {
+	results := service.ExtractURLs(request.Urls, request.Schema, request.Render, 30*time.Second)
+
+	processedResults := make([]map[string]interface{}, 0)
+	failures := make([]map[string]interface{}, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, map[string]interface{}{
+				"url": result.Url,
+				"msg": result.Err.Error(),
+			})
+			continue
+		}
+		processedResults = append(processedResults, map[string]interface{}{
+			"url":    result.Url,
+			"fields": result.Data,
+		})
+	}
+
+	return models.Response{
+		Code: 0,
+		Msg:  "success",
+		Data: map[string]interface{}{
+			"results": processedResults,
+			"errors":  failures,
+		},
+	}
+}
+
+// HandleExtract 处理结构化抽取的HTTP请求
+func HandleExtract(c *gin.Context) {
+	var request models.ExtractRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, models.Response{Code: -1, Msg: "Invalid request body", Data: nil})
+		return
+	}
+	if len(request.Urls) == 0 {
+		c.JSON(400, models.Response{Code: -1, Msg: "urls不能为空", Data: nil})
+		return
+	}
+	if err := scrape.Validate(request.Schema); err != nil {
+		c.JSON(400, models.Response{Code: -1, Msg: err.Error(), Data: nil})
+		return
+	}
+
+	response := ExtractURLs(request)
+	c.JSON(200, response)
+}
@@ -1,6 +1,37 @@
 package models
 
+import "context_crawl/base/scrape"
+
 // ============= 接口接收参数 ===================
 type Request struct {
-	Urls []string `json:"urls"`
+	Urls              []string `json:"urls"`
+	PreserveLinks     bool     `json:"preserve_links"`     // 保留正文中的超链接并返回outlinks
+	ExtractImages     bool     `json:"extract_images"`     // 提取内容图片并返回images
+	ImagePlaceholders bool     `json:"image_placeholders"` // 在正文中插入 ![alt](src) 图片占位
+	Selector          string   `json:"selector"`           // 只抽取该CSS选择器命中的区域
+	ChunkSize         int      `json:"chunk_size"`         // 分块大小上限
+	ChunkOverlap      *int     `json:"chunk_overlap"`      // 相邻分块的重叠长度
+	ChunkUnit         string   `json:"chunk_unit"`         // 分块计数单位：rune / token
+	MaxTokens         int      `json:"max_tokens"`         // 整批结果的token预算，0表示不限制
+	Query             string   `json:"query"`              // 预算不足时按与query的相关度挑选分块
+	Dedupe            bool     `json:"dedupe"`             // 合并本批次中跨页面的近重复分块
+	KeepDuplicates    bool     `json:"keep_duplicates"`    // 保留页面内重复的分块，默认合并
+	PageSize          int      `json:"page_size"`          // 每个URL每页返回的分块数，0表示不分页
+	Summary           bool     `json:"summary"`            // 返回每个页面的摘要和关键词
+	Simplified        bool     `json:"simplified"`         // 正文繁体转简体
+	Pages             string   `json:"pages"`              // PDF只处理这些页，如 "1-5,8,12-"
+	Password          string   `json:"password"`           // 加密PDF的打开密码
+}
+
+// ============= 分块翻页接口参数 ===================
+type NextRequest struct {
+	Cursor   string `json:"cursor"`    // 上一页返回的next_cursor
+	PageSize int    `json:"page_size"` // 本页分块数，0表示与上一页相同
+}
+
+// ============= 结构化抽取接口参数 ===================
+type ExtractRequest struct {
+	Urls   []string       `json:"urls"`
+	Render bool           `json:"render"` // 是否使用浏览器渲染后再抽取
+	Schema []scrape.Field `json:"schema"` // 字段定义
 }
@@ -14,27 +14,91 @@ import (
 // ProcessURLs 处理多个URL的请求
 func ProcessURLs(request models.Request) models.Response {
 	// 将URL列表转换为[]types.Type
+	options := types.Options{
+		PreserveLinks:     request.PreserveLinks,
+		ExtractImages:     request.ExtractImages || request.ImagePlaceholders,
+		ImagePlaceholders: request.ImagePlaceholders,
+		Selector:          request.Selector,
+		ChunkSize:         request.ChunkSize,
+		ChunkOverlap:      request.ChunkOverlap,
+		ChunkUnit:         request.ChunkUnit,
+		Summary:           request.Summary,
+		KeepDuplicates:    request.KeepDuplicates,
+		Simplified:        request.Simplified,
+		Pages:             request.Pages,
+		Password:          request.Password,
+	}
 	var inputs []types.Type
 	for _, url := range request.Urls {
-		inputs = append(inputs, types.Type{Url: url})
+		inputs = append(inputs, types.Type{Url: url, Options: options})
 	}
 
 	// 调用service.HandleURLs处理多个URL，设置10秒超时
-	results := service.HandleURLs(inputs, 10*time.Second)
+	results, failures := service.HandleURLs(inputs, 10*time.Second)
 
 	// 构建响应数据
 	data := make(map[string]interface{})
+
+	// 开启dedupe时合并跨页面的近重复分块
+	merged := make([]int, len(results))
+	if request.Dedupe {
+		merged = service.DeduplicateResults(results)
+	}
+
+	// 按token预算挑选分块
+	var budgets []service.PageBudget
+	if request.MaxTokens > 0 {
+		budgets = service.ApplyTokenBudget(results, request.MaxTokens, request.Query)
+		data["budget"] = formatBudgetSummary(request.MaxTokens, budgets)
+	}
+
 	// 初始化为空数组而不是nil
 	processedResults := make([]map[string]interface{}, 0)
 
-	for _, result := range results {
-		processedResults = append(processedResults, map[string]interface{}{
+	for i, result := range results {
+		item := map[string]interface{}{
 			"url":  result.Url,
 			"text": result.Text,
-		})
+		}
+		if request.Summary {
+			item["summary"] = result.Summary
+			keywords := result.Keywords
+			if keywords == nil {
+				keywords = []string{}
+			}
+			item["keywords"] = keywords
+		}
+		if merged[i] > 0 {
+			item["merged_chunks"] = merged[i]
+		}
+		if budgets != nil {
+			item["budget"] = formatPageBudget(budgets[i])
+		}
+		if request.PageSize > 0 && len(result.Chunks) > 0 {
+			page := service.PaginateChunks(result, request.PageSize)
+			item["text"] = types.FormatChunksFrom(page.Chunks, page.Offset)
+			item["total_chunks"] = page.Total
+			item["next_cursor"] = page.NextCursor
+		}
+		if request.PreserveLinks {
+			item["outlinks"] = formatOutlinks(result.Outlinks)
+		}
+		if options.ExtractImages {
+			item["images"] = formatImages(result.Images)
+		}
+		if len(result.Metadata) > 0 {
+			item["metadata"] = result.Metadata
+		}
+		if len(result.Toc) > 0 {
+			item["toc"] = formatToc(result.Toc)
+		}
+		processedResults = append(processedResults, item)
 	}
 
 	data["results"] = processedResults
+	if len(failures) > 0 {
+		data["errors"] = formatFailures(failures)
+	}
 
 	if len(processedResults) == 0 {
 		log.Printf("⚠️ 没有爬取到任何内容，URLs: %v", request.Urls)
@@ -48,6 +112,96 @@ func ProcessURLs(request models.Request) models.Response {
 	}
 }
 
+// formatOutlinks 将outlinks转换为响应中的列表格式
+func formatOutlinks(outlinks []types.Outlink) []map[string]interface{} {
+	formatted := make([]map[string]interface{}, 0, len(outlinks))
+	for _, link := range outlinks {
+		formatted = append(formatted, map[string]interface{}{
+			"index":    link.Index,
+			"url":      link.Url,
+			"text":     link.Text,
+			"rel":      link.Rel,
+			"internal": link.Internal,
+		})
+	}
+	return formatted
+}
+
+// formatImages 将images转换为响应中的列表格式
+func formatImages(images []types.Image) []map[string]interface{} {
+	formatted := make([]map[string]interface{}, 0, len(images))
+	for _, img := range images {
+		srcset := img.Srcset
+		if srcset == nil {
+			srcset = []string{}
+		}
+		formatted = append(formatted, map[string]interface{}{
+			"src":     img.Src,
+			"srcset":  srcset,
+			"alt":     img.Alt,
+			"caption": img.Caption,
+			"heading": img.Heading,
+			"width":   img.Width,
+			"height":  img.Height,
+		})
+	}
+	return formatted
+}
+
+// formatFailures 将失败的URL转换为响应中的列表格式
+func formatFailures(failures []service.URLFailure) []map[string]interface{} {
+	formatted := make([]map[string]interface{}, 0, len(failures))
+	for _, f := range failures {
+		formatted = append(formatted, map[string]interface{}{
+			"url":     f.Url,
+			"code":    f.Code,
+			"message": f.Message,
+		})
+	}
+	return formatted
+}
+
+// formatToc 将目录转换为响应中的列表格式
+func formatToc(toc []types.TocEntry) []map[string]interface{} {
+	formatted := make([]map[string]interface{}, 0, len(toc))
+	for _, entry := range toc {
+		formatted = append(formatted, map[string]interface{}{
+			"level": entry.Level,
+			"title": entry.Title,
+			"page":  entry.Page,
+		})
+	}
+	return formatted
+}
+
+// formatBudgetSummary 整批结果的预算使用情况
+func formatBudgetSummary(maxTokens int, budgets []service.PageBudget) map[string]interface{} {
+	used, omitted, truncated := 0, 0, false
+	for _, b := range budgets {
+		used += b.ReturnedTokens
+		omitted += b.OmittedTokens
+		truncated = truncated || b.Truncated()
+	}
+	return map[string]interface{}{
+		"max_tokens":     maxTokens,
+		"used_tokens":    used,
+		"omitted_tokens": omitted,
+		"truncated":      truncated,
+	}
+}
+
+// formatPageBudget 单个页面的预算使用情况
+func formatPageBudget(b service.PageBudget) map[string]interface{} {
+	return map[string]interface{}{
+		"total_chunks":     b.TotalChunks,
+		"returned_chunks":  b.ReturnedChunks,
+		"returned_tokens":  b.ReturnedTokens,
+		"omitted_tokens":   b.OmittedTokens,
+		"duplicate_chunks": b.DuplicateChunks,
+		"truncated":        b.Truncated(),
+	}
+}
+
 // HandleProcessURLs 处理多个URL的HTTP请求
 func HandleProcessURLs(c *gin.Context) {
 	var request models.Request
@@ -4,6 +4,9 @@ package main
 
 import (
 	"context_crawl/app"
+	"context_crawl/base/wordseg"
+	"context_crawl/custom/doi"
+	"context_crawl/custom/pdf"
 	"context_crawl/utils"
 	"fmt"
 	"os"
@@ -30,6 +33,34 @@ func main() {
 		return
 	}
 
+	// 加载站点抽取规则
+	utils.SetSiteRules(config.ContextCrawl.SiteRules)
+
+	// 加载中文分词的完整词典和HMM参数，用户词典在替换词典之后加载
+	if config.ContextCrawl.HMMEmit != "" {
+		if err := wordseg.Default().LoadHMMFile(config.ContextCrawl.HMMEmit); err != nil {
+			fmt.Printf("Failed to load HMM parameters: %v\n", err)
+		}
+	}
+	if config.ContextCrawl.Dict != "" {
+		if err := wordseg.Default().LoadDictFile(config.ContextCrawl.Dict); err != nil {
+			fmt.Printf("Failed to load dict: %v\n", err)
+		}
+	}
+
+	// 加载中文分词的用户词典
+	if config.ContextCrawl.UserDict != "" {
+		if err := wordseg.Default().LoadUserDictFile(config.ContextCrawl.UserDict); err != nil {
+			fmt.Printf("Failed to load user dict: %v\n", err)
+		}
+	}
+
+	// PDF下载大小上限
+	pdf.SetMaxDownloadSize(int64(config.ContextCrawl.PDFMaxSizeMB) << 20)
+
+	// 请求Crossref时在User-Agent中附带联系邮箱，进入polite pool
+	doi.SetMailto(config.ContextCrawl.Mailto)
+
 	// 设置路由
 	router := app.RouterAPI()
 
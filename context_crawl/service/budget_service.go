@@ -0,0 +1,233 @@
+// ================== 批量结果的token预算分配 ===================
+
+package service
+
+import (
+	"context_crawl/base/colly"
+	"context_crawl/base/simhash"
+	"context_crawl/base/summary"
+	"context_crawl/base/tokenizer"
+	"context_crawl/base/wordseg"
+	"context_crawl/types"
+	"math"
+	"sort"
+	"strings"
+)
+
+// PageBudget 单个页面在预算分配后的情况，供调用方判断是否需要继续获取
+type PageBudget struct {
+	Url             string
+	TotalChunks     int // 分块总数
+	ReturnedChunks  int // 返回的分块数
+	ReturnedTokens  int // 返回内容的token数
+	OmittedTokens   int // 因预算不足被省略的token数
+	DuplicateChunks int // 与已返回分块近重复而跳过的分块数，不占用预算
+}
+
+// Truncated 是否有分块因预算不足被省略
+func (p PageBudget) Truncated() bool {
+	return p.TotalChunks > p.ReturnedChunks+p.DuplicateChunks
+}
+
+// candidate 参与预算分配的分块
+type candidate struct {
+	page, index int
+	tokens      int
+	priority    float64
+	text        string
+	isCode      bool
+	fingerprint uint64
+	hasPrint    bool // 过短的分块没有指纹，不参与近重复判断
+}
+
+// chunk 返回重复判断所需的分块字段
+func (c candidate) chunk() types.Chunk {
+	return types.Chunk{Text: c.text, IsCode: c.isCode, Fingerprint: c.fingerprint, HasFingerprint: c.hasPrint}
+}
+
+// ApplyTokenBudget 按maxTokens为整批结果分配预算，原地改写分块有变化的结果的Chunks和Text
+// 先按页面平均分配，每个页面内优先保留质量分（有query时为相关度）高的分块，
+// 用不完的额度再按优先级在所有页面间分配，页面内保持原有顺序；
+// 与已选分块重复的分块（见colly.DuplicateChunk）直接跳过，不占用预算，其页面URL追加到已选分块的Sources中
+func ApplyTokenBudget(results []types.Type, maxTokens int, query string) []PageBudget {
+	counter := tokenizer.NewCounter(tokenizer.UnitToken)
+
+	reports := make([]PageBudget, len(results))
+	var candidates []candidate
+	for p, result := range results {
+		reports[p] = PageBudget{Url: result.Url, TotalChunks: len(result.Chunks)}
+		for i, chunk := range result.Chunks {
+			c := candidate{
+				page:        p,
+				index:       i,
+				tokens:      counter.Count(types.FormatChunks([]types.Chunk{chunk})),
+				priority:    chunk.Score,
+				text:        chunk.Text,
+				isCode:      chunk.IsCode,
+				fingerprint: chunk.Fingerprint,
+				hasPrint:    chunk.HasFingerprint,
+			}
+			if !c.hasPrint && chunk.Text != types.EmptyChunkText {
+				c.fingerprint, c.hasPrint = simhash.Compute(chunk.Text)
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	if query = strings.TrimSpace(query); query != "" {
+		relevance := queryRelevance(query, candidates)
+		for i := range candidates {
+			candidates[i].priority = 0.7*relevance[i] + 0.3*candidates[i].priority
+		}
+	}
+
+	// 优先级从高到低，相同时保持原顺序
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].priority > candidates[j].priority
+	})
+
+	selected := make([]map[int]bool, len(results))
+	for p := range selected {
+		selected[p] = make(map[int]bool)
+	}
+	duplicate := make([]map[int]bool, len(results))
+	for p := range duplicate {
+		duplicate[p] = make(map[int]bool)
+	}
+	var kept []candidate                      // 已选中的分块
+	extraSources := make(map[[2]int][]string) // 已选分块 -> 被跳过的近重复分块所在页面
+	changed := make([]bool, len(results))
+	remaining := maxTokens
+
+	take := func(c candidate, limit int) bool {
+		if selected[c.page][c.index] || duplicate[c.page][c.index] {
+			return false
+		}
+		for _, k := range kept {
+			if colly.DuplicateChunk(k.chunk(), c.chunk()) {
+				duplicate[c.page][c.index] = true
+				reports[c.page].DuplicateChunks++
+				key := [2]int{k.page, k.index}
+				extraSources[key] = appendSource(extraSources[key], results[c.page].Url)
+				return false
+			}
+		}
+		if c.tokens > limit || c.tokens > remaining {
+			return false
+		}
+		selected[c.page][c.index] = true
+		kept = append(kept, c)
+		remaining -= c.tokens
+		reports[c.page].ReturnedChunks++
+		reports[c.page].ReturnedTokens += c.tokens
+		return true
+	}
+
+	// 第一轮：每个页面在平均额度内取优先级最高的分块
+	if len(results) > 0 {
+		share := maxTokens / len(results)
+		used := make([]int, len(results))
+		for _, c := range candidates {
+			if take(c, share-used[c.page]) {
+				used[c.page] += c.tokens
+			}
+		}
+	}
+	// 第二轮：剩余额度按优先级在所有页面间分配
+	for _, c := range candidates {
+		take(c, remaining)
+	}
+
+	for _, c := range candidates {
+		if !selected[c.page][c.index] {
+			changed[c.page] = true
+			if !duplicate[c.page][c.index] {
+				reports[c.page].OmittedTokens += c.tokens
+			}
+		}
+	}
+
+	for p := range results {
+		var chunks []types.Chunk
+		for i, chunk := range results[p].Chunks {
+			if !selected[p][i] {
+				continue
+			}
+			if extra := extraSources[[2]int{p, i}]; len(extra) > 0 {
+				sources := append([]string(nil), chunk.Sources...)
+				if len(sources) == 0 {
+					sources = []string{results[p].Url}
+				}
+				for _, url := range extra {
+					sources = appendSource(sources, url)
+				}
+				chunk.Sources = sources
+				changed[p] = true
+			}
+			chunks = append(chunks, chunk)
+		}
+		// 分块没有变化的页面保留原有文本（如DeduplicateResults生成的合并提示）
+		if !changed[p] {
+			continue
+		}
+		results[p].Chunks = chunks
+		results[p].Text = types.FormatChunks(chunks)
+	}
+	return reports
+}
+
+// queryRelevance 计算每个分块与query的相关度（0~1）：
+// 命中的查询词按idf加权后占全部查询词权重的比例，中文查询先分词
+func queryRelevance(query string, candidates []candidate) []float64 {
+	terms := queryTerms(query)
+	relevance := make([]float64, len(candidates))
+	if len(terms) == 0 {
+		return relevance
+	}
+
+	lowered := make([]string, len(candidates))
+	for i, c := range candidates {
+		lowered[i] = strings.ToLower(c.text)
+	}
+
+	weights := make([]float64, len(terms))
+	total := 0.0
+	for t, term := range terms {
+		df := 0
+		for _, text := range lowered {
+			if strings.Contains(text, term) {
+				df++
+			}
+		}
+		weights[t] = math.Log(1 + float64(len(candidates)+1)/float64(df+1))
+		total += weights[t]
+	}
+
+	for i, text := range lowered {
+		hit := 0.0
+		for t, term := range terms {
+			if strings.Contains(text, term) {
+				hit += weights[t]
+			}
+		}
+		relevance[i] = hit / total
+	}
+	return relevance
+}
+
+// queryTerms 将query切分为去重后的小写查询词
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	for _, word := range wordseg.Words(query) {
+		if !summary.IsStopword(word) {
+			add(word)
+		}
+	}
+	return terms
+}
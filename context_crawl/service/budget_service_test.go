@@ -0,0 +1,146 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"context_crawl/base/tokenizer"
+	"context_crawl/types"
+)
+
+// chunkTokens 单个分块在预算中占用的token数
+func chunkTokens(chunk types.Chunk) int {
+	return tokenizer.NewCounter(tokenizer.UnitToken).Count(types.FormatChunks([]types.Chunk{chunk}))
+}
+
+func chunkTexts(chunks []types.Chunk) []string {
+	var texts []string
+	for _, c := range chunks {
+		texts = append(texts, c.Text)
+	}
+	return texts
+}
+
+var (
+	textDeploy  = "Deploy the service with Helm and set the replica count in values.yaml before upgrading the release."
+	textBackup  = "Nightly backups are written to object storage and kept for thirty days unless the retention policy changes."
+	textMetrics = "Prometheus scrapes the metrics endpoint every fifteen seconds and alerts fire after three failed probes."
+	textAuth    = "Requests must carry a bearer token issued by the identity provider, otherwise the gateway rejects them."
+)
+
+func TestApplyTokenBudgetShare(t *testing.T) {
+	// 第一个页面的分块质量分都更高，平均分配保证第二个页面也能拿到额度
+	a := []types.Chunk{{Text: textDeploy, Score: 0.9}, {Text: textBackup, Score: 0.8}}
+	b := []types.Chunk{{Text: textMetrics, Score: 0.5}, {Text: textAuth, Score: 0.4}}
+	results := []types.Type{
+		{Url: "https://a.com", Chunks: a, Text: types.FormatChunks(a)},
+		{Url: "https://b.com", Chunks: b, Text: types.FormatChunks(b)},
+	}
+	budget := chunkTokens(a[0]) + chunkTokens(b[0]) + 1
+	reports := ApplyTokenBudget(results, budget, "")
+
+	if got, want := chunkTexts(results[0].Chunks), []string{textDeploy}; !reflect.DeepEqual(got, want) {
+		t.Errorf("page a chunks = %q, want %q", got, want)
+	}
+	if got, want := chunkTexts(results[1].Chunks), []string{textMetrics}; !reflect.DeepEqual(got, want) {
+		t.Errorf("page b chunks = %q, want %q", got, want)
+	}
+	want := []PageBudget{
+		{Url: "https://a.com", TotalChunks: 2, ReturnedChunks: 1, ReturnedTokens: chunkTokens(a[0]), OmittedTokens: chunkTokens(a[1])},
+		{Url: "https://b.com", TotalChunks: 2, ReturnedChunks: 1, ReturnedTokens: chunkTokens(b[0]), OmittedTokens: chunkTokens(b[1])},
+	}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("reports = %+v, want %+v", reports, want)
+	}
+	for _, r := range reports {
+		if !r.Truncated() {
+			t.Errorf("%s Truncated() = false, want true", r.Url)
+		}
+	}
+	if results[0].Text != types.FormatChunks(results[0].Chunks) {
+		t.Errorf("page a text not rebuilt from kept chunks: %q", results[0].Text)
+	}
+}
+
+func TestApplyTokenBudgetLeftover(t *testing.T) {
+	// 第二个页面用不完的额度分给第一个页面
+	a := []types.Chunk{{Text: textDeploy, Score: 0.9}, {Text: textBackup, Score: 0.8}}
+	b := []types.Chunk{{Text: "Short page.", Score: 0.5}}
+	results := []types.Type{{Url: "https://a.com", Chunks: a}, {Url: "https://b.com", Chunks: b, Text: "original"}}
+	budget := chunkTokens(a[0]) + chunkTokens(a[1]) + chunkTokens(b[0])
+	reports := ApplyTokenBudget(results, budget, "")
+
+	if len(results[0].Chunks) != 2 || len(results[1].Chunks) != 1 {
+		t.Errorf("chunks = %q / %q, want everything kept", chunkTexts(results[0].Chunks), chunkTexts(results[1].Chunks))
+	}
+	if reports[0].Truncated() || reports[1].Truncated() {
+		t.Errorf("reports = %+v, want nothing truncated", reports)
+	}
+	if results[1].Text != "original" {
+		t.Errorf("unchanged page text = %q, want it kept", results[1].Text)
+	}
+}
+
+func TestApplyTokenBudgetQuery(t *testing.T) {
+	chunks := []types.Chunk{
+		{Text: textDeploy, Score: 0.9},
+		{Text: textBackup, Score: 0.8},
+		{Text: textAuth, Score: 0.3},
+	}
+	results := []types.Type{{Url: "https://a.com", Chunks: chunks}}
+	ApplyTokenBudget(results, chunkTokens(chunks[2])+1, "bearer token")
+
+	if got, want := chunkTexts(results[0].Chunks), []string{textAuth}; !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks = %q, want the query-relevant chunk %q", got, want)
+	}
+}
+
+func TestApplyTokenBudgetKeepsOrder(t *testing.T) {
+	// 优先级最低的分块被省略，其余分块保持原文顺序
+	chunks := []types.Chunk{
+		{Text: textBackup, Score: 0.4},
+		{Text: textMetrics, Score: 0.2},
+		{Text: textDeploy, Score: 0.9},
+	}
+	results := []types.Type{{Url: "https://a.com", Chunks: chunks}}
+	ApplyTokenBudget(results, chunkTokens(chunks[0])+chunkTokens(chunks[2]), "")
+
+	if got, want := chunkTexts(results[0].Chunks), []string{textBackup, textDeploy}; !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks = %q, want %q", got, want)
+	}
+}
+
+func TestApplyTokenBudgetDuplicates(t *testing.T) {
+	results := []types.Type{
+		{Url: "https://a.com/post", Chunks: []types.Chunk{{Text: textDeploy, Score: 0.9}}},
+		{Url: "https://mirror.com/post", Chunks: []types.Chunk{{Text: textDeploy, Score: 0.9}, {Text: textBackup, Score: 0.5}}},
+	}
+	reports := ApplyTokenBudget(results, 10000, "")
+
+	if got, want := chunkTexts(results[1].Chunks), []string{textBackup}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mirror chunks = %q, want %q", got, want)
+	}
+	if got, want := results[0].Chunks[0].Sources, []string{"https://a.com/post", "https://mirror.com/post"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("kept chunk sources = %v, want %v", got, want)
+	}
+	if reports[1].DuplicateChunks != 1 || reports[1].OmittedTokens != 0 || reports[1].Truncated() {
+		t.Errorf("mirror report = %+v, want one duplicate and nothing truncated", reports[1])
+	}
+	if want := chunkTokens(types.Chunk{Text: textBackup, Score: 0.5}); reports[1].ReturnedTokens != want {
+		t.Errorf("mirror returned tokens = %d, want %d: duplicates should not use budget", reports[1].ReturnedTokens, want)
+	}
+}
+
+func TestApplyTokenBudgetAfterDedupe(t *testing.T) {
+	// dedupe已合并整页内容时，预算阶段保留合并提示
+	results := []types.Type{
+		{Url: "https://a.com/post", Chunks: []types.Chunk{{Text: textDeploy, Score: 0.9}}},
+		{Url: "https://b.com/post", Chunks: []types.Chunk{{Text: textDeploy, Score: 0.9}}},
+	}
+	DeduplicateResults(results)
+	ApplyTokenBudget(results, 10000, "")
+
+	if results[1].Text != mergedPageText {
+		t.Errorf("merged page text = %q, want %q", results[1].Text, mergedPageText)
+	}
+}
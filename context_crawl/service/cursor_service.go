@@ -0,0 +1,235 @@
+// ================== 分块分页service ===================
+
+package service
+
+import (
+	"container/list"
+	"context_crawl/types"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 分页游标的暂存设置
+const (
+	CursorTTL           = 30 * time.Minute // 游标的有效期，过期后需要重新抓取
+	CursorStoreMaxBytes = 256 << 20        // 暂存分块的总大小上限，超过时淘汰最久未访问的文档
+	cursorSweepInterval = time.Minute      // 后台清理过期文档的间隔
+)
+
+// 游标错误：ErrCursorInvalid 为调用方的输入错误，ErrCursorNotFound 为游标本身合法但文档已过期或被淘汰
+var (
+	ErrCursorInvalid  = errors.New("cursor格式无效")
+	ErrCursorNotFound = errors.New("cursor不存在或已过期")
+)
+
+// ChunkPage 一页分块
+type ChunkPage struct {
+	Url        string
+	Chunks     []types.Chunk
+	Offset     int    // 本页第一个分块在文档中的位置（从0开始）
+	Total      int    // 文档的分块总数
+	NextCursor string // 获取下一页的游标，没有下一页时为空
+}
+
+// storedDoc 服务端暂存的完整分块结果
+type storedDoc struct {
+	id      string
+	url     string
+	chunks  []types.Chunk
+	size    int // 估算的内存占用（字节）
+	expires time.Time
+}
+
+// chunkStore 内存中的分块暂存，按文档id索引
+// 按最近访问顺序组成LRU，总大小超过maxBytes时淘汰最久未访问的文档；过期文档由后台定期清理
+type chunkStore struct {
+	mu       sync.Mutex
+	docs     map[string]*list.Element // 元素的值为*storedDoc
+	lru      *list.List               // 队首为最近访问
+	size     int
+	maxBytes int
+	ttl      time.Duration
+}
+
+var cursorStore = newChunkStore(CursorStoreMaxBytes, CursorTTL)
+
+func init() {
+	go cursorStore.sweepEvery(cursorSweepInterval)
+}
+
+func newChunkStore(maxBytes int, ttl time.Duration) *chunkStore {
+	return &chunkStore{
+		docs:     make(map[string]*list.Element),
+		lru:      list.New(),
+		maxBytes: maxBytes,
+		ttl:      ttl,
+	}
+}
+
+// put 暂存文档并返回文档id；文档本身超过大小上限时不暂存，返回false
+func (s *chunkStore) put(url string, chunks []types.Chunk) (string, bool) {
+	size := docSize(url, chunks)
+	if size > s.maxBytes {
+		return "", false
+	}
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	id := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc := &storedDoc{id: id, url: url, chunks: chunks, size: size, expires: time.Now().Add(s.ttl)}
+	s.docs[id] = s.lru.PushFront(doc)
+	s.size += size
+	for s.size > s.maxBytes {
+		s.remove(s.lru.Back())
+	}
+	return id, true
+}
+
+// get 取出未过期的文档，每次访问都会延长有效期并移到LRU队首
+func (s *chunkStore) get(id string) (*storedDoc, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.docs[id]
+	if !ok {
+		return nil, false
+	}
+	doc := elem.Value.(*storedDoc)
+	if time.Now().After(doc.expires) {
+		s.remove(elem)
+		return nil, false
+	}
+	doc.expires = time.Now().Add(s.ttl)
+	s.lru.MoveToFront(elem)
+	return doc, true
+}
+
+// sweep 清理所有过期的文档
+func (s *chunkStore) sweep(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for elem := s.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if now.After(elem.Value.(*storedDoc).expires) {
+			s.remove(elem)
+		}
+		elem = prev
+	}
+}
+
+// sweepEvery 定期清理过期文档，没有翻页请求时内存也能及时释放
+func (s *chunkStore) sweepEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		s.sweep(now)
+	}
+}
+
+// remove 移除一个文档，调用方需持有锁
+func (s *chunkStore) remove(elem *list.Element) {
+	doc := s.lru.Remove(elem).(*storedDoc)
+	delete(s.docs, doc.id)
+	s.size -= doc.size
+}
+
+// docSize 估算文档暂存后的内存占用，以文本长度为主
+func docSize(url string, chunks []types.Chunk) int {
+	size := len(url)
+	for _, c := range chunks {
+		size += len(c.Text) + len(c.Lang) + 128
+		for _, h := range c.HeadingPath {
+			size += len(h)
+		}
+		for _, src := range c.Sources {
+			size += len(src)
+		}
+	}
+	return size
+}
+
+// PaginateChunks 返回结果的第一页分块；分块多于pageSize时暂存完整结果并生成下一页的游标
+func PaginateChunks(result types.Type, pageSize int) ChunkPage {
+	page := ChunkPage{Url: result.Url, Chunks: result.Chunks, Total: len(result.Chunks)}
+	if pageSize <= 0 || len(result.Chunks) <= pageSize {
+		return page
+	}
+	page.Chunks = result.Chunks[:pageSize]
+	id, ok := cursorStore.put(result.Url, result.Chunks)
+	if !ok {
+		log.Printf("⚠️ 分块结果超过暂存上限，不生成翻页游标: %s", result.Url)
+		return page
+	}
+	page.NextCursor = encodeCursor(id, pageSize, pageSize)
+	return page
+}
+
+// NextChunks 根据游标返回下一页分块，pageSize为0时沿用上一页的大小
+// 游标或pageSize不合法时返回ErrCursorInvalid，文档已过期或被淘汰时返回ErrCursorNotFound
+func NextChunks(cursor string, pageSize int) (ChunkPage, error) {
+	if pageSize < 0 {
+		return ChunkPage{}, fmt.Errorf("%w: page_size不能为负数", ErrCursorInvalid)
+	}
+	id, offset, size, err := decodeCursor(cursor)
+	if err != nil {
+		return ChunkPage{}, err
+	}
+	if pageSize > 0 {
+		size = pageSize
+	}
+	doc, ok := cursorStore.get(id)
+	if !ok {
+		return ChunkPage{}, ErrCursorNotFound
+	}
+	if offset >= len(doc.chunks) {
+		return ChunkPage{}, fmt.Errorf("%w: 偏移超出分块总数", ErrCursorInvalid)
+	}
+
+	end := offset + size
+	if end > len(doc.chunks) {
+		end = len(doc.chunks)
+	}
+	page := ChunkPage{
+		Url:    doc.url,
+		Chunks: doc.chunks[offset:end],
+		Offset: offset,
+		Total:  len(doc.chunks),
+	}
+	if end < len(doc.chunks) {
+		page.NextCursor = encodeCursor(id, end, size)
+	}
+	return page, nil
+}
+
+// encodeCursor 游标对调用方不透明，内部为 文档id:偏移:页大小
+func encodeCursor(id string, offset, pageSize int) string {
+	raw := fmt.Sprintf("%s:%d:%d", id, offset, pageSize)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor 解析游标，格式不对时返回ErrCursorInvalid
+func decodeCursor(cursor string) (string, int, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, 0, ErrCursorInvalid
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", 0, 0, ErrCursorInvalid
+	}
+	offset, err1 := strconv.Atoi(parts[1])
+	size, err2 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || offset < 0 || size <= 0 {
+		return "", 0, 0, ErrCursorInvalid
+	}
+	return parts[0], offset, size, nil
+}
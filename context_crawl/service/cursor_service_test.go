@@ -0,0 +1,109 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"context_crawl/types"
+)
+
+func makeChunks(n, size int) []types.Chunk {
+	chunks := make([]types.Chunk, n)
+	for i := range chunks {
+		chunks[i] = types.Chunk{Text: fmt.Sprintf("%d%s", i, strings.Repeat("x", size))}
+	}
+	return chunks
+}
+
+func TestChunkStoreEviction(t *testing.T) {
+	one := docSize("u", makeChunks(2, 100))
+	store := newChunkStore(3*one, time.Minute)
+
+	a, _ := store.put("u", makeChunks(2, 100))
+	b, _ := store.put("u", makeChunks(2, 100))
+	c, _ := store.put("u", makeChunks(2, 100))
+	// 访问a后b成为最久未访问的文档
+	if _, ok := store.get(a); !ok {
+		t.Fatal("a should be stored")
+	}
+	d, _ := store.put("u", makeChunks(2, 100))
+
+	tests := []struct {
+		id   string
+		want bool
+	}{{a, true}, {b, false}, {c, true}, {d, true}}
+	for i, tt := range tests {
+		if _, ok := store.get(tt.id); ok != tt.want {
+			t.Errorf("doc %d stored = %v, want %v", i, ok, tt.want)
+		}
+	}
+	if store.size > store.maxBytes {
+		t.Errorf("store size %d exceeds limit %d", store.size, store.maxBytes)
+	}
+	if _, ok := store.put("u", makeChunks(10, 100)); ok {
+		t.Error("doc larger than the limit should not be stored")
+	}
+}
+
+func TestChunkStoreSweep(t *testing.T) {
+	store := newChunkStore(1<<20, time.Minute)
+	old, _ := store.put("u", makeChunks(1, 10))
+	store.docs[old].Value.(*storedDoc).expires = time.Now().Add(-time.Second)
+	fresh, _ := store.put("u", makeChunks(1, 10))
+
+	store.sweep(time.Now())
+	if _, ok := store.docs[old]; ok {
+		t.Error("expired doc should be swept")
+	}
+	if _, ok := store.docs[fresh]; !ok {
+		t.Error("fresh doc should be kept")
+	}
+	if store.size != docSize("u", makeChunks(1, 10)) || store.lru.Len() != 1 {
+		t.Errorf("size = %d, lru = %d after sweep", store.size, store.lru.Len())
+	}
+}
+
+func TestNextChunks(t *testing.T) {
+	result := types.Type{Url: "https://example.com/doc", Chunks: makeChunks(5, 10)}
+	first := PaginateChunks(result, 2)
+	if len(first.Chunks) != 2 || first.NextCursor == "" {
+		t.Fatalf("PaginateChunks() = %+v", first)
+	}
+	id, _, _, _ := decodeCursor(first.NextCursor)
+
+	tests := []struct {
+		name       string
+		cursor     string
+		pageSize   int
+		wantErr    error
+		wantOffset int
+		wantLen    int
+		wantNext   bool
+	}{
+		{"second page", first.NextCursor, 0, nil, 2, 2, true},
+		{"larger page", first.NextCursor, 10, nil, 2, 3, false},
+		{"negative page size", first.NextCursor, -1, ErrCursorInvalid, 0, 0, false},
+		{"not base64", "%%%", 0, ErrCursorInvalid, 0, 0, false},
+		{"wrong fields", base64.RawURLEncoding.EncodeToString([]byte("abc:1")), 0, ErrCursorInvalid, 0, 0, false},
+		{"offset past end", encodeCursor(id, 9, 2), 0, ErrCursorInvalid, 0, 0, false},
+		{"unknown document", encodeCursor("deadbeef", 2, 2), 0, ErrCursorNotFound, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := NextChunks(tt.cursor, tt.pageSize)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("NextChunks() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if page.Offset != tt.wantOffset || len(page.Chunks) != tt.wantLen || (page.NextCursor != "") != tt.wantNext {
+				t.Errorf("NextChunks() = offset %d, %d chunks, next %q", page.Offset, len(page.Chunks), page.NextCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// ================== 批量结果的跨页面去重 ===================
+
+package service
+
+import (
+	"context_crawl/base/colly"
+	"context_crawl/base/simhash"
+	"context_crawl/types"
+)
+
+// mergedPageText 页面的全部分块都与其他页面重复时返回的提示
+const mergedPageText = "该页面内容与本批次中的其他页面重复，已合并到其他结果中（见分块的sources）。"
+
+// DeduplicateResults 合并整批结果中跨页面的近重复分块（镜像站、转载文章、同一文档的多个版本等），原地改写每个结果
+// 分块只保留在最先出现的页面中，并在其Sources中追加其他页面的URL；返回每个结果被合并掉的分块数
+// 请求开启dedupe时调用；重复按colly.DuplicateChunk判断，代码只合并原文相同的，没有指纹的过短正文不参与合并
+func DeduplicateResults(results []types.Type) []int {
+	type ref struct{ page, index int }
+	var survivors []ref
+	merged := make([]int, len(results))
+
+	for p := range results {
+		var chunks []types.Chunk
+		var own []ref // 本页保留的分块，本页内部的重复已在分块阶段处理
+		for _, chunk := range results[p].Chunks {
+			if chunk.Text == types.EmptyChunkText {
+				chunks = append(chunks, chunk)
+				continue
+			}
+			if !chunk.HasFingerprint {
+				chunk.Fingerprint, chunk.HasFingerprint = simhash.Compute(chunk.Text)
+			}
+			duplicate := false
+			for _, s := range survivors {
+				survivor := &results[s.page].Chunks[s.index]
+				if colly.DuplicateChunk(*survivor, chunk) {
+					survivor.Sources = appendSource(survivor.Sources, results[p].Url)
+					duplicate = true
+					break
+				}
+			}
+			if duplicate {
+				merged[p]++
+				continue
+			}
+			if len(chunk.Sources) == 0 {
+				chunk.Sources = []string{results[p].Url}
+			}
+			chunks = append(chunks, chunk)
+			own = append(own, ref{page: p, index: len(chunks) - 1})
+		}
+		results[p].Chunks = chunks
+		survivors = append(survivors, own...)
+	}
+
+	// 来源可能在后面的页面中追加，最后统一重新生成文本
+	for p := range results {
+		if merged[p] == 0 && !hasMultipleSources(results[p].Chunks) {
+			continue
+		}
+		if len(results[p].Chunks) == 0 {
+			results[p].Text = mergedPageText
+			continue
+		}
+		results[p].Text = types.FormatChunks(results[p].Chunks)
+	}
+	return merged
+}
+
+// appendSource 追加来源URL，已存在时忽略
+func appendSource(sources []string, url string) []string {
+	for _, s := range sources {
+		if s == url {
+			return sources
+		}
+	}
+	return append(sources, url)
+}
+
+func hasMultipleSources(chunks []types.Chunk) bool {
+	for _, c := range chunks {
+		if len(c.Sources) > 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"context_crawl/types"
+)
+
+func TestDeduplicateResults(t *testing.T) {
+	article := "Kubernetes schedules pods onto nodes based on resource requests, affinity rules and taints, then keeps them running."
+	other := "Install the CLI with Homebrew and log in with your API token before creating the first project."
+
+	results := []types.Type{
+		{Url: "https://a.com/post", Chunks: []types.Chunk{{Text: article}, {Text: "Share"}}},
+		{Url: "https://b.com/post", Chunks: []types.Chunk{{Text: article}, {Text: "Share"}, {Text: other}}},
+		{Url: "https://c.com/post", Chunks: []types.Chunk{{Text: article}}},
+	}
+	merged := DeduplicateResults(results)
+
+	if want := []int{0, 1, 1}; !reflect.DeepEqual(merged, want) {
+		t.Errorf("merged = %v, want %v", merged, want)
+	}
+	tests := []struct {
+		page    int
+		texts   []string
+		sources [][]string
+	}{
+		{0, []string{article, "Share"}, [][]string{{"https://a.com/post", "https://b.com/post", "https://c.com/post"}, {"https://a.com/post"}}},
+		// 过短的分块没有指纹，不会被合并
+		{1, []string{"Share", other}, [][]string{{"https://b.com/post"}, {"https://b.com/post"}}},
+		{2, nil, nil},
+	}
+	for _, tt := range tests {
+		chunks := results[tt.page].Chunks
+		if len(chunks) != len(tt.texts) {
+			t.Fatalf("page %d chunks = %+v, want %q", tt.page, chunks, tt.texts)
+		}
+		for i, chunk := range chunks {
+			if chunk.Text != tt.texts[i] || !reflect.DeepEqual(chunk.Sources, tt.sources[i]) {
+				t.Errorf("page %d chunk %d = %q %v, want %q %v", tt.page, i, chunk.Text, chunk.Sources, tt.texts[i], tt.sources[i])
+			}
+		}
+	}
+	if results[2].Text != mergedPageText {
+		t.Errorf("fully merged page text = %q", results[2].Text)
+	}
+}
@@ -0,0 +1,72 @@
+// ================== 结构化抽取service ===================
+
+package service
+
+import (
+	"context"
+	"context_crawl/base/colly"
+	"context_crawl/base/scrape"
+	"context_crawl/utils"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ExtractResult 单个URL的结构化抽取结果
+type ExtractResult struct {
+	Url  string
+	Data map[string]interface{}
+	Err  error
+}
+
+// ExtractURL 抓取单个URL并按字段定义抽取结构化数据，ctx取消时放弃抓取
+// 与正文抓取一样应用匹配的站点规则：额外请求头和渲染方式（dynamic、auto）
+func ExtractURL(ctx context.Context, url string, fields []scrape.Field, render bool) (map[string]interface{}, error) {
+	rule, _ := utils.MatchSiteRule(url)
+	crawler := colly.NewCollyCrawler()
+	html, err := crawler.FetchDocument(ctx, url, render, rule)
+	if err != nil {
+		return nil, err
+	}
+	return scrape.Extract(html, url, fields)
+}
+
+// ExtractURLs 并发抽取多个URL，结果顺序与输入一致
+// 超时或失败的URL在结果中带有Err
+func ExtractURLs(urls []string, fields []scrape.Field, render bool, timeout time.Duration) []ExtractResult {
+	results := make([]ExtractResult, len(urls))
+	var wg sync.WaitGroup
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for i, url := range urls {
+		wg.Add(1)
+
+		go func(i int, url string) {
+			defer wg.Done()
+
+			done := make(chan ExtractResult, 1)
+			go func() {
+				// 超时后放弃等待的同时取消抓取，及时释放浏览器并发名额
+				data, err := ExtractURL(ctx, url, fields, render)
+				done <- ExtractResult{Url: url, Data: data, Err: err}
+			}()
+
+			select {
+			case result := <-done:
+				if result.Err != nil {
+					log.Printf("❌ 结构化抽取失败: %v, URL: %s", result.Err, url)
+				}
+				results[i] = result
+			case <-ctx.Done():
+				log.Printf("⏰ 结构化抽取超时: %s", url)
+				results[i] = ExtractResult{Url: url, Err: fmt.Errorf("处理超时")}
+			}
+		}(i, url)
+	}
+
+	wg.Wait()
+	return results
+}
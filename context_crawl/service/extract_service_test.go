@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"context_crawl/base/scrape"
+	"context_crawl/utils"
+)
+
+func TestExtractURLAppliesSiteRule(t *testing.T) {
+	page := "<html><body><h1 class=\"title\">Release notes</h1><p>" + strings.Repeat("Fixed a crash when loading large files. ", 5) + "</p></body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 只有带站点规则里的请求头才返回正文
+		if r.Header.Get("X-Api-Key") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	fields := []scrape.Field{{Name: "title", Selector: "h1.title"}}
+	tests := []struct {
+		name    string
+		rules   []utils.SiteRule
+		wantErr bool
+	}{
+		{name: "no rule", wantErr: true},
+		{
+			name:  "rule headers",
+			rules: []utils.SiteRule{{Domain: "127.0.0.1", Headers: map[string]string{"X-Api-Key": "secret"}}},
+		},
+		{
+			// 静态结果不像JS渲染的页面，不会启动浏览器
+			name:  "auto render keeps a static page",
+			rules: []utils.SiteRule{{Domain: "127.0.0.1", Render: utils.RenderAuto, Headers: map[string]string{"X-Api-Key": "secret"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utils.SetSiteRules(tt.rules)
+			defer utils.SetSiteRules(nil)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			data, err := ExtractURL(ctx, server.URL+"/notes", fields, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && data["title"] != "Release notes" {
+				t.Errorf("ExtractURL() = %v, want title %q", data, "Release notes")
+			}
+		})
+	}
+}
@@ -12,9 +12,24 @@ import (
 	"time"
 )
 
+// 非types.Error的失败使用的错误码
+const (
+	ErrCodeCrawlFailed = "crawl_failed" // 所有pipeline都失败
+	ErrCodeTimeout     = "timeout"      // 处理超时
+)
+
+// URLFailure 处理失败的URL及原因
+type URLFailure struct {
+	Url     string
+	Code    string // 错误码，来自types.Error，其余为ErrCodeCrawlFailed或ErrCodeTimeout
+	Message string
+}
+
 // HandleURL 处理单个URL
 // 根据URL选择合适的pipeline，然后使用该pipeline处理数据
-// 如果当前pipeline失败或返回空，会尝试下一个pipeline（保底机制）
+// 如果当前pipeline失败或返回空，会尝试下一个pipeline（保底机制）；
+// pipeline返回NoFallback的types.Error时（如加密PDF）其他pipeline同样无能为力，直接返回该错误；
+// 返回带Handoff的types.Error时（如链接内容实为PDF）改用其指定的pipeline处理
 func HandleURL(input types.Type) (types.Type, error) {
 	// 获取第一个匹配的pipeline
 	pipeline, found := core.ChoosePipeline(input.Url)
@@ -26,14 +41,26 @@ func HandleURL(input types.Type) (types.Type, error) {
 	fallbackPipelines := core.GetPipelinesAfter(input.Url)
 	allPipelines := append([]core.PipelineEntry{{Index: 0, Name: "primary", Pipeline: pipeline}}, fallbackPipelines...)
 
+	var lastErr error
 	for i, entry := range allPipelines {
 		p := entry.Pipeline
+		// 已超过截止时间的请求不再尝试保底pipeline
+		if i > 0 && !input.Options.Deadline.IsZero() && time.Now().After(input.Options.Deadline) {
+			break
+		}
 
 		// 使用pipeline处理数据
 		result, err := p.Process(input)
+		if typed, ok := types.AsError(err); ok && typed.Handoff != "" {
+			result, err = handoff(input, typed)
+		}
 		if err != nil {
 			log.Printf("⚠️ 第%d个pipeline(%s)处理失败: %v, URL: %s",
 				i+1, entry.Name, err, input.Url)
+			if typed, ok := types.AsError(err); ok && typed.NoFallback {
+				return types.Type{}, err
+			}
+			lastErr = err
 			continue // 尝试下一个pipeline
 		}
 
@@ -50,18 +77,30 @@ func HandleURL(input types.Type) (types.Type, error) {
 		return result, nil
 	}
 
-	// 所有pipeline都失败了
+	// 所有pipeline都失败了，保留最后一个错误以便返回错误码
+	if lastErr != nil {
+		return types.Type{}, fmt.Errorf("all pipelines failed for url: %s: %w", input.Url, lastErr)
+	}
 	return types.Type{}, fmt.Errorf("all pipelines failed for url: %s", input.Url)
 }
 
-// HandleURLs 并发处理多个URL
-// 设置超时时间，只返回成功的内容
-func HandleURLs(inputs []types.Type, timeout time.Duration) []types.Type {
-	// 创建结果切片
-	var results []types.Type
+// handoff 按pipeline返回的要求改用指定的pipeline处理（如colly抓到的内容实为PDF），找不到该pipeline时返回原错误
+func handoff(input types.Type, typed *types.Error) (types.Type, error) {
+	p, ok := core.GetPipeline(typed.Handoff)
+	if !ok {
+		return types.Type{}, typed
+	}
+	log.Printf("🔀 %s，改用pipeline(%s)处理, URL: %s", typed.Message, typed.Handoff, input.Url)
+	return p.Process(input)
+}
 
-	// 创建互斥锁，保护results切片
-	var mu sync.Mutex
+// HandleURLs 并发处理多个URL
+// 设置超时时间，返回成功的内容以及失败的URL和原因，两者都按请求中URL的顺序排列：
+// 跨页面去重和预算分配在重复或优先级相同时以靠前的结果为准，顺序不能取决于完成的先后
+func HandleURLs(inputs []types.Type, timeout time.Duration) ([]types.Type, []URLFailure) {
+	// 每个URL的结果写入自己的位置，不需要加锁
+	outcomes := make([]*types.Type, len(inputs))
+	failed := make([]*URLFailure, len(inputs))
 
 	// 创建等待组
 	var wg sync.WaitGroup
@@ -69,13 +108,18 @@ func HandleURLs(inputs []types.Type, timeout time.Duration) []types.Type {
 	// 创建上下文，用于控制超时
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
+	// 各pipeline按同一截止时间结束耗时步骤，超时后不再在后台继续运行
+	deadline, _ := ctx.Deadline()
 
 	// 并发处理每个URL
-	for _, input := range inputs {
+	for i, input := range inputs {
 		wg.Add(1)
 
-		go func(input types.Type) {
+		go func(i int, input types.Type) {
 			defer wg.Done()
+			if input.Options.Deadline.IsZero() {
+				input.Options.Deadline = deadline
+			}
 
 			// 创建通道，用于接收处理结果
 			resultChan := make(chan types.Type, 1)
@@ -94,19 +138,38 @@ func HandleURLs(inputs []types.Type, timeout time.Duration) []types.Type {
 			// 等待处理结果或超时
 			select {
 			case result := <-resultChan:
-				mu.Lock()
-				results = append(results, result)
-				mu.Unlock()
+				outcomes[i] = &result
 			case err := <-errChan:
 				log.Printf("❌ 处理失败: %v, URL: %s", err, input.Url)
+				failure := newURLFailure(input.Url, err)
+				failed[i] = &failure
 			case <-ctx.Done():
 				log.Printf("⏰ 处理超时: %s", input.Url)
+				failed[i] = &URLFailure{Url: input.Url, Code: ErrCodeTimeout, Message: "处理超时"}
 			}
-		}(input)
+		}(i, input)
 	}
 
 	// 等待所有goroutine完成
 	wg.Wait()
 
-	return results
+	var results []types.Type
+	var failures []URLFailure
+	for i := range inputs {
+		if outcomes[i] != nil {
+			results = append(results, *outcomes[i])
+		}
+		if failed[i] != nil {
+			failures = append(failures, *failed[i])
+		}
+	}
+	return results, failures
+}
+
+// newURLFailure 由错误生成失败原因，错误链中有types.Error时使用其错误码和描述
+func newURLFailure(url string, err error) URLFailure {
+	if typed, ok := types.AsError(err); ok {
+		return URLFailure{Url: url, Code: typed.Code, Message: typed.Message}
+	}
+	return URLFailure{Url: url, Code: ErrCodeCrawlFailed, Message: err.Error()}
 }
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"context_crawl/custom/pdf"
+	"context_crawl/types"
+)
+
+func TestHandleURLsKeepsRequestOrder(t *testing.T) {
+	page := "<html><body><h1>%s</h1><p>This page explains how the scheduler assigns jobs to workers and retries failed tasks.</p></body></html>"
+	mux := http.NewServeMux()
+	// 靠前的URL响应更慢，完成顺序与请求顺序相反
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+	mux.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+	// 声明的大小超过PDF下载上限，不可回退的错误直接记为失败
+	tooLarge := func(delay time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Length", strconv.Itoa(128<<10))
+			w.Write(make([]byte, 128<<10))
+		}
+	}
+	mux.HandleFunc("/slow-large.pdf", tooLarge(300*time.Millisecond))
+	mux.HandleFunc("/fast-large.pdf", tooLarge(0))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	pdf.SetMaxDownloadSize(64 << 10)
+	defer pdf.SetMaxDownloadSize(0)
+
+	var inputs []types.Type
+	for _, path := range []string{"/slow-large.pdf", "/slow", "/fast-large.pdf", "/fast"} {
+		inputs = append(inputs, types.Type{Url: server.URL + path})
+	}
+	results, failures := HandleURLs(inputs, 10*time.Second)
+
+	if len(results) != 2 || results[0].Url != server.URL+"/slow" || results[1].Url != server.URL+"/fast" {
+		var urls []string
+		for _, r := range results {
+			urls = append(urls, r.Url)
+		}
+		t.Errorf("results = %v, want /slow then /fast", urls)
+	}
+	if len(failures) != 2 || failures[0].Url != server.URL+"/slow-large.pdf" || failures[1].Url != server.URL+"/fast-large.pdf" {
+		t.Errorf("failures = %+v, want /slow-large.pdf then /fast-large.pdf", failures)
+	}
+}
@@ -2,7 +2,43 @@ package types
 
 // 定义输入输出通用类，关于网页的处理无外乎网址 和 文本
 type Type struct {
-	Url     string            // URL
-	Text    string            // 任意类型的文本
-	CodeMap map[string]string // 代码映射，用于存储代码占位符和实际代码内容的映射
+	Url      string            // URL
+	Text     string            // 任意类型的文本
+	CodeMap  map[string]string // 代码映射，用于存储代码占位符和实际代码内容的映射
+	CodeLang map[string]string // 代码块占位符对应的语言，如Markdown围栏代码块的 ```go，未知时没有该项
+	Options  Options           // 请求级别的处理选项，随Type在各组件间传递
+	Outlinks []Outlink         // 页面中保留下来的超链接，序号与正文中的引用标记一一对应
+	Images   []Image           // 页面中的内容图片（已过滤装饰性图片）
+	Chunks   []Chunk           // 分块结果，Text为其格式化后的文本
+	Summary  string            // 抽取式摘要，开启Options.Summary时生成
+	Keywords []string          // 关键短语，开启Options.Summary时生成
+	Metadata map[string]string // 文档元数据，如PDF信息字典中的标题、作者、创建时间
+	Toc      []TocEntry        // 文档目录，如PDF的书签（大纲）
+}
+
+// Outlink 页面中的一个超链接
+type Outlink struct {
+	Index    int    // 正文中引用标记的序号，如 [1]
+	Url      string // 解析为绝对地址后的链接
+	Text     string // 锚文本
+	Rel      string // a标签的rel属性，如 nofollow
+	Internal bool   // 是否与当前页面同域
+}
+
+// Image 页面中的一张内容图片
+type Image struct {
+	Src     string   // 解析为绝对地址后的图片地址
+	Srcset  []string // srcset中的候选地址（绝对地址）
+	Alt     string   // alt文本
+	Caption string   // 所在figure的figcaption
+	Heading string   // 图片之前最近的标题
+	Width   int      // 声明的宽度，未知为0
+	Height  int      // 声明的高度，未知为0
+}
+
+// TocEntry 文档目录中的一项
+type TocEntry struct {
+	Level int    // 层级，从1开始
+	Title string // 标题
+	Page  int    // 所在页码，未知为0
 }
@@ -1,7 +1,66 @@
 // ============== chunk 统一 输入、输出、接口规范 ==============
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Chunker 接口定义分块组件的统一行为
 type Chunker interface {
 	Chunk(Type) (Type, error)
 }
+
+// Chunk 单个分块
+type Chunk struct {
+	Text           string   // 分块文本（代码块为代码原文）
+	Score          float64  // 质量评分
+	IsCode         bool     // 是否为代码块
+	Lang           string   // 代码块的语言，未知为空
+	HeadingPath    []string // 所在章节的标题路径，如 [Install Linux Troubleshooting]
+	Fingerprint    uint64   // 文本的SimHash指纹，用于近重复判断
+	HasFingerprint bool     // Fingerprint是否有效；过短的分块没有指纹，不参与近重复判断
+	Sources        []string // 包含该分块内容的所有URL，近重复的分块合并后会有多个
+	PageStart      int      // 分块起始页码（从1开始），没有页码的文档为0
+	PageEnd        int      // 分块结束页码
+}
+
+// PageMarker 清洗后的文本中标记新一页开始的占位符，单独占一行
+func PageMarker(page int) string {
+	return fmt.Sprintf("@PAGE_%d@", page)
+}
+
+// EmptyChunkText 没有任何有效分块时返回的提示
+const EmptyChunkText = "查询结果为空，当前链接中无有效信息，请尝试其他关键词或者其他链接。"
+
+// FormatChunks 将分块格式化为返回给调用方的文本
+func FormatChunks(chunks []Chunk) string {
+	return FormatChunksFrom(chunks, 0)
+}
+
+// FormatChunksFrom 与FormatChunks相同，分块编号从offset+1开始，用于分页返回
+func FormatChunksFrom(chunks []Chunk, offset int) string {
+	var organizedText strings.Builder
+	for i, chunk := range chunks {
+		section := ""
+		if len(chunk.HeadingPath) > 0 {
+			section = " section:" + strings.Join(chunk.HeadingPath, " > ")
+		}
+		if chunk.PageStart > 0 {
+			if chunk.PageEnd > chunk.PageStart {
+				section += fmt.Sprintf(" pages:%d-%d", chunk.PageStart, chunk.PageEnd)
+			} else {
+				section += fmt.Sprintf(" page:%d", chunk.PageStart)
+			}
+		}
+		if chunk.IsCode && chunk.Lang != "" {
+			section = " lang:" + chunk.Lang + section
+		}
+		if len(chunk.Sources) > 1 {
+			section += " sources:" + strings.Join(chunk.Sources, ", ")
+		}
+		organizedText.WriteString(fmt.Sprintf("### chunk %d (recall_score:%.3f is_code:%t%s):\n", offset+i+1, chunk.Score, chunk.IsCode, section))
+		organizedText.WriteString(chunk.Text + "\n\n")
+	}
+	return organizedText.String()
+}
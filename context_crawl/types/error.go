@@ -0,0 +1,51 @@
+// ================ error.go 带错误码的处理错误 =====================
+package types
+
+import "errors"
+
+// Error 组件返回的带错误码的错误，service层据此决定是否继续尝试其他pipeline，并在响应中返回错误码
+type Error struct {
+	Code       string // 错误码，如 pdf_encrypted
+	Message    string // 面向用户的错误描述
+	NoFallback bool   // 其他pipeline同样无法处理（如加密文件、超过大小上限），不再尝试保底pipeline
+	Handoff    string // 应交给该名称的pipeline处理（如链接内容实为PDF），service层改用它重试
+	Err        error  // 原始错误，可为nil
+}
+
+// NewError 创建一个Error
+func NewError(code, message string, noFallback bool, err error) *Error {
+	return &Error{
+		Code:       code,
+		Message:    message,
+		NoFallback: noFallback,
+		Err:        err,
+	}
+}
+
+// NewHandoffError 创建一个要求改用指定pipeline处理的Error，pipeline为core中注册的名称
+func NewHandoffError(code, message, pipeline string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+		Handoff: pipeline,
+	}
+}
+
+// Error 实现error接口，格式为 "错误码: 描述"
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Unwrap 返回原始错误，支持errors.Is/errors.As
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// AsError 从错误链中取出*Error
+func AsError(err error) (*Error, bool) {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed, true
+	}
+	return nil, false
+}
@@ -0,0 +1,45 @@
+// ================ 请求选项：由接口参数转换而来，供各组件按需读取 =====================
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// Options 定义单次请求可调整的处理行为，零值即默认行为
+type Options struct {
+	PreserveLinks     bool      // 保留超链接：正文中以 [n] 标记锚文本，并返回outlinks列表
+	ExtractImages     bool      // 提取内容图片，返回images列表
+	ImagePlaceholders bool      // 在正文中图片所在位置插入 ![alt](src) 占位
+	Selector          string    // 只抽取该CSS选择器命中的区域，未命中时使用完整页面
+	ChunkSize         int       // 分块大小上限，0表示使用分块器的默认值
+	ChunkOverlap      *int      // 相邻分块的重叠长度，nil表示使用分块器的默认值
+	ChunkUnit         string    // 分块计数单位：rune / token，空表示使用分块器的默认值
+	Summary           bool      // 生成页面摘要和关键词
+	KeepDuplicates    bool      // 保留页面内重复的分块，默认只保留第一次出现的
+	Simplified        bool      // 正文繁体转简体
+	Pages             string    // 只处理这些页（PDF），如 "1-5,8,12-"，空表示全部
+	Password          string    // 加密PDF的打开密码
+	Deadline          time.Time // 处理截止时间，由service层按请求超时设置，零值表示不限制
+}
+
+// Context 返回在Deadline到期时取消的context，供抓取、渲染等耗时步骤使用
+func (o Options) Context() (context.Context, context.CancelFunc) {
+	if o.Deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), o.Deadline)
+}
+
+// Reserve 返回截止时间提前d的选项，为后面的步骤预留时间；没有截止时间时原样返回
+func (o Options) Reserve(d time.Duration) Options {
+	if !o.Deadline.IsZero() {
+		o.Deadline = o.Deadline.Add(-d)
+	}
+	return o
+}
+
+// Expired 判断截止时间是否已过，没有截止时间时总是false
+func (o Options) Expired() bool {
+	return !o.Deadline.IsZero() && !time.Now().Before(o.Deadline)
+}
@@ -0,0 +1,13 @@
+// ================ score.go 分块质量评分接口规范 =====================
+package types
+
+// Scorer 接口定义分块质量评分的统一行为，返回值范围为[0, 1]
+// 近重复不在评分中处理，由分块之后的SimHash去重统一负责
+type Scorer interface {
+	Score(text string) float64
+}
+
+// LinkScorer 评分器可选实现的接口：分块器会传入页面的outlinks，评分器据此按锚文本计算链接密度
+type LinkScorer interface {
+	ScoreWithLinks(text string, outlinks []Outlink) float64
+}
@@ -7,7 +7,8 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	Server ServerConfig `yaml:"server"`
+	Server       ServerConfig       `yaml:"server"`
+	ContextCrawl ContextCrawlConfig `yaml:"context_crawl"`
 }
 
 // ServerConfig 服务器配置
@@ -16,6 +17,16 @@ type ServerConfig struct {
 	Port int    `yaml:"port"`
 }
 
+// ContextCrawlConfig 网页爬取服务配置
+type ContextCrawlConfig struct {
+	SiteRules    []SiteRule `yaml:"site_rules"`      // 按站点定制的抽取规则
+	Dict         string     `yaml:"dict"`            // 中文分词的完整词典路径（jieba的dict.txt格式），替换内置词典
+	HMMEmit      string     `yaml:"hmm_emit"`        // 中文分词训练好的HMM发射概率路径，替换按词典估计的参数
+	UserDict     string     `yaml:"user_dict"`       // 中文分词的用户词典路径
+	PDFMaxSizeMB int        `yaml:"pdf_max_size_mb"` // PDF下载大小上限（MB），为0时使用默认的50MB
+	Mailto       string     `yaml:"mailto"`          // 请求Crossref、DataCite时附带的联系邮箱
+}
+
 // LoadConfig 加载配置文件
 func LoadConfig(filePath string) (*Config, error) {
 	// 读取配置文件
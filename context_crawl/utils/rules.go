@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// 渲染方式
+const (
+	RenderStatic  = "static"  // 直接抓取静态HTML（默认）
+	RenderDynamic = "dynamic" // 使用浏览器渲染后再抓取
+	RenderAuto    = "auto"    // 先静态抓取，检测到JS框架页面时改用浏览器渲染
+)
+
+// SiteRule 单个站点的抽取规则，按域名或URL正则匹配
+type SiteRule struct {
+	Domain           string            `yaml:"domain"`            // 域名，同时匹配其子域名
+	Pattern          string            `yaml:"pattern"`           // URL正则，可选
+	ContentSelectors []string          `yaml:"content_selectors"` // 正文所在元素的CSS选择器，按顺序取第一个命中的
+	RemoveSelectors  []string          `yaml:"remove_selectors"`  // 需要移除的元素的CSS选择器
+	Render           string            `yaml:"render"`            // 渲染方式：static / dynamic / auto
+	WaitSelector     string            `yaml:"wait_selector"`     // 浏览器渲染时等待出现的元素
+	Headers          map[string]string `yaml:"headers"`           // 额外的请求头
+	PDFPassword      string            `yaml:"pdf_password"`      // 该站点加密PDF的打开密码
+
+	pattern *regexp.Regexp
+}
+
+// 维护一个全局的站点规则列表，服务启动时从配置加载
+var (
+	siteRules   []SiteRule
+	siteRulesMu sync.RWMutex
+)
+
+// SetSiteRules 设置站点规则，非法的URL正则或渲染方式会被跳过
+func SetSiteRules(rules []SiteRule) {
+	compiled := make([]SiteRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Domain == "" && rule.Pattern == "" {
+			log.Printf("⚠️ 站点规则缺少domain和pattern，已跳过")
+			continue
+		}
+		if rule.Pattern != "" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				log.Printf("⚠️ 站点规则的pattern无效: %s, %v", rule.Pattern, err)
+				continue
+			}
+			rule.pattern = re
+		}
+		switch rule.Render = strings.ToLower(strings.TrimSpace(rule.Render)); rule.Render {
+		case "":
+			rule.Render = RenderStatic
+		case RenderStatic, RenderDynamic, RenderAuto:
+		default:
+			log.Printf("⚠️ 站点规则的render无效: %s（可选 static / dynamic / auto），已跳过", rule.Render)
+			continue
+		}
+		rule.Domain = strings.ToLower(strings.TrimPrefix(rule.Domain, "www."))
+		compiled = append(compiled, rule)
+	}
+
+	siteRulesMu.Lock()
+	siteRules = compiled
+	siteRulesMu.Unlock()
+}
+
+// MatchSiteRule 返回第一个匹配URL的站点规则，按配置中的顺序匹配
+func MatchSiteRule(rawURL string) (*SiteRule, bool) {
+	siteRulesMu.RLock()
+	defer siteRulesMu.RUnlock()
+
+	host := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		host = strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+	}
+
+	for i := range siteRules {
+		rule := &siteRules[i]
+		if rule.Domain != "" && host != rule.Domain && !strings.HasSuffix(host, "."+rule.Domain) {
+			continue
+		}
+		if rule.pattern != nil && !rule.pattern.MatchString(rawURL) {
+			continue
+		}
+		return rule, true
+	}
+	return nil, false
+}
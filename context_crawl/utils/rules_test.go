@@ -0,0 +1,42 @@
+package utils
+
+import "testing"
+
+func TestSetSiteRules(t *testing.T) {
+	SetSiteRules([]SiteRule{
+		{Domain: "www.Static.com"},
+		{Domain: "dynamic.com", Render: " Dynamic "},
+		{Domain: "auto.com", Render: "auto"},
+		{Domain: "typo.com", Render: "dynamc"},
+		{Domain: "badpattern.com", Pattern: "("},
+		{Pattern: `^https://docs\.example\.org/v\d+/`, Render: "auto"},
+		{Render: "static"},
+	})
+	defer SetSiteRules(nil)
+
+	tests := []struct {
+		url    string
+		found  bool
+		render string
+	}{
+		{"https://static.com/page", true, RenderStatic},
+		{"https://blog.static.com/page", true, RenderStatic},
+		{"https://www.dynamic.com/", true, RenderDynamic},
+		{"https://auto.com/x", true, RenderAuto},
+		{"https://typo.com/x", false, ""},
+		{"https://badpattern.com/x", false, ""},
+		{"https://docs.example.org/v2/intro", true, RenderAuto},
+		{"https://docs.example.org/latest/intro", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			rule, found := MatchSiteRule(tt.url)
+			if found != tt.found {
+				t.Fatalf("MatchSiteRule(%q) found = %v, want %v", tt.url, found, tt.found)
+			}
+			if found && rule.Render != tt.render {
+				t.Errorf("MatchSiteRule(%q) render = %q, want %q", tt.url, rule.Render, tt.render)
+			}
+		})
+	}
+}
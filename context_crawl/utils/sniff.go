@@ -0,0 +1,14 @@
+package utils
+
+import "bytes"
+
+// PDFSniffLength 识别PDF时读取的文件头长度，%PDF- 标记之前允许有少量无关字节
+const PDFSniffLength = 1024
+
+// IsPDFContent 根据文件头判断内容是否为PDF：文件开头附近出现 %PDF- 标记
+func IsPDFContent(head []byte) bool {
+	if len(head) > PDFSniffLength {
+		head = head[:PDFSniffLength]
+	}
+	return bytes.Contains(head, []byte("%PDF-"))
+}
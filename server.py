@@ -25,6 +25,7 @@ mcp_config = config['mcp']
 host_1 = context_crawl_config['host']
 port_1 = context_crawl_config['port']
 crawl_server_api = f"http://{host_1}:{port_1}/crawl"
+crawl_next_api = f"http://{host_1}:{port_1}/crawl/next"
 
 host_2 = links_search_config['host']
 port_2 = links_search_config['port']
@@ -76,35 +77,88 @@ async def get_links(query: str, count: int = 5) -> str:
 
 
 @mcp.tool()
-async def get_page_content(urls: List[str]) -> str:
+async def get_page_content(urls: List[str], skim: bool = False, page_size: int = 0) -> str:
     """
     爬取指定URL的网页完整内容，这在已经从摘要中捕捉到重要信息，想要进一步了解更加全面的内容时非常有用
     Args:
         urls: 你要全文浏览的URL列表，例如 ["https://www.baidu.com", "https://www.google.com"]
+        skim: 为True时只返回每个页面的摘要和关键词，用于快速判断是否值得阅读全文
+        page_size: 每个URL每页返回的分块数，0表示一次返回全部；长文档（如PDF）建议设为10~20，
+                   内容未读完时结果中会给出cursor，使用get_next_page工具继续阅读
     Returns:
-        网页的完整内容
+        网页的完整内容，skim为True时为摘要和关键词
     """
+    payload = {"urls": urls, "summary": skim}
+    if page_size > 0:
+        payload["page_size"] = page_size
     async with aiohttp.ClientSession() as session:
         async with session.post(
             crawl_server_api,
-            json={"urls": urls}
+            json=payload
         ) as resp:
             if resp.status != 200:
                 raise RuntimeError(f"Crawl API failed: {resp.status}")
             data = await resp.json()
             results = data["data"].get("results", [])
+            errors = data["data"].get("errors", [])
 
-    if not results:
+    if not results and not errors:
         return "查询结果为空，当前链接中无有效信息，请尝试其他关键词或者其他链接。"
 
     text_list = []
     for page in results:
         url = page.get("url", "")
+        if skim:
+            summary = page.get("summary", "")
+            keywords = "、".join(page.get("keywords", []))
+            text_list.append(f"URL: {url}\n摘要: {summary}\n关键词: {keywords}")
+            continue
         text = page.get("text", "")
-        text_list.append(f"URL: {url}\n{text}")
+        text_list.append(f"URL: {url}\n{text}{next_page_hint(page)}")
+    for err in errors:
+        text_list.append(f"URL: {err.get('url', '')}\n获取失败（{err.get('code', '')}）: {err.get('message', '')}")
 
     return "\n\n===\n\n".join(text_list)
 
+
+@mcp.tool()
+async def get_next_page(cursor: str, page_size: int = 0) -> str:
+    """
+    继续阅读get_page_content或上一次get_next_page分页返回的长文档，不会重新抓取网页
+    Args:
+        cursor: 上一页结果末尾给出的cursor
+        page_size: 本页返回的分块数，0表示与上一页相同
+    Returns:
+        文档下一页的内容，分块编号接续上一页；还有后续内容时末尾给出新的cursor
+    """
+    payload = {"cursor": cursor}
+    if page_size > 0:
+        payload["page_size"] = page_size
+    async with aiohttp.ClientSession() as session:
+        async with session.post(
+            crawl_next_api,
+            json=payload
+        ) as resp:
+            # 游标过期或结果已被淘汰时需要重新抓取
+            if resp.status == 404:
+                data = await resp.json()
+                return f"翻页失败: {data.get('msg', '')}。请使用get_page_content重新获取该链接的内容。"
+            if resp.status != 200:
+                raise RuntimeError(f"Crawl next API failed: {resp.status}")
+            data = await resp.json()
+            page = data["data"]
+
+    return f"URL: {page.get('url', '')}\n{page.get('text', '')}{next_page_hint(page)}"
+
+
+def next_page_hint(page: Dict[str, Any]) -> str:
+    """分页结果还有后续内容时，提示调用get_next_page和所需的cursor"""
+    cursor = page.get("next_cursor", "")
+    if not cursor:
+        return ""
+    return (f"\n\n（共 {page.get('total_chunks', 0)} 个分块，还有后续内容，"
+            f"可使用get_next_page工具并传入cursor继续阅读）\ncursor: {cursor}")
+
 if __name__ == '__main__':
     print("MCP:web-search is running on port 8006.")
     mcp.run(transport="sse")